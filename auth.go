@@ -0,0 +1,143 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sessions for keyless instances with bot protection: a Turnstile challenge is solved and exchanged at /session
+// for a token, sent as "Authorization: Bearer token" with the jobs.
+
+// Challenge describes the bot protection challenge of an instance.
+type Challenge struct {
+	API     string //Instance api url.
+	Sitekey string //Turnstile sitekey from the server info.
+	Code    string //Error code that required the challenge, like error.api.auth.jwt.missing. Empty when refreshing a session about to expire.
+}
+
+// Sessions are refreshed before Run when they expire in less than this.
+const sessionRefreshMargin = 30 * time.Second
+
+// ChallengeSolver solves the Turnstile challenge of an instance, with a headless browser or a remote solving service,
+// and returns the Turnstile response token. Set it with WithChallengeSolver.
+type ChallengeSolver interface {
+	SolveChallenge(ctx context.Context, challenge Challenge) (string, error)
+}
+
+// ChallengeSolverFunc is a function used as a ChallengeSolver.
+type ChallengeSolverFunc func(ctx context.Context, challenge Challenge) (string, error)
+
+func (f ChallengeSolverFunc) SolveChallenge(ctx context.Context, challenge Challenge) (string, error) {
+	return f(ctx, challenge)
+}
+
+// WithChallengeSolver sets the solver used when an instance requires a session. Default: none, the job fails.
+func WithChallengeSolver(solver ChallengeSolver) Option {
+	return func(c *Cobalt) {
+		c.solver = solver
+	}
+}
+
+// session holds the token of a Cobalt, shared by its copies.
+type session struct {
+	mu      sync.Mutex
+	api     string //Instance the token is from, it isn't sent to others.
+	token   string
+	expires time.Time //Zero if the instance didn't say.
+}
+
+// get returns the token for api, or an empty string if there's none or it expired.
+func (s *session) get(api string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.api != api || !s.expires.IsZero() && time.Now().After(s.expires) {
+		return ""
+	}
+	return s.token
+}
+
+// expiring reports if there's a token for api expiring in less than sessionRefreshMargin, or already expired.
+func (s *session) expiring(api string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.api == api && s.token != "" && !s.expires.IsZero() && time.Until(s.expires) < sessionRefreshMargin
+}
+
+func (s *session) set(api, token string, expires time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.api, s.token, s.expires = api, token, expires
+}
+
+// needsSession reports if the error code means the job needs a (new) session.
+func needsSession(code string) bool {
+	return strings.HasPrefix(code, "error.api.auth.jwt.") || strings.HasPrefix(code, "error.api.auth.turnstile.")
+}
+
+// newSession solves the challenge of the instance and exchanges it for a session token.
+func (c *Cobalt) newSession(ctx context.Context, api string, info *ServerInfo, code string) error {
+	if info.Cobalt.TurnstileKey == "" {
+		//The health check may have been skipped, the sitekey is in the server info.
+		if fetched, err := c.cachedServerInfo(api); err == nil {
+			info = fetched
+		}
+	}
+	solution, err := c.solver.SolveChallenge(ctx, Challenge{API: api, Sitekey: info.Cobalt.TurnstileKey, Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to solve the challenge of %v: %w", api, err)
+	}
+
+	endpoint, err := url.JoinPath(api, "session")
+	if err != nil {
+		return fmt.Errorf("net/url failed to parse provided url, check it and try again (details: %v, url: %v)", err, api)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create the request to %v: %w", endpoint, err)
+	}
+	req.Header.Add("User-Agent", c.userAgent)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("cf-turnstile-response", solution)
+
+	res, err := c.withTimeout(c.jobTimeout).doInstance(req)
+	if err != nil {
+		return fmt.Errorf("unable to get a session, %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := c.readBody(res)
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Token string `json:"token"`
+		Exp   int    `json:"exp"` //Seconds until the token expires.
+		Error *Error `json:"error"`
+	}
+	if err := c.decodeInstanceJSON(res, body, &response); err != nil {
+		return err
+	}
+	if response.Token == "" {
+		if response.Error != nil {
+			return fmt.Errorf("cobalt refused the session: %w", c.newCobaltError(response.Error, res.StatusCode))
+		}
+		return fmt.Errorf("%w: no session token from %v", ErrUnexpectedResponse, endpoint)
+	}
+	var expires time.Time
+	if response.Exp > 0 {
+		expires = time.Now().Add(time.Duration(response.Exp) * time.Second)
+	}
+	c.session.set(api, response.Token, expires)
+	return nil
+}
@@ -0,0 +1,158 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// sessionServer is a keyless instance requiring a session, solved by the turnstile response "solved". Sessions last exp seconds.
+func sessionServer(exp int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4","turnstileSitekey":"0x4AAA"},"git":{}}`))
+		case r.URL.Path == "/session":
+			if r.Header.Get("cf-turnstile-response") != "solved" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"status":"error","error":{"code":"error.api.auth.turnstile.invalid"}}`))
+				return
+			}
+			fmt.Fprintf(w, `{"token":"session-token","exp":%v}`, exp)
+		case r.Header.Get("Authorization") != "Bearer session-token":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":"error","error":{"code":"error.api.auth.jwt.missing"}}`))
+		default:
+			w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+		}
+	}))
+}
+
+func TestChallengeSolver(t *testing.T) {
+	server := sessionServer(1800)
+	defer server.Close()
+
+	var challenges []Challenge
+	solver := ChallengeSolverFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+		challenges = append(challenges, challenge)
+		return "solved", nil
+	})
+	client := New(WithAPI(server.URL), WithChallengeSolver(solver))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	for i := 0; i < 2; i++ {
+		media, err := client.Run(settings)
+		if err != nil {
+			t.Fatalf("run %v failed: %v", i, err)
+		}
+		if media.Status != "tunnel" {
+			t.Errorf("unexpected response %+v", media)
+		}
+	}
+	if len(challenges) != 1 {
+		t.Fatalf("expected the session to be reused, solved %v challenges", len(challenges))
+	}
+	if challenges[0].Sitekey != "0x4AAA" || challenges[0].Code != "error.api.auth.jwt.missing" {
+		t.Errorf("unexpected challenge %+v", challenges[0])
+	}
+}
+
+func TestChallengeSolverErrors(t *testing.T) {
+	server := sessionServer(1800)
+	defer server.Close()
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	if _, err := New(WithAPI(server.URL)).Run(settings); err == nil {
+		t.Errorf("expected an error without a solver")
+	}
+
+	failed := errors.New("no browser")
+	client := New(WithAPI(server.URL), WithChallengeSolver(ChallengeSolverFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+		return "", failed
+	})))
+	if _, err := client.Run(settings); !errors.Is(err, failed) {
+		t.Errorf("expected the solver error, got %v", err)
+	}
+
+	client = New(WithAPI(server.URL), WithChallengeSolver(ChallengeSolverFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+		return "wrong", nil
+	})))
+	if _, err := client.Run(settings); err == nil {
+		t.Errorf("expected an error for a rejected challenge")
+	}
+}
+
+func TestChallengeSolverContext(t *testing.T) {
+	server := sessionServer(1800)
+	defer server.Close()
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	client := New(WithAPI(server.URL), WithChallengeSolver(ChallengeSolverFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.RunContext(ctx, settings); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the solver to get the context of the job, got %v", err)
+	}
+}
+
+func TestSessionRefresh(t *testing.T) {
+	server := sessionServer(10)
+	defer server.Close()
+
+	var codes []string
+	client := New(WithAPI(server.URL), WithChallengeSolver(ChallengeSolverFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+		codes = append(codes, challenge.Code)
+		return "solved", nil
+	})))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	for i := 0; i < 2; i++ {
+		if _, err := client.Run(settings); err != nil {
+			t.Fatalf("run %v failed: %v", i, err)
+		}
+	}
+	if len(codes) != 2 || codes[1] != "" {
+		t.Errorf("expected the session about to expire to be refreshed before the job, got challenges %q", codes)
+	}
+}
+
+func TestSessionRetriedOnce(t *testing.T) {
+	var jobs int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+		case r.URL.Path == "/session":
+			w.Write([]byte(`{"token":"session-token","exp":1800}`))
+		default:
+			jobs++
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":"error","error":{"code":"error.api.auth.jwt.expired"}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithAPI(server.URL), WithChallengeSolver(ChallengeSolverFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+		return "solved", nil
+	})))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	if _, err := client.Run(settings); err == nil {
+		t.Fatalf("expected the expired session error")
+	}
+	if jobs != 2 {
+		t.Errorf("expected the job to be retried exactly once, sent %v times", jobs)
+	}
+}
@@ -0,0 +1,123 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Circuit breaker for instances: after some consecutive failed requests to an instance host, requests to it fail
+// immediately for a cooldown, instead of waiting for the timeout every time. After the cooldown a single request
+// is let through to probe the instance, closing the circuit if it works.
+
+// ErrCircuitOpen is returned without contacting the instance when the circuit breaker of its host is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Default circuit breaker settings, see WithCircuitBreaker.
+const (
+	DefaultBreakerThreshold = 5           //Consecutive failures opening the circuit.
+	DefaultBreakerCooldown  = time.Minute //Time the circuit stays open before probing the instance again.
+)
+
+// defaultBreaker is shared by the package level functions.
+var defaultBreaker = newCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown)
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*circuitState
+}
+
+type circuitState struct {
+	failures int
+	openedAt time.Time
+	probing  bool //A request is probing the instance after the cooldown.
+}
+
+// newCircuitBreaker returns nil, a disabled breaker, if threshold is 0 or less.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, hosts: map[string]*circuitState{}}
+}
+
+// allow returns ErrCircuitOpen if requests to host shouldn't be made.
+func (b *circuitBreaker) allow(host string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.hosts[host]
+	if state == nil || state.failures < b.threshold {
+		return nil
+	}
+	if retry := state.openedAt.Add(b.cooldown); time.Now().Before(retry) {
+		return fmt.Errorf("%w: %v failed %v times in a row, retrying after %v", ErrCircuitOpen, host, state.failures, retry.Format(time.TimeOnly))
+	}
+	if state.probing {
+		return fmt.Errorf("%w: %v is being probed", ErrCircuitOpen, host)
+	}
+	state.probing = true
+	return nil
+}
+
+// record counts a request to host, a success closes the circuit and a failure may open it.
+func (b *circuitBreaker) record(host string, success bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		delete(b.hosts, host)
+		return
+	}
+	state := b.hosts[host]
+	if state == nil {
+		state = &circuitState{}
+		b.hosts[host] = state
+	}
+	state.failures++
+	state.probing = false
+	if state.failures >= b.threshold {
+		state.openedAt = time.Now()
+	}
+}
+
+// release lets another request probe host, without counting the request that was probing it.
+func (b *circuitBreaker) release(host string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state := b.hosts[host]; state != nil {
+		state.probing = false
+	}
+}
+
+// doInstance sends a request to a cobalt instance through its circuit breaker. Network errors and 5xx responses are failures,
+// requests canceled by the caller are not counted.
+func (c *Cobalt) doInstance(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if err := c.breaker.allow(host); err != nil {
+		return nil, err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil && (errors.Is(err, context.Canceled) || req.Context().Err() != nil) {
+		c.breaker.release(host)
+		return res, err
+	}
+	c.breaker.record(host, err == nil && res.StatusCode < http.StatusInternalServerError)
+	return res, err
+}
@@ -0,0 +1,110 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var hits atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+	}))
+	defer server.Close()
+
+	client := New(WithAPI(server.URL), WithCircuitBreaker(2, 100*time.Millisecond))
+	if _, err := client.ServerInfo(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the instance error, got %v", err)
+	}
+	requests := hits.Load()
+
+	if _, err := client.ServerInfo(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if hits.Load() != requests {
+		t.Errorf("the instance was contacted with the circuit open")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	healthy.Store(true)
+	if _, err := client.ServerInfo(); err != nil {
+		t.Fatalf("expected the probe to close the circuit, got %v", err)
+	}
+	if _, err := client.ServerInfo(); err != nil {
+		t.Fatalf("expected the circuit to be closed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	breaker := newCircuitBreaker(1, time.Millisecond)
+	breaker.record("example.com", false)
+	if err := breaker.allow("example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+	if err := breaker.allow("other.example.com"); err != nil {
+		t.Errorf("other hosts should not be affected: %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := breaker.allow("example.com"); err != nil {
+		t.Fatalf("expected a probe to be allowed after the cooldown, got %v", err)
+	}
+	if err := breaker.allow("example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected a single probe at a time, got %v", err)
+	}
+	breaker.record("example.com", false)
+	if err := breaker.allow("example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected a failed probe to open the circuit again, got %v", err)
+	}
+
+	disabled := newCircuitBreaker(0, time.Minute)
+	disabled.record("example.com", false)
+	if err := disabled.allow("example.com"); err != nil {
+		t.Errorf("a disabled breaker should allow everything, got %v", err)
+	}
+}
+
+func TestCircuitBreakerCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client := New(WithAPI(server.URL), WithCircuitBreaker(1, time.Millisecond))
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.doInstance(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+	if err := client.breaker.allow(host); err != nil {
+		t.Errorf("a request canceled by the caller should not count as a failure, got %v", err)
+	}
+
+	//A canceled probe lets the next request probe the instance.
+	client.breaker.record(host, false)
+	time.Sleep(time.Millisecond)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.doInstance(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+	if err := client.breaker.allow(host); err != nil {
+		t.Errorf("expected a new probe after a canceled one, got %v", err)
+	}
+}
@@ -0,0 +1,386 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewUsesItsOwnConfiguration(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(CobaltResponse{Status: "tunnel", URL: "https://example.com/file", Filename: "file.mp4"})
+	}))
+	defer server.Close()
+
+	client := New(WithAPI(server.URL), WithAPIKey("secret"))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	media, err := client.Run(settings)
+	if err != nil {
+		t.Fatalf("failed running with a custom instance: %v", err)
+	}
+	if media.Filename != "file.mp4" {
+		t.Errorf("unexpected filename %q", media.Filename)
+	}
+	if authorization != "Api-Key secret" {
+		t.Errorf("expected the client api key, got %q", authorization)
+	}
+	if client.API() != server.URL || CobaltApi == server.URL {
+		t.Errorf("the client api should not change CobaltApi")
+	}
+}
+
+// fakeService shows Service being mocked.
+type fakeService struct{ Service }
+
+func (fakeService) Run(options Settings) (*CobaltResponse, error) {
+	return &CobaltResponse{Status: "redirect", URL: options.Url}, nil
+}
+
+func TestServiceMock(t *testing.T) {
+	var service Service = fakeService{}
+	media, err := service.Run(Settings{Url: "https://example.com"})
+	if err != nil || media.URL != "https://example.com" {
+		t.Fatalf("unexpected result %+v, %v", media, err)
+	}
+}
+
+// fakeClient shows CobaltClient being mocked, with instance discovery working offline.
+type fakeClient struct{ CobaltClient }
+
+func (fakeClient) ServerInfo() (*ServerInfo, error) {
+	return &ServerInfo{Cobalt: CobaltServerInformation{Version: "10.5.4"}}, nil
+}
+
+func (fakeClient) MergeInstanceTrackers(ctx context.Context, trackers ...string) ([]CobaltInstance, error) {
+	return []CobaltInstance{{API: "cobalt.example.com", Version: "10.5.4", APIOnline: true}}, nil
+}
+
+func (fakeClient) Search(ctx context.Context, query string, filters SearchFilters) ([]SearchResult, error) {
+	return []SearchResult{{ID: "dQw4w9WgXcQ", Title: query}}, nil
+}
+
+func TestCobaltClientMock(t *testing.T) {
+	var client CobaltClient = fakeClient{}
+	info, err := client.ServerInfo()
+	if err != nil || info.Cobalt.Version != "10.5.4" {
+		t.Fatalf("unexpected server info %+v, %v", info, err)
+	}
+	instances, err := client.MergeInstanceTrackers(context.Background())
+	if err != nil || len(instances) != 1 {
+		t.Fatalf("unexpected instances %+v, %v", instances, err)
+	}
+	results, err := client.Search(context.Background(), "never gonna give you up", SearchFilters{})
+	if err != nil || len(results) != 1 || results[0].Title != "never gonna give you up" {
+		t.Fatalf("unexpected search results %+v, %v", results, err)
+	}
+}
+
+func TestCobaltTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		switch {
+		case r.URL.Path == "/file":
+			w.Write([]byte("media"))
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+		}
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Timeout: 50 * time.Millisecond}
+	client := New(WithAPI(server.URL), WithHTTPClient(httpClient), WithHealthTimeout(20*time.Millisecond), WithDownloadTimeout(time.Second))
+
+	if _, err := client.ServerInfo(); err == nil {
+		t.Errorf("expected the server info request to time out")
+	}
+	stream, err := client.OpenStream(server.URL+"/file", DownloadOptions{})
+	if err != nil {
+		t.Fatalf("expected the download timeout to replace the client timeout: %v", err)
+	}
+	stream.Close()
+	if httpClient.Timeout != 50*time.Millisecond {
+		t.Errorf("the http client was modified")
+	}
+}
+
+// socks5Proxy is a minimal SOCKS5 server accepting CONNECT with username and password authentication.
+func socks5Proxy(t *testing.T, username, password string) (addr string, connections *atomic.Int32) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	connections = &atomic.Int32{}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				//Greeting: only username and password (0x02).
+				buf := make([]byte, 512)
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil || !bytes.Contains(buf[:buf[1]], []byte{2}) {
+					return
+				}
+				conn.Write([]byte{5, 2})
+				//Authentication.
+				io.ReadFull(conn, buf[:2])
+				user := make([]byte, buf[1])
+				io.ReadFull(conn, user)
+				io.ReadFull(conn, buf[:1])
+				pass := make([]byte, buf[0])
+				io.ReadFull(conn, pass)
+				if string(user) != username || string(pass) != password {
+					conn.Write([]byte{1, 1})
+					return
+				}
+				conn.Write([]byte{1, 0})
+				//Request: only CONNECT to an ip or a hostname.
+				io.ReadFull(conn, buf[:4])
+				var host string
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					name := make([]byte, buf[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				io.ReadFull(conn, buf[:2])
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2])))))
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				connections.Add(1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return listener.Addr().String(), connections
+}
+
+func TestCobaltProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+	addr, connections := socks5Proxy(t, "user", "pass")
+
+	client := New(WithProxy(&url.URL{Scheme: "socks5", Host: addr, User: url.UserPassword("user", "pass")}))
+	stream, err := client.OpenStream(server.URL, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("failed streaming through the proxy: %v", err)
+	}
+	stream.Close()
+	if connections.Load() != 1 {
+		t.Errorf("expected 1 connection through the proxy, got %v", connections.Load())
+	}
+
+	client = New(WithProxy(&url.URL{Scheme: "socks5", Host: addr, User: url.UserPassword("user", "wrong")}))
+	if _, err := client.OpenStream(server.URL, DownloadOptions{}); err == nil {
+		t.Errorf("expected the proxy to reject wrong credentials")
+	}
+	if Client.Transport != defaultTransport {
+		t.Errorf("the proxy should not change Client")
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	if _, err := New(WithUserAgent("myapp/1.0")).ProcessMedia(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "myapp/1.0" {
+		t.Errorf("expected the client user agent, got %q", userAgent)
+	}
+
+	defer SetUserAgent(useragent)
+	SetUserAgent("otherapp/2.0")
+	if _, err := ProcessMedia(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "otherapp/2.0" {
+		t.Errorf("expected the package user agent, got %q", userAgent)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cobalt":{"version":"10.5.4","url":"` + strings.Repeat("a", 1024) + `"},"git":{}}`))
+	}))
+	defer server.Close()
+
+	if _, err := New(WithAPI(server.URL), WithMaxResponseSize(512)).ServerInfo(); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if _, err := New(WithAPI(server.URL)).ServerInfo(); err != nil {
+		t.Errorf("expected the response to fit the default limit, got %v", err)
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	response := `{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	strict := New(WithAPI(server.URL), WithStrictJSON())
+	if _, err := strict.Run(settings); err != nil {
+		t.Fatalf("expected a known schema to be accepted: %v", err)
+	}
+
+	response = `{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4","isHLS":true}`
+	if _, err := strict.Run(settings); !errors.Is(err, ErrSchemaDrift) || !strings.Contains(err.Error(), `"isHLS"`) {
+		t.Errorf("expected ErrSchemaDrift naming the field, got %v", err)
+	}
+	if _, err := New(WithAPI(server.URL)).Run(settings); err != nil {
+		t.Errorf("unknown fields should be ignored without strict mode, got %v", err)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/file":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write([]byte("media"))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+		default:
+			w.Write([]byte(`{"status":"tunnel","url":"` + "http://" + r.Host + `/file","filename":"file.mp3"}`))
+		}
+	}))
+	defer server.Close()
+	client := New(WithAPI(server.URL), WithRequestCoalescing(), WithResponseCache(2, time.Minute), WithServerInfoTTL(time.Millisecond))
+
+	oldAPI := CobaltApi
+	CobaltApi = server.URL
+	defer func() { CobaltApi = oldAPI }()
+	defer SetUserAgent(useragent)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			settings := CreateDefaultSettings()
+			settings.Url = fmt.Sprintf("https://www.youtube.com/watch?v=dQw4w9WgXc%v", i%3)
+			if _, err := client.Run(settings); err != nil {
+				t.Errorf("run failed: %v", err)
+			}
+			if _, err := Run(settings); err != nil {
+				t.Errorf("package level run failed: %v", err)
+			}
+			if _, err := client.ProcessMedia(server.URL + "/file"); err != nil {
+				t.Errorf("processing media failed: %v", err)
+			}
+			if _, err := client.DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL + "/file"}, io.Discard, DownloadOptions{}); err != nil {
+				t.Errorf("download failed: %v", err)
+			}
+			SetUserAgent(fmt.Sprintf("app/%v", i))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConnectionLimits(t *testing.T) {
+	transport := func(c *Cobalt) *http.Transport {
+		t.Helper()
+		transport, ok := c.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("expected an *http.Transport, got %T", c.httpClient.Transport)
+		}
+		return transport
+	}
+
+	if limits := transport(New()); limits.MaxConnsPerHost != DefaultMaxConnsPerHost || limits.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("unexpected default limits %v and %v", limits.MaxConnsPerHost, limits.MaxIdleConnsPerHost)
+	}
+	if limits := transport(New(WithHTTPClient(&http.Client{}))); limits.MaxConnsPerHost != DefaultMaxConnsPerHost {
+		t.Errorf("expected the default limits without a transport, got %v", limits.MaxConnsPerHost)
+	}
+
+	custom := &http.Transport{MaxConnsPerHost: 100}
+	if limits := transport(New(WithHTTPClient(&http.Client{Transport: custom}))); limits != custom {
+		t.Error("expected the transport set with WithHTTPClient to be used as is")
+	}
+	limited := transport(New(WithHTTPClient(&http.Client{Transport: custom}), WithConnectionLimits(2, 1)))
+	if limited == custom || limited.MaxConnsPerHost != 2 || limited.MaxIdleConnsPerHost != 1 || custom.MaxConnsPerHost != 100 {
+		t.Errorf("expected the limits on a copy of the transport, got %v and %v", limited.MaxConnsPerHost, limited.MaxIdleConnsPerHost)
+	}
+}
+
+type recordingTransport struct {
+	header http.Header
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.header = req.Header
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestWithTransport(t *testing.T) {
+	client := &http.Client{Timeout: time.Minute}
+	recorder := &recordingTransport{}
+	c := New(WithHTTPClient(client), WithTransport(recorder))
+	res, err := c.genericHttpRequest("https://cobalt.example.com/", http.MethodGet, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	res.Body.Close()
+	if recorder.header == nil || c.httpClient.Timeout != time.Minute || client.Transport != nil {
+		t.Errorf("expected the transport on a copy of the client, got %T", client.Transport)
+	}
+}
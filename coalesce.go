@@ -0,0 +1,85 @@
+package gobalt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Coalescing of identical jobs: when the same link is sent by several users at once, only one job is submitted to the instance.
+
+// WithRequestCoalescing makes concurrent Run calls for the same url (after NormalizeURL) and Settings, to the same instance,
+// share a single job. Every caller gets its own copy of the response. Default: disabled.
+func WithRequestCoalescing() Option {
+	return func(c *Cobalt) {
+		c.flights = &flightGroup{}
+	}
+}
+
+// flightGroup runs one job per key at a time, callers asking for a key already running wait for its result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a job shared by the callers of flightGroup.do.
+type flight struct {
+	done  chan struct{}
+	media *CobaltResponse
+	err   error
+}
+
+// do runs job for key, or waits for the job already running for it. The job doesn't stop when a caller's context is canceled,
+// since other callers may be waiting for it, but the caller stops waiting. The values of the context of the first caller are kept.
+func (g *flightGroup) do(ctx context.Context, key string, job func(context.Context) (*CobaltResponse, error)) (*CobaltResponse, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		call = &flight{done: make(chan struct{})}
+		if g.calls == nil {
+			g.calls = make(map[string]*flight)
+		}
+		g.calls[key] = call
+		go func() {
+			defer func() {
+				//A panic in the job (a plugin hook, a solver) would crash the program from this goroutine, report it to the callers instead.
+				if recovered := recover(); recovered != nil {
+					call.media, call.err = nil, fmt.Errorf("coalesced job panicked: %v", recovered)
+				}
+				g.mu.Lock()
+				delete(g.calls, key)
+				g.mu.Unlock()
+				close(call.done)
+			}()
+			call.media, call.err = job(context.WithoutCancel(ctx))
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	return call.media.clone(), nil
+}
+
+// jobKey identifies a job by the instance, the normalized url and a hash of the settings.
+func jobKey(api string, options Settings) string {
+	if normalized, err := NormalizeURL(options.Url); err == nil {
+		options.Url = normalized
+	}
+	body, err := json.Marshal(options)
+	if err != nil {
+		//Can't be hashed, not coalesced.
+		return ""
+	}
+	hash := sha256.Sum256(body)
+	return api + " " + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,104 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var jobs atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		jobs.Add(1)
+		<-release
+		w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+	}))
+	defer server.Close()
+	client := New(WithAPI(server.URL), WithRequestCoalescing())
+
+	var wg sync.WaitGroup
+	responses := make([]*CobaltResponse, 5)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			settings := CreateDefaultSettings()
+			settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+			if i%2 == 0 {
+				settings.Url += "&si=tracking"
+			}
+			media, err := client.Run(settings)
+			if err != nil {
+				t.Errorf("run failed: %v", err)
+			}
+			responses[i] = media
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if jobs.Load() != 1 {
+		t.Errorf("expected the concurrent requests to share one job, got %v jobs", jobs.Load())
+	}
+	if responses[0] == nil || responses[1] == nil || responses[0] == responses[1] || responses[0].URL != responses[1].URL {
+		t.Errorf("expected every caller to get its own copy of the response, got %p and %p", responses[0], responses[1])
+	}
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var group flightGroup
+	_, err := group.do(context.Background(), "key", func(context.Context) (*CobaltResponse, error) {
+		panic("broken hook")
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken hook") {
+		t.Errorf("expected the panic as an error, got %v", err)
+	}
+
+	//The key is released, the next caller runs a new job instead of waiting forever.
+	done := make(chan error, 1)
+	go func() {
+		_, err := group.do(context.Background(), "key", func(context.Context) (*CobaltResponse, error) {
+			return &CobaltResponse{Status: "tunnel"}, nil
+		})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the next job to succeed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the key was not released after the panic")
+	}
+}
+
+func TestJobKey(t *testing.T) {
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	tracked := settings
+	tracked.Url = "https://youtu.be/dQw4w9WgXcQ?si=tracking"
+	audio := settings
+	audio.Mode = Audio
+
+	if jobKey("https://a.example", settings) != jobKey("https://a.example", tracked) {
+		t.Error("expected the same key for the same normalized url")
+	}
+	if jobKey("https://a.example", settings) == jobKey("https://a.example", audio) {
+		t.Error("expected another key for other settings")
+	}
+	if jobKey("https://a.example", settings) == jobKey("https://b.example", settings) {
+		t.Error("expected another key for another instance")
+	}
+}
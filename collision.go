@@ -0,0 +1,56 @@
+package gobalt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// What downloads do when the file they save already exists.
+
+type collisionStrategy string
+
+const (
+	CollisionOverwrite collisionStrategy = "overwrite" //The existing file is replaced. Default.
+	CollisionSkip      collisionStrategy = "skip"      //The existing file is kept and nothing is downloaded, or even requested when the name doesn't depend on the response.
+	CollisionRename    collisionStrategy = "rename"    //The file is saved as "name (1).ext", or the first number free.
+)
+
+// collisionPath returns where a file meant for path is saved with the strategy,
+// and the strategy applied if path already exists, or an empty string if it doesn't.
+func collisionPath(path string, strategy collisionStrategy) (string, collisionStrategy) {
+	if !fileExists(path) {
+		return path, ""
+	}
+	switch strategy {
+	case CollisionSkip:
+		return path, CollisionSkip
+	case CollisionRename:
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		for i := 1; ; i++ {
+			//A .part file is a download in progress that will take the name.
+			renamed := fmt.Sprintf("%v (%v)%v", base, i, ext)
+			if !fileExists(renamed) && !fileExists(renamed+".part") {
+				return renamed, CollisionRename
+			}
+		}
+	}
+	return path, CollisionOverwrite
+}
+
+// skippedResult is the result of a download skipped because path exists.
+func skippedResult(path string) *DownloadResult {
+	result := &DownloadResult{Path: path, Collision: CollisionSkip}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	return result
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
@@ -0,0 +1,99 @@
+package gobalt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadCollisions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new media"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "video.mp4")
+	media := &CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "video.mp4"}
+
+	tests := []struct {
+		strategy  collisionStrategy
+		path      string
+		collision collisionStrategy
+		size      int64
+		content   string
+	}{
+		{CollisionSkip, "video.mp4", CollisionSkip, 3, "old"},
+		{CollisionRename, "video (1).mp4", CollisionRename, 9, "old"},
+		{CollisionRename, "video (2).mp4", CollisionRename, 9, "old"},
+		{"", "video.mp4", CollisionOverwrite, 9, "new media"},
+	}
+	os.WriteFile(existing, []byte("old"), 0o644)
+	for _, test := range tests {
+		result, err := Download(media, DownloadOptions{Directory: dir, Collision: test.strategy})
+		if err != nil {
+			t.Fatalf("download with %q failed: %v", test.strategy, err)
+		}
+		if result.Path != filepath.Join(dir, test.path) || result.Collision != test.collision || result.Size != test.size {
+			t.Errorf("unexpected result with %q: %+v", test.strategy, result)
+		}
+		if data, _ := os.ReadFile(existing); string(data) != test.content {
+			t.Errorf("expected the existing file to contain %q with %q, got %q", test.content, test.strategy, data)
+		}
+	}
+
+	result, err := Download(media, DownloadOptions{Directory: dir, Filename: "other.mp4", Collision: CollisionSkip})
+	if err != nil || result.Collision != "" || result.Path != filepath.Join(dir, "other.mp4") {
+		t.Errorf("expected a download without collision, got %+v, %v", result, err)
+	}
+}
+
+func TestDownloadSkipsBeforeRequesting(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("new media"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "video.mp4"), []byte("old"), 0o644)
+	os.WriteFile(filepath.Join(dir, "Title [1080p].mp4"), []byte("old"), 0o644)
+	skipped := []struct {
+		media   *CobaltResponse
+		options DownloadOptions
+	}{
+		{&CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "video.mp4"}, DownloadOptions{}},
+		{&CobaltResponse{Status: "tunnel", URL: server.URL}, DownloadOptions{Filename: "video.mp4"}},
+		{&CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "Title (1080p, h264).mp4"}, DownloadOptions{Template: "{title} [{quality}].{ext}"}},
+	}
+	for _, test := range skipped {
+		test.options.Directory, test.options.Collision = dir, CollisionSkip
+		result, err := Download(test.media, test.options)
+		if err != nil || result.Collision != CollisionSkip || result.Size != 3 {
+			t.Errorf("expected the download of %+v to be skipped, got %+v, %v", test.media, result, err)
+		}
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected the media not to be requested, got %v requests", requests.Load())
+	}
+
+	//Without an extension the name depends on the content type, the media is requested to know it.
+	result, err := Download(&CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "video"}, DownloadOptions{Directory: dir, Collision: CollisionSkip})
+	if err != nil || result.Collision != CollisionSkip || requests.Load() != 1 {
+		t.Errorf("expected the download to be skipped after requesting the media, got %+v, %v", result, err)
+	}
+}
+
+func TestCollisionPathSkipsPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.mp3"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "a (1).mp3.part"), nil, 0o644)
+	if path, collision := collisionPath(filepath.Join(dir, "a.mp3"), CollisionRename); path != filepath.Join(dir, "a (2).mp3") || collision != CollisionRename {
+		t.Errorf("unexpected path %v, %v", path, collision)
+	}
+}
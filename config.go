@@ -0,0 +1,233 @@
+package gobalt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuration files, so CLI and daemon users can set the instance, credentials and default settings declaratively.
+// LoadConfig reads JSON files:
+//
+//	{
+//		"api": "https://cobalt.example.com",
+//		"apiKey": "secret",
+//		"proxy": "socks5://127.0.0.1:1080",
+//		"jobTimeout": "30s",
+//		"settings": {"videoQuality": "max", "youtubeVideoCodec": "vp9"}
+//	}
+//
+// The settings use the names of the cobalt api, see the json tags of Settings. YAML and TOML files with the same keys are
+// read by the github.com/lostdusty/gobalt/v2/config module, it's separate so gobalt itself doesn't depend on their parsers.
+
+// ErrInvalidConfig is returned by LoadConfig and ParseConfig for files they can't read as a configuration.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config is read by LoadConfig or ParseConfig. Unset timeouts are nil, they keep the defaults of New.
+type Config struct {
+	Settings Settings //CreateDefaultSettings with the values of the file.
+
+	API       string   //See WithAPI.
+	APIKey    string   //See WithAPIKey.
+	Proxy     *url.URL //See WithProxy.
+	UserAgent string   //See WithUserAgent.
+	Language  string   //See WithLanguage.
+
+	HealthTimeout   *time.Duration //See WithHealthTimeout.
+	JobTimeout      *time.Duration //See WithJobTimeout.
+	DownloadTimeout *time.Duration //See WithDownloadTimeout.
+}
+
+// configFile is the layout of the file, before the values are checked.
+type configFile struct {
+	API             string          `json:"api"`
+	APIKey          string          `json:"apiKey"`
+	Proxy           string          `json:"proxy"`
+	UserAgent       string          `json:"userAgent"`
+	Language        string          `json:"language"`
+	HealthTimeout   *configDuration `json:"healthTimeout"`
+	JobTimeout      *configDuration `json:"jobTimeout"`
+	DownloadTimeout *configDuration `json:"downloadTimeout"`
+	Settings        json.RawMessage `json:"settings"`
+}
+
+// configDuration is a duration like "1m30s", or a number of seconds.
+type configDuration time.Duration
+
+func (d *configDuration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		text = string(data)
+	}
+	duration, err := parseConfigDuration(text)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = configDuration(duration)
+	return nil
+}
+
+// LoadConfig reads the JSON configuration file at path. Unknown keys are errors, so typos don't go unnoticed.
+func LoadConfig(path string) (*Config, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		return nil, fmt.Errorf("%w: unknown format %q, use .json, or the github.com/lostdusty/gobalt/v2/config module for YAML and TOML", ErrInvalidConfig, ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%w: %v: %v", ErrInvalidConfig, path, err)
+	}
+
+	config, err := parseConfig(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v: %v", ErrInvalidConfig, path, err)
+	}
+	return config, nil
+}
+
+// ParseConfig checks the values decoded from a configuration file in any format, with the keys of LoadConfig.
+func ParseConfig(values map[string]any) (*Config, error) {
+	config, err := parseConfig(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return config, nil
+}
+
+func parseConfig(values map[string]any) (*Config, error) {
+	var file configFile
+	if err := decodeStrict(values, &file); err != nil {
+		return nil, err
+	}
+
+	config := &Config{
+		Settings:  CreateDefaultSettings(),
+		API:       file.API,
+		APIKey:    file.APIKey,
+		UserAgent: file.UserAgent,
+		Language:  file.Language,
+	}
+	if file.Proxy != "" {
+		proxy, err := url.Parse(file.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+		config.Proxy = proxy
+	}
+	for _, timeout := range []struct {
+		from *configDuration
+		to   **time.Duration
+	}{{file.HealthTimeout, &config.HealthTimeout}, {file.JobTimeout, &config.JobTimeout}, {file.DownloadTimeout, &config.DownloadTimeout}} {
+		if timeout.from != nil {
+			duration := time.Duration(*timeout.from)
+			*timeout.to = &duration
+		}
+	}
+
+	if len(file.Settings) > 0 && string(file.Settings) != "null" {
+		var settings map[string]any
+		if err := json.Unmarshal(file.Settings, &settings); err != nil {
+			return nil, fmt.Errorf("settings: %w", err)
+		}
+		var err error
+		if config.Settings, err = parseSettings(settings, true); err != nil {
+			return nil, fmt.Errorf("settings: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// parseSettings reads settings with the names of the cobalt api over CreateDefaultSettings. Unknown names are errors
+// if strict, otherwise they are kept in Settings.Extra.
+func parseSettings(values map[string]any, strict bool) (Settings, error) {
+	values = maps.Clone(values)
+	//The api sends the bitrate as a string, files have it as a number.
+	if bitrate, ok := values["audioBitrate"].(float64); ok {
+		values["audioBitrate"] = strconv.FormatFloat(bitrate, 'f', -1, 64)
+	}
+	settings := CreateDefaultSettings()
+	if !strict {
+		known := settingsNames()
+		for name, value := range values {
+			if !slices.Contains(known, name) {
+				if settings.Extra == nil {
+					settings.Extra = map[string]any{}
+				}
+				settings.Extra[name] = value
+				delete(values, name)
+			}
+		}
+	}
+	type plainSettings Settings //Without the MarshalJSON method.
+	if err := decodeStrict(values, (*plainSettings)(&settings)); err != nil {
+		return Settings{}, err
+	}
+	return settings, settings.Validate()
+}
+
+// settingsNames returns the names of the Settings fields in the cobalt api.
+func settingsNames() []string {
+	var names []string
+	settings := reflect.TypeOf(Settings{})
+	for i := range settings.NumField() {
+		if name, _, _ := strings.Cut(settings.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// decodeStrict decodes values into v through json, failing on keys v doesn't have.
+func decodeStrict(values any, v any) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Options returns the options set in the configuration, pass them to New.
+func (config *Config) Options() []Option {
+	var options []Option
+	if config.API != "" {
+		options = append(options, WithAPI(config.API))
+	}
+	if config.APIKey != "" {
+		options = append(options, WithAPIKey(config.APIKey))
+	}
+	if config.Proxy != nil {
+		options = append(options, WithProxy(config.Proxy))
+	}
+	if config.UserAgent != "" {
+		options = append(options, WithUserAgent(config.UserAgent))
+	}
+	if config.Language != "" {
+		options = append(options, WithLanguage(config.Language))
+	}
+	if config.HealthTimeout != nil {
+		options = append(options, WithHealthTimeout(*config.HealthTimeout))
+	}
+	if config.JobTimeout != nil {
+		options = append(options, WithJobTimeout(*config.JobTimeout))
+	}
+	if config.DownloadTimeout != nil {
+		options = append(options, WithDownloadTimeout(*config.DownloadTimeout))
+	}
+	return options
+}
@@ -0,0 +1,78 @@
+// Package config reads gobalt configuration files in YAML and TOML, besides the JSON read by gobalt.LoadConfig.
+// The keys are the same in every format, see gobalt.LoadConfig. It's a separate module so gobalt itself doesn't depend
+// on the YAML and TOML parsers.
+//
+//	api = "https://cobalt.example.com"
+//	apiKey = "secret"
+//	jobTimeout = "30s"
+//
+//	[settings]
+//	videoQuality = "max"
+//	youtubeVideoCodec = "vp9"
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/lostdusty/gobalt/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the configuration file at path, .json, .yaml, .yml or .toml. Unknown keys are errors, so typos don't go unnoticed.
+func Load(path string) (*gobalt.Config, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" {
+		return gobalt.LoadConfig(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	switch ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, use .json, .yaml or .toml", gobalt.ErrInvalidConfig, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v: %v", gobalt.ErrInvalidConfig, path, err)
+	}
+
+	config, err := gobalt.ParseConfig(values)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return config, nil
+}
+
+// FromEnv is gobalt.ConfigFromEnv, reading the file in GOBALT_CONFIG with Load.
+func FromEnv() (*gobalt.Config, error) {
+	config := &gobalt.Config{Settings: gobalt.CreateDefaultSettings()}
+	if path := os.Getenv(gobalt.EnvConfig); path != "" {
+		var err error
+		if config, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// NewFromEnv creates a Cobalt configured by FromEnv. The options passed take precedence over the environment.
+func NewFromEnv(options ...gobalt.Option) (*gobalt.Cobalt, error) {
+	config, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return gobalt.New(append(config.Options(), options...)...), nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lostdusty/gobalt/v2"
+)
+
+// write writes the files to a temporary directory and returns their paths.
+func write(t *testing.T, files map[string]string) map[string]string {
+	dir := t.TempDir()
+	paths := map[string]string{}
+	for name, content := range files {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestLoad(t *testing.T) {
+	paths := write(t, map[string]string{
+		"gobalt.json": `{
+			"api": "https://cobalt.example.com",
+			"apiKey": "secret",
+			"jobTimeout": "45s",
+			"downloadTimeout": 600,
+			"settings": {"videoQuality": "max", "youtubeVideoCodec": "vp9", "audioBitrate": 320}
+		}`,
+		"gobalt.yaml": `
+api: https://cobalt.example.com
+apiKey: secret
+jobTimeout: 45s
+downloadTimeout: 600
+settings:
+  videoQuality: max
+  youtubeVideoCodec: vp9
+  audioBitrate: 320
+`,
+		"gobalt.toml": `
+# Instance
+api = "https://cobalt.example.com"
+apiKey = 'secret' # from the instance owner
+jobTimeout = "45s"
+downloadTimeout = 600
+
+[settings]
+videoQuality = "max"
+youtubeVideoCodec = "vp9"
+audioBitrate = 320
+`,
+	})
+	for name, path := range paths {
+		config, err := Load(path)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if config.API != "https://cobalt.example.com" || config.APIKey != "secret" {
+			t.Errorf("%v: unexpected client configuration %+v", name, config)
+		}
+		if config.JobTimeout == nil || *config.JobTimeout != 45*time.Second || config.DownloadTimeout == nil || *config.DownloadTimeout != 10*time.Minute {
+			t.Errorf("%v: unexpected timeouts %v, %v", name, config.JobTimeout, config.DownloadTimeout)
+		}
+		if config.Settings.VideoQuality != gobalt.QMax || config.Settings.YoutubeVideoFormat != gobalt.VP9 || config.Settings.AudioBitrate != gobalt.Bitrate320 {
+			t.Errorf("%v: unexpected settings %+v", name, config.Settings)
+		}
+	}
+}
+
+// TOML beyond key = value lines and [tables], which a line based parser gets wrong.
+func TestLoadTOML(t *testing.T) {
+	paths := write(t, map[string]string{
+		"dotted.toml": `
+api = "https://cobalt.example.com"
+settings.videoQuality = "max"
+settings."youtubeVideoCodec" = "vp9"
+`,
+		"inline.toml": `
+api = "https://cobalt.example.com"
+settings = { videoQuality = "max", youtubeVideoCodec = "vp9" }
+`,
+		"strings.toml": `
+api = "https://cobalt.example.com"
+userAgent = """
+gobalt/2 \
+caf\u00e9 # not a comment"""
+apiKey = 'C:\keys\n'
+[settings]
+videoQuality = "max"
+youtubeVideoCodec = "vp9"
+`,
+	})
+	for name, path := range paths {
+		config, err := Load(path)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if config.API != "https://cobalt.example.com" || config.Settings.VideoQuality != gobalt.QMax || config.Settings.YoutubeVideoFormat != gobalt.VP9 {
+			t.Errorf("%v: unexpected configuration %+v", name, config)
+		}
+		if name == "strings.toml" && (config.UserAgent != "gobalt/2 café # not a comment" || config.APIKey != `C:\keys\n`) {
+			t.Errorf("%v: unexpected strings %q, %q", name, config.UserAgent, config.APIKey)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	paths := write(t, map[string]string{
+		"typo.json":     `{"apiKye": "secret"}`,
+		"setting.yaml":  "settings:\n  videoQuality: 1000\n",
+		"timeout.toml":  `jobTimeout = "soon"`,
+		"syntax.toml":   `api "https://cobalt.example.com"`,
+		"escape.toml":   `userAgent = "\x41"`,
+		"datetime.toml": `jobTimeout = 1979-05-27T07:32:00Z`,
+		"tables.toml":   "[[settings]]\nvideoQuality = \"max\"\n",
+		"gobalt.ini":    `api=https://cobalt.example.com`,
+	})
+	for name, path := range paths {
+		if _, err := Load(path); !errors.Is(err, gobalt.ErrInvalidConfig) {
+			t.Errorf("%v: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+
+	//Values of the wrong type are reported by name, after the file parsed.
+	if _, err := Load(paths["datetime.toml"]); err == nil || !strings.Contains(err.Error(), "1979-05-27") {
+		t.Errorf("expected the datetime in the error, got %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	paths := write(t, map[string]string{"gobalt.yaml": "api: https://file.example.com\napiKey: file\nsettings:\n  videoQuality: 720\n"})
+	t.Setenv(gobalt.EnvConfig, paths["gobalt.yaml"])
+	t.Setenv(gobalt.EnvAPI, "https://env.example.com")
+	t.Setenv(gobalt.EnvTimeout, "90")
+
+	config, err := FromEnv()
+	if err != nil {
+		t.Fatalf("failed reading the environment: %v", err)
+	}
+	if config.API != "https://env.example.com" || config.APIKey != "file" || *config.JobTimeout != 90*time.Second || config.Settings.VideoQuality != gobalt.Q720 {
+		t.Errorf("expected the environment over the file, got %+v", config)
+	}
+
+	client, err := NewFromEnv(gobalt.WithAPI("https://explicit.example.com"))
+	if err != nil {
+		t.Fatalf("failed creating the client: %v", err)
+	}
+	if client.API() != "https://explicit.example.com" {
+		t.Errorf("expected the explicit options over the environment, got %v", client.API())
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/lostdusty/gobalt/v2/config
+
+go 1.23
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/lostdusty/gobalt/v2 v2.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/mcuadros/go-version v0.0.0-20190830083331-035f6764e8d2 // indirect
+
+//The module is released alongside gobalt, always build it against the same tree.
+replace github.com/lostdusty/gobalt/v2 => ../
@@ -0,0 +1,73 @@
+package gobalt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	files := map[string]string{
+		"gobalt.json": `{
+			"api": "https://cobalt.example.com",
+			"apiKey": "secret",
+			"proxy": "socks5://127.0.0.1:1080",
+			"jobTimeout": "45s",
+			"downloadTimeout": 600,
+			"settings": {"videoQuality": "max", "youtubeVideoCodec": "vp9", "audioBitrate": 320}
+		}`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if config.API != "https://cobalt.example.com" || config.APIKey != "secret" || config.Proxy == nil || config.Proxy.Host != "127.0.0.1:1080" {
+			t.Errorf("%v: unexpected client configuration %+v", name, config)
+		}
+		if config.JobTimeout == nil || *config.JobTimeout != 45*time.Second || config.DownloadTimeout == nil || *config.DownloadTimeout != 10*time.Minute || config.HealthTimeout != nil {
+			t.Errorf("%v: unexpected timeouts %v, %v, %v", name, config.HealthTimeout, config.JobTimeout, config.DownloadTimeout)
+		}
+		if config.Settings.VideoQuality != QMax || config.Settings.YoutubeVideoFormat != VP9 || config.Settings.AudioBitrate != Bitrate320 {
+			t.Errorf("%v: unexpected settings %+v", name, config.Settings)
+		}
+		if config.Settings.FilenameStyle != Basic || !config.Settings.TwitterConvertGif {
+			t.Errorf("%v: expected the settings not in the file to keep their defaults, got %+v", name, config.Settings)
+		}
+
+		client := New(config.Options()...)
+		if client.API() != config.API || client.apiKey != "secret" || client.jobTimeout != 45*time.Second || client.healthTimeout != DefaultHealthTimeout {
+			t.Errorf("%v: options not applied to the client", name)
+		}
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	files := map[string]string{
+		"typo.json":    `{"apiKye": "secret"}`,
+		"setting.json": `{"settings": {"videoQuality": 1000}}`,
+		"timeout.json": `{"jobTimeout": "soon"}`,
+		"syntax.json":  `{"api" "https://cobalt.example.com"}`,
+		"gobalt.ini":   `api=https://cobalt.example.com`,
+		"gobalt.yaml":  `api: https://cobalt.example.com`,
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%v: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
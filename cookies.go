@@ -0,0 +1,123 @@
+package gobalt
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cookies for the requests made to YouTube (playlists, search, trending and feeds), from cookies.txt files exported by browser extensions.
+// Logged in requests can read private and membership playlists, and are less often blocked as bots.
+
+// LoadCookiesTxt reads the cookies of a Netscape cookies.txt file, see ParseCookiesTxt.
+func LoadCookiesTxt(path string) ([]*http.Cookie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseCookiesTxt(file)
+}
+
+// ParseCookiesTxt parses cookies in the Netscape cookies.txt format, a cookie per line with tab separated domain, subdomains flag,
+// path, secure flag, expiry (unix seconds, 0 for session cookies), name and value. Expired cookies are skipped.
+func ParseCookiesTxt(r io.Reader) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+		text = strings.TrimPrefix(text, "#HttpOnly_")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("invalid cookies.txt line %v: expected 7 tab separated fields, got %v", line, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookies.txt line %v: invalid expiry %q", line, fields[4])
+		}
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		//Without the subdomains flag, the cookie is only sent to the exact host, like cookies without a Domain attribute.
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = fields[0]
+		} else {
+			cookie.Domain = strings.TrimPrefix(fields[0], ".")
+		}
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cookies, nil
+}
+
+// WithScraperCookies sends cookies, like the ones from LoadCookiesTxt, with the requests to YouTube made by PlaylistItems,
+// MusicPlaylist, Search, Trending and ChannelFeed. They're never sent to cobalt instances or to media servers.
+func WithScraperCookies(cookies []*http.Cookie) Option {
+	return func(c *Cobalt) {
+		jar, _ := cookiejar.New(nil)
+		for _, cookie := range cookies {
+			host := strings.TrimPrefix(cookie.Domain, ".")
+			if host == "" {
+				continue
+			}
+			hostOnly := *cookie
+			if !strings.HasPrefix(cookie.Domain, ".") {
+				hostOnly.Domain = ""
+			}
+			jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: cookie.Path}, []*http.Cookie{&hostOnly})
+		}
+		c.scraperCookies = jar
+	}
+}
+
+// addScraperCookies adds the cookies for the url of a request to YouTube. Logged in requests to the YouTube api also need
+// a SAPISIDHASH Authorization header, made from the SAPISID cookie and the origin.
+func (c *Cobalt) addScraperCookies(request *http.Request, origin string) {
+	if c.scraperCookies == nil {
+		return
+	}
+	var sapisid string
+	for _, cookie := range c.scraperCookies.Cookies(request.URL) {
+		request.AddCookie(cookie)
+		if cookie.Name == "SAPISID" || (sapisid == "" && cookie.Name == "__Secure-3PAPISID") {
+			sapisid = cookie.Value
+		}
+	}
+	if sapisid != "" && origin != "" {
+		request.Header.Set("Authorization", sapisidHash(sapisid, origin, time.Now()))
+		request.Header.Set("X-Origin", origin)
+	}
+}
+
+// sapisidHash returns the SAPISIDHASH Authorization header, the sha1 of the time, SAPISID cookie and origin.
+func sapisidHash(sapisid, origin string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	hash := sha1.Sum([]byte(timestamp + " " + sapisid + " " + origin))
+	return "SAPISIDHASH " + timestamp + "_" + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,70 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cookiesTxt = `# Netscape HTTP Cookie File
+# This is a generated file! Do not edit.
+
+.youtube.com	TRUE	/	TRUE	4102444800	SAPISID	sapisid/value
+#HttpOnly_.youtube.com	TRUE	/	TRUE	0	LOGIN_INFO	login
+www.youtube.com	FALSE	/	FALSE	1000000000	EXPIRED	old
+127.0.0.1	FALSE	/	FALSE	0	SAPISID	local
+`
+
+func TestParseCookiesTxt(t *testing.T) {
+	cookies, err := ParseCookiesTxt(strings.NewReader(cookiesTxt))
+	if err != nil {
+		t.Fatalf("failed to parse the cookies: %v", err)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("expected 3 cookies, got %v", cookies)
+	}
+	sapisid, login := cookies[0], cookies[1]
+	if sapisid.Name != "SAPISID" || sapisid.Value != "sapisid/value" || sapisid.Domain != ".youtube.com" || !sapisid.Secure || !sapisid.Expires.Equal(time.Unix(4102444800, 0)) {
+		t.Errorf("unexpected cookie %+v", sapisid)
+	}
+	if login.Name != "LOGIN_INFO" || !login.HttpOnly || !login.Expires.IsZero() {
+		t.Errorf("unexpected http only session cookie %+v", login)
+	}
+	if cookies[2].Domain != "127.0.0.1" {
+		t.Errorf("unexpected host only cookie %+v", cookies[2])
+	}
+
+	if _, err := ParseCookiesTxt(strings.NewReader("youtube.com\tTRUE\t/\n")); err == nil {
+		t.Error("expected an error for an invalid line")
+	}
+}
+
+func TestScraperCookies(t *testing.T) {
+	var cookie, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, authorization = r.Header.Get("Cookie"), r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	oldAPI := youtubeSearchAPI
+	youtubeSearchAPI = server.URL
+	defer func() { youtubeSearchAPI = oldAPI }()
+
+	cookies, err := ParseCookiesTxt(strings.NewReader(cookiesTxt))
+	if err != nil {
+		t.Fatalf("failed to parse the cookies: %v", err)
+	}
+	if _, err := New(WithScraperCookies(cookies)).Search(context.Background(), "song", SearchFilters{}); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if cookie != "SAPISID=local" || !strings.HasPrefix(authorization, "SAPISIDHASH ") {
+		t.Errorf("expected only the cookies of the host and a SAPISIDHASH, got %q and %q", cookie, authorization)
+	}
+
+	if sapisidHash("abc", "https://www.youtube.com", time.Unix(1700000000, 0)) != "SAPISIDHASH 1700000000_27b236f59d4ec583d7530f2c7055d2f9c6aecf92" {
+		t.Errorf("unexpected hash %v", sapisidHash("abc", "https://www.youtube.com", time.Unix(1700000000, 0)))
+	}
+}
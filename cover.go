@@ -0,0 +1,125 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Cover art for audio downloads, the thumbnail of the video embedded in the tags (ID3 APIC, mp4 covr or Vorbis
+// METADATA_BLOCK_PICTURE) without ffmpeg.
+
+// CoverArt embeds an image as cover art, see the method.
+func CoverArt(image string) PostProcessor {
+	return defaultCobalt().CoverArt(image)
+}
+
+// CoverArt returns a post-processor embedding the jpeg or png image at image, a path or an url fetched with the http client
+// of this Cobalt, as the front cover of mp3, ogg/opus and mp4/m4a files. See EmbedThumbnail for other formats, with ffmpeg.
+func (c *Cobalt) CoverArt(image string) PostProcessor {
+	return PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		cover, err := c.readCover(ctx, image)
+		if err != nil {
+			return "", err
+		}
+		return input, WriteCover(input, cover)
+	})
+}
+
+// readCover reads the image at a path or an url.
+func (c *Cobalt) readCover(ctx context.Context, image string) ([]byte, error) {
+	if u, err := url.Parse(image); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return os.ReadFile(image)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, image, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", image, err)
+	}
+	request.Header.Add("User-Agent", c.userAgent)
+	res, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the cover art from %v: %v", image, res.Status)
+	}
+	return c.readBody(res)
+}
+
+// coverPostProcessor returns the CoverArt post-processor for the thumbnail of the YouTube video of an audio download,
+// nil if the media isn't audio from a YouTube video.
+func (c *Cobalt) coverPostProcessor(ctx context.Context, media *CobaltResponse, contentType string) (PostProcessor, error) {
+	if media.job == nil || (media.job.settings.Mode != Audio && !strings.HasPrefix(contentType, "audio/")) {
+		return nil, nil
+	}
+	videoID := videoIDFromURL(media.job.settings.Url)
+	if videoID == "" {
+		return nil, nil
+	}
+	thumbnail, err := c.BestThumbnail(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	return c.CoverArt(thumbnail), nil
+}
+
+// videoIDFromURL returns the id of the video of a YouTube or YouTube Music url, empty if it isn't a video url.
+func videoIDFromURL(rawURL string) string {
+	u, err := parseMediaURL(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	parts := pathParts(u)
+	id := ""
+	switch {
+	case host == "youtu.be" && len(parts) > 0:
+		id = parts[0]
+	case host != "youtube.com" && !strings.HasSuffix(host, ".youtube.com"):
+	case len(parts) > 0 && parts[0] == "watch":
+		id = u.Query().Get("v")
+	case len(parts) > 1 && (parts[0] == "shorts" || parts[0] == "embed" || parts[0] == "live" || parts[0] == "v"):
+		id = parts[1]
+	}
+	if !youtubeVideoID.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// coverType returns the mime type of the cover art, ErrUnsupportedCover if it isn't jpeg or png.
+func coverType(cover []byte) (string, error) {
+	switch mimeType := http.DetectContentType(cover); mimeType {
+	case "image/jpeg", "image/png":
+		return mimeType, nil
+	}
+	return "", ErrUnsupportedCover
+}
+
+// flacPicture returns the FLAC picture block of a front cover, the value of METADATA_BLOCK_PICTURE in Vorbis comments.
+func flacPicture(cover []byte) []byte {
+	mimeType, _ := coverType(cover)
+	var width, height uint32
+	if config, _, err := image.DecodeConfig(bytes.NewReader(cover)); err == nil {
+		width, height = uint32(config.Width), uint32(config.Height)
+	}
+	block := binary.BigEndian.AppendUint32(nil, 3) //Front cover.
+	block = binary.BigEndian.AppendUint32(block, uint32(len(mimeType)))
+	block = append(block, mimeType...)
+	block = binary.BigEndian.AppendUint32(block, 0) //Description.
+	block = binary.BigEndian.AppendUint32(block, width)
+	block = binary.BigEndian.AppendUint32(block, height)
+	block = binary.BigEndian.AppendUint32(block, 24) //Color depth.
+	block = binary.BigEndian.AppendUint32(block, 0)  //Colors of indexed images.
+	block = binary.BigEndian.AppendUint32(block, uint32(len(cover)))
+	return append(block, cover...)
+}
@@ -0,0 +1,107 @@
+package gobalt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testCover(t *testing.T) []byte {
+	t.Helper()
+	var cover bytes.Buffer
+	if err := png.Encode(&cover, image.NewGray(image.Rect(0, 0, 2, 3))); err != nil {
+		t.Fatal(err)
+	}
+	return cover.Bytes()
+}
+
+func TestWriteCover(t *testing.T) {
+	cover := testCover(t)
+
+	mp3 := writeTestFile(t, "song.mp3", append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 100)...))
+	WriteTags(mp3, Tags{Title: "Title"})
+	if err := WriteCover(mp3, cover); err != nil {
+		t.Fatalf("failed embedding the cover in mp3: %v", err)
+	}
+	data, _ := os.ReadFile(mp3)
+	if !bytes.Contains(data, append([]byte("image/png\x00\x03\x00"), cover...)) || !bytes.Contains(data, []byte("TIT2")) {
+		t.Errorf("expected an APIC frame and the title in %q", data)
+	}
+
+	opus := writeTestFile(t, "song.opus", testOggOpus(t))
+	if err := WriteCover(opus, cover); err != nil {
+		t.Fatalf("failed embedding the cover in opus: %v", err)
+	}
+	data, _ = os.ReadFile(opus)
+	i := bytes.Index(data, []byte("METADATA_BLOCK_PICTURE="))
+	if i < 0 {
+		t.Fatalf("expected a picture comment in %q", data)
+	}
+	encoded := data[i+len("METADATA_BLOCK_PICTURE=") : i+len("METADATA_BLOCK_PICTURE=")+base64.StdEncoding.EncodedLen(len(flacPicture(cover)))]
+	block, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil || binary.BigEndian.Uint32(block) != 3 || !bytes.HasSuffix(block, cover) || binary.BigEndian.Uint32(block[4+4+9+4:]) != 2 {
+		t.Errorf("unexpected picture block %q, %v", block, err)
+	}
+	if !bytes.Contains(data, []byte("TITLE=old12")) {
+		t.Errorf("expected the other comments to be kept")
+	}
+
+	m4a := writeTestFile(t, "song.m4a", bytes.Join([][]byte{mp4Box("ftyp", []byte("M4A ")), mp4Box("moov"), mp4Box("mdat", []byte("audio"))}, nil))
+	if err := WriteCover(m4a, cover); err != nil {
+		t.Fatalf("failed embedding the cover in m4a: %v", err)
+	}
+	data, _ = os.ReadFile(m4a)
+	if !bytes.Contains(data, append([]byte("covr"), binary.BigEndian.AppendUint32(nil, uint32(16+len(cover)))...)) || !bytes.Contains(data, append([]byte{0, 0, 0, 14, 0, 0, 0, 0}, cover...)) {
+		t.Errorf("expected a png covr atom in %q", data)
+	}
+
+	if err := WriteCover(mp3, []byte("GIF89a")); !errors.Is(err, ErrUnsupportedCover) {
+		t.Errorf("expected ErrUnsupportedCover, got %v", err)
+	}
+}
+
+func TestDownloadEmbedCover(t *testing.T) {
+	cover := testCover(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write(append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 100)...))
+		case "/vi/dQw4w9WgXcQ/maxresdefault.jpg":
+			w.Write(cover)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	oldHost := youtubeThumbnailHost
+	youtubeThumbnailHost = server.URL
+	defer func() { youtubeThumbnailHost = oldHost }()
+
+	settings := CreateDefaultSettings()
+	settings.Url, settings.Mode = "https://youtu.be/dQw4w9WgXcQ", Audio
+	media := &CobaltResponse{Status: "tunnel", URL: server.URL + "/audio", Filename: "song.mp3"}
+	media.obtained(defaultCobalt(), CobaltApi, settings)
+
+	result, err := Download(media, DownloadOptions{Directory: t.TempDir(), EmbedCover: true})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if data, _ := os.ReadFile(result.Path); !bytes.Contains(data, cover) {
+		t.Errorf("expected the thumbnail to be embedded")
+	}
+
+	if id := videoIDFromURL("https://music.youtube.com/watch?v=dQw4w9WgXcQ&list=x"); id != "dQw4w9WgXcQ" {
+		t.Errorf("unexpected video id %q", id)
+	}
+	if id := videoIDFromURL("https://soundcloud.com/a/b"); id != "" {
+		t.Errorf("expected no video id, got %q", id)
+	}
+}
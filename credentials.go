@@ -0,0 +1,67 @@
+package gobalt
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Credentials of each instance, for failover between instances with different api keys or tokens.
+// Jobs sent to an instance in the credentials use its credential instead of the api key of the Cobalt.
+
+// Credential authenticates the jobs sent to an instance.
+type Credential struct {
+	APIKey string //Sent as "Authorization: Api-Key key".
+	Token  string //Sent as "Authorization: Bearer token", takes precedence over APIKey.
+}
+
+// Credentials maps instance hosts, like "cobalt.example.com", or "cobalt.example.com:9000" for a single port, to their credential.
+type Credentials map[string]Credential
+
+// WithCredentials sets the credential of each instance. Instances not in credentials use the api key of WithAPIKey.
+// Sessions from a ChallengeSolver take precedence, they're only used with the instance they came from.
+func WithCredentials(credentials Credentials) Option {
+	return func(c *Cobalt) {
+		c.credentials = make(Credentials, len(credentials))
+		for host, credential := range credentials {
+			c.credentials[strings.ToLower(host)] = credential
+		}
+	}
+}
+
+// authorization returns the Authorization header of the jobs sent to api.
+func (c *Cobalt) authorization(api string) string {
+	if token := c.session.get(api); token != "" {
+		return "Bearer " + token
+	}
+	if credential, ok := c.credential(api); ok {
+		if credential.Token != "" {
+			return "Bearer " + credential.Token
+		}
+		return "Api-Key " + credential.APIKey
+	}
+	return "Api-Key " + c.apiKey
+}
+
+// credential returns the credential of the instance at api, looked up by host and port, then by host.
+func (c *Cobalt) credential(api string) (Credential, bool) {
+	if len(c.credentials) == 0 {
+		return Credential{}, false
+	}
+	parsed, err := url.Parse(api)
+	if err != nil {
+		return Credential{}, false
+	}
+	if credential, ok := c.credentials[strings.ToLower(parsed.Host)]; ok {
+		return credential, true
+	}
+	credential, ok := c.credentials[strings.ToLower(parsed.Hostname())]
+	return credential, ok
+}
+
+// hasCredential reports if jobs sent to api carry an api key or a token.
+func (c *Cobalt) hasCredential(api string) bool {
+	if credential, ok := c.credential(api); ok {
+		return credential.APIKey != "" || credential.Token != ""
+	}
+	return c.apiKey != ""
+}
@@ -0,0 +1,71 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCredentialsFailover(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	instance := func() *httptest.Server {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+				return
+			}
+			mu.Lock()
+			received[server.URL] = r.Header.Get("Authorization")
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"error","error":{"code":"error.api.capacity"}}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	keyed, tokened := instance(), instance()
+
+	client := New(WithAPIKey("default"), WithCredentials(Credentials{
+		strings.TrimPrefix(keyed.URL, "http://"):   {APIKey: "key"},
+		strings.TrimPrefix(tokened.URL, "http://"): {Token: "token"},
+	}))
+	candidates := []Candidate{{CobaltInstance: CobaltInstance{API: keyed.URL}}, {CobaltInstance: CobaltInstance{API: tokened.URL}}}
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	if _, err := client.RunFailover(context.Background(), settings, candidates, nil); err == nil {
+		t.Fatalf("expected every instance to fail")
+	}
+	if received[keyed.URL] != "Api-Key key" || received[tokened.URL] != "Bearer token" {
+		t.Errorf("expected each instance to get its credential, got %v", received)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	client := New(WithAPIKey("default"), WithCredentials(Credentials{"Cobalt.Example.com": {APIKey: "host"}, "cobalt.example.com:9000": {Token: "port"}}))
+	tests := map[string]string{
+		"https://cobalt.example.com":      "Api-Key host",
+		"https://cobalt.example.com:8443": "Api-Key host",
+		"http://cobalt.example.com:9000":  "Bearer port",
+		"https://other.example.com":       "Api-Key default",
+	}
+	for api, expected := range tests {
+		if header := client.authorization(api); header != expected {
+			t.Errorf("authorization(%v) = %q, expected %q", api, header, expected)
+		}
+	}
+
+	client.session.set("https://other.example.com", "session", time.Time{})
+	if header := client.authorization("https://other.example.com"); header != "Bearer session" {
+		t.Errorf("expected the session of the instance, got %q", header)
+	}
+	if header := client.authorization("https://cobalt.example.com"); header != "Api-Key host" {
+		t.Errorf("expected the session not to be sent to another instance, got %q", header)
+	}
+}
@@ -0,0 +1,202 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Diagnostics for self-hosters, to check a deployment from Go: is it reachable, which version and auth scheme it runs,
+// does it allow browsers to use it, and can it download from each service.
+
+type authScheme string
+
+const (
+	AuthNone      authScheme = "none"      //Jobs are accepted without credentials.
+	AuthKey       authScheme = "key"       //Jobs need an api key.
+	AuthTurnstile authScheme = "turnstile" //Jobs need a session from a Turnstile challenge, or an api key.
+	AuthUnknown   authScheme = "unknown"   //The instance answered in a way gobalt doesn't recognize.
+)
+
+// DiagnosticOptions changes the checks made by DiagnoseInstance.
+type DiagnosticOptions struct {
+	Origin   string            //Origin of the web app using the instance, for the CORS check. Default: https://cobalt.tools.
+	TestURLs map[string]string //Service name to a media url of that service, each submitted as a test job. Empty skips the service checks.
+}
+
+// DiagnosticCheck is the result of a single check made by DiagnoseInstance.
+type DiagnosticCheck struct {
+	Name    string //Name of the check: reachable, version, auth, cors or service:<name>.
+	Passed  bool   //If the instance passed the check.
+	Skipped bool   //The check couldn't be made.
+	Detail  string //Why the check failed or was skipped, or what was found.
+}
+
+// DiagnosticReport is returned by DiagnoseInstance.
+type DiagnosticReport struct {
+	API     string            //Instance api url diagnosed.
+	Latency time.Duration     //Time the server info request took.
+	Info    *ServerInfo       //Server info of the instance, nil if it's unreachable.
+	Auth    authScheme        //Authentication required to submit jobs.
+	Checks  []DiagnosticCheck //Every check made, in order.
+}
+
+// Passed reports if every check that was made passed.
+func (r *DiagnosticReport) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed && !check.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// DiagnoseInstance checks a (self-hosted) instance, see the method.
+func DiagnoseInstance(ctx context.Context, api string, options DiagnosticOptions) *DiagnosticReport {
+	return defaultCobalt().DiagnoseInstance(ctx, api, options)
+}
+
+// DiagnoseInstance checks that the instance at api is reachable and reports a consistent version, finds its auth scheme,
+// checks that options.Origin can use it from a browser (CORS) and submits the test urls as jobs, using the api key of this Cobalt.
+// When the instance is unreachable, the other checks are skipped.
+func (c *Cobalt) DiagnoseInstance(ctx context.Context, api string, options DiagnosticOptions) *DiagnosticReport {
+	if !strings.HasPrefix(api, "http") {
+		api = "https://" + api
+	}
+	report := &DiagnosticReport{API: api, Auth: AuthUnknown}
+
+	start := time.Now()
+	info, err := c.serverInfo(api)
+	report.Latency = time.Since(start)
+	if err != nil {
+		report.Checks = append(report.Checks, DiagnosticCheck{Name: "reachable", Detail: err.Error()})
+		for _, name := range []string{"version", "auth", "cors"} {
+			report.Checks = append(report.Checks, DiagnosticCheck{Name: name, Skipped: true, Detail: "unreachable"})
+		}
+		return report
+	}
+	report.Info = info
+	report.Checks = append(report.Checks, DiagnosticCheck{Name: "reachable", Passed: true, Detail: fmt.Sprintf("answered in %v", report.Latency.Round(time.Millisecond))})
+
+	version := checkVersion(api, info)
+	report.Checks = append(report.Checks, DiagnosticCheck{Name: version.Name, Passed: version.Passed, Detail: version.Detail})
+
+	auth := c.checkAuth(ctx, api, info, report)
+	report.Checks = append(report.Checks, auth, c.checkCORS(ctx, api, options.Origin))
+
+	services := make([]string, 0, len(options.TestURLs))
+	for service := range options.TestURLs {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		check := DiagnosticCheck{Name: "service:" + service}
+		settings := CreateDefaultSettings()
+		settings.Url = options.TestURLs[service]
+		if media, err := c.run(ctx, api, settings); err != nil {
+			check.Detail = err.Error()
+		} else {
+			check.Passed = true
+			check.Detail = media.Status
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return report
+}
+
+// checkAuth submits an empty job without credentials: the instance answers with an auth error if it needs them,
+// or with a body error if it doesn't. The check fails if credentials are needed and this Cobalt can't provide them.
+func (c *Cobalt) checkAuth(ctx context.Context, api string, info *ServerInfo, report *DiagnosticReport) DiagnosticCheck {
+	check := DiagnosticCheck{Name: "auth"}
+	if isLegacyVersion(info.Cobalt.Version) {
+		report.Auth = AuthNone
+		check.Passed, check.Detail = true, "7.x instances have no authentication"
+		return check
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api, strings.NewReader("{}"))
+	if err != nil {
+		check.Skipped, check.Detail = true, err.Error()
+		return check
+	}
+	req.Header.Add("User-Agent", c.userAgent)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Content-Type", "application/json")
+	res, err := c.withTimeout(c.jobTimeout).doInstance(req)
+	if err != nil {
+		check.Detail = fmt.Sprintf("job request failed: %v", err)
+		return check
+	}
+	defer res.Body.Close()
+
+	var response CobaltResponse
+	body, err := c.readBody(res)
+	if err == nil {
+		err = json.Unmarshal(body, &response)
+	}
+	if err != nil || response.Error == nil {
+		check.Detail = fmt.Sprintf("unexpected answer to an empty job: %v", res.Status)
+		return check
+	}
+
+	code := response.Error.Code
+	switch {
+	case strings.HasPrefix(code, "error.api.auth.jwt") || strings.HasPrefix(code, "error.api.auth.turnstile"):
+		report.Auth = AuthTurnstile
+		check.Passed = c.hasCredential(api) || c.solver != nil
+		check.Detail = fmt.Sprintf("jobs need a Turnstile session (sitekey %q) or an api key", info.Cobalt.TurnstileKey)
+		if !check.Passed {
+			check.Detail += ", set a ChallengeSolver or an api key"
+		}
+	case strings.HasPrefix(code, "error.api.auth.key"):
+		report.Auth = AuthKey
+		check.Passed = c.hasCredential(api)
+		check.Detail = "jobs need an api key"
+		if !check.Passed {
+			check.Detail += ", none is set"
+		}
+	case strings.HasPrefix(code, "error.api.auth"):
+		check.Detail = fmt.Sprintf("unknown auth error %v", code)
+	default:
+		report.Auth = AuthNone
+		check.Passed, check.Detail = true, "jobs need no credentials"
+	}
+	return check
+}
+
+// checkCORS makes the preflight request a browser makes before submitting a job from origin.
+func (c *Cobalt) checkCORS(ctx context.Context, api, origin string) DiagnosticCheck {
+	check := DiagnosticCheck{Name: "cors"}
+	if origin == "" {
+		origin = "https://cobalt.tools"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, api, nil)
+	if err != nil {
+		check.Skipped, check.Detail = true, err.Error()
+		return check
+	}
+	req.Header.Add("User-Agent", c.userAgent)
+	req.Header.Add("Origin", origin)
+	req.Header.Add("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Add("Access-Control-Request-Headers", "content-type, authorization")
+	res, err := c.withTimeout(c.healthTimeout).httpClient.Do(req)
+	if err != nil {
+		check.Detail = fmt.Sprintf("preflight request failed: %v", err)
+		return check
+	}
+	res.Body.Close()
+
+	switch allowed := res.Header.Get("Access-Control-Allow-Origin"); allowed {
+	case "*", origin:
+		check.Passed, check.Detail = true, fmt.Sprintf("%v can use the instance", origin)
+	case "":
+		check.Detail = fmt.Sprintf("%v can't use the instance from a browser, no Access-Control-Allow-Origin", origin)
+	default:
+		check.Detail = fmt.Sprintf("only %v can use the instance from a browser", allowed)
+	}
+	return check
+}
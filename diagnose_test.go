@@ -0,0 +1,51 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiagnoseInstance(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodOptions:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4","url":"` + server.URL + `/"},"git":{"branch":"main","commit":"6a1cb0a","remote":"imputnet/cobalt"}}`))
+		case r.Header.Get("Authorization") != "Api-Key secret":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":"error","error":{"code":"error.api.auth.key.missing"}}`))
+		default:
+			w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+		}
+	}))
+	defer server.Close()
+
+	options := DiagnosticOptions{TestURLs: map[string]string{"youtube": "https://www.youtube.com/watch?v=dQw4w9WgXcQ"}}
+	report := New(WithAPIKey("secret")).DiagnoseInstance(context.Background(), server.URL, options)
+	if !report.Passed() || report.Auth != AuthKey || report.Info == nil || len(report.Checks) != 5 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	report = New().DiagnoseInstance(context.Background(), server.URL, options)
+	failed := map[string]bool{}
+	for _, check := range report.Checks {
+		if !check.Passed {
+			failed[check.Name] = true
+		}
+	}
+	if report.Passed() || !failed["auth"] || !failed["service:youtube"] || len(failed) != 2 {
+		t.Errorf("expected auth and the service to fail without an api key, got %+v", report.Checks)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	report = DiagnoseInstance(context.Background(), down.URL, DiagnosticOptions{})
+	if report.Passed() || report.Info != nil || report.Checks[0].Name != "reachable" || !report.Checks[1].Skipped {
+		t.Errorf("unexpected report for an unreachable instance %+v", report)
+	}
+}
@@ -0,0 +1,52 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientSpace is returned (as an *InsufficientSpaceError) by Download when the media doesn't fit in the directory.
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// InsufficientSpaceError has the bytes required by the media and the bytes available in the directory.
+type InsufficientSpaceError struct {
+	Directory string
+	Required  int64
+	Available int64
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("%v: %v needs %v bytes, %v are available", ErrInsufficientSpace, e.Directory, e.Required, e.Available)
+}
+
+func (e *InsufficientSpaceError) Unwrap() error {
+	return ErrInsufficientSpace
+}
+
+// checkSpace returns an *InsufficientSpaceError if size bytes don't fit in directory. Unknown sizes and free space pass.
+func checkSpace(directory string, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	if directory == "" {
+		directory = "."
+	}
+	available, err := freeSpace(directory)
+	if err != nil || available < 0 {
+		return nil
+	}
+	if size > available {
+		return &InsufficientSpaceError{Directory: directory, Required: size, Available: available}
+	}
+	return nil
+}
+
+// probeSize gets the size of the media at url from a ranged request, for tunnels streamed without a Content-Length. -1 if unknown.
+func (c *Cobalt) probeSize(ctx context.Context, url string) int64 {
+	size, _, _, err := c.probeFile(ctx, url)
+	if err != nil {
+		return -1
+	}
+	return size
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || dragonfly || windows)
+
+package gobalt
+
+// freeSpace isn't implemented on this system, the space check is skipped.
+func freeSpace(directory string) (int64, error) {
+	return -1, nil
+}
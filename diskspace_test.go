@@ -0,0 +1,36 @@
+package gobalt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDownloadInsufficientSpace(t *testing.T) {
+	if available, _ := freeSpace(t.TempDir()); available < 0 {
+		t.Skipf("free space isn't supported on %v", runtime.GOOS)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "4611686018427387904")
+		w.Header().Set("Content-Type", "video/mp4")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	_, err := Download(&CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "huge.mp4"}, DownloadOptions{Directory: dir})
+	var spaceErr *InsufficientSpaceError
+	if !errors.Is(err, ErrInsufficientSpace) || !errors.As(err, &spaceErr) {
+		t.Fatalf("expected ErrInsufficientSpace, got %v", err)
+	}
+	if spaceErr.Required != 1<<62 || spaceErr.Available <= 0 {
+		t.Errorf("unexpected sizes %+v", spaceErr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "huge.mp4.part")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("no file should be created")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package gobalt
+
+import "syscall"
+
+// freeSpace returns the bytes available to the user in the filesystem of directory.
+func freeSpace(directory string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(directory, &stat); err != nil {
+		return -1, err
+	}
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), nil
+}
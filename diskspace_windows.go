@@ -0,0 +1,24 @@
+//go:build windows
+
+package gobalt
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// freeSpace returns the bytes available to the user in the filesystem of directory.
+func freeSpace(directory string) (int64, error) {
+	path, err := syscall.UTF16PtrFromString(directory)
+	if err != nil {
+		return -1, err
+	}
+	var available, total, free uint64
+	ok, _, err := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&available)), uintptr(unsafe.Pointer(&total)), uintptr(unsafe.Pointer(&free)))
+	if ok == 0 {
+		return -1, err
+	}
+	return int64(available), nil
+}
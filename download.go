@@ -0,0 +1,437 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Downloading the media returned by cobalt, to a file (Download) or as a stream (OpenStream).
+
+var (
+	ErrRedirectLoop       = errors.New("redirect loop detected")      //A redirect pointed to an url already visited.
+	ErrTooManyRedirects   = errors.New("too many redirects")          //More redirects than RedirectPolicy.MaxHops.
+	ErrRedirectNotAllowed = errors.New("redirect target not allowed") //A redirect pointed to a scheme or host not allowed by the RedirectPolicy.
+)
+
+// RedirectPolicy controls how redirects are followed by Download and OpenStream. The zero value follows up to 10 redirects
+// to http and https urls on any host, stripping cookies and credentials when the host changes.
+type RedirectPolicy struct {
+	MaxHops                int      //Maximum redirects to follow. Default: 10, -1 to not follow redirects: the 3xx response is used as is, Download and OpenStream fail with ErrRedirectNotAllowed.
+	AllowedSchemes         []string //Schemes redirects can point to. Default: http and https.
+	AllowedHosts           []string //Hosts (and their subdomains) redirects can point to. Default: any host.
+	KeepCookiesAcrossHosts bool     //Sends cookies and the Authorization header to other hosts. Default: false.
+}
+
+// DownloadOptions changes how Download and OpenStream fetch the media.
+type DownloadOptions struct {
+	Directory string            //Directory to save the file in. Default: current directory.
+	Filename  string            //Name of the saved file. Default: the filename returned by cobalt, or from the server response.
+	Template  string            //Filename template, like "{title} - {uploader} [{quality}].{ext}". See TemplateFields for the fields. Ignored if Filename is set.
+	Fields    map[string]string //Extra template fields, or overrides, like the ones from TemplateFields(media, ProcessMedia(url)).
+	Sanitize  SanitizeOptions   //How the filename is sanitized, see SanitizeFilename.
+	Redirects RedirectPolicy    //How redirects are followed.
+	Collision collisionStrategy //What to do when the file already exists. Default: CollisionOverwrite.
+	Zip       bool              //DownloadPicker saves the items in a single zip archive, see DownloadZip.
+
+	KeepPartial    bool            //Keeps the .part file when the download fails or is canceled, instead of removing it.
+	Progress       func(Progress)  //Called after every write with the progress of the download.
+	Retries        int             //Times a download interrupted by a network error, or stalled, is resumed from the last byte received. Default: 3, -1 disables resuming.
+	StallTimeout   time.Duration   //Drops the connection when nothing is received for this long, like from a stuck live-render tunnel, and resumes. 0 waits forever.
+	PostProcessors []PostProcessor //Run in order on the downloaded file, like Remux("mkv"). Ignored by DownloadTo.
+	EmbedCover     bool            //Embeds the thumbnail of the YouTube video as cover art of audio downloads, after PostProcessors. See CoverArt.
+}
+
+// Progress of a download, see DownloadOptions.Progress.
+type Progress struct {
+	Written int64         //Bytes written so far.
+	Size    int64         //Size reported by the server, -1 if unknown.
+	Elapsed time.Duration //Time since the download started.
+	Speed   float64       //Bytes per second, an exponential moving average of the recent speed.
+	ETA     time.Duration //Estimated time until the download ends, -1 if the size or the speed is unknown.
+
+	Indeterminate bool //The size is unknown, like for chunked tunnels and HLS streams. Show Written and Elapsed with a spinner instead of a percentage.
+}
+
+// Percent returns how much was downloaded, from 0 to 100, or -1 if the progress is indeterminate.
+func (p Progress) Percent() float64 {
+	if p.Indeterminate || p.Size <= 0 {
+		return -1
+	}
+	return min(float64(p.Written)/float64(p.Size)*100, 100)
+}
+
+// DownloadResult is returned by Download.
+type DownloadResult struct {
+	Path        string   //Where the file was saved.
+	Size        int64    //Bytes written.
+	ContentType string   //Content type reported by the server.
+	FinalURL    string   //Url the file was downloaded from, after following redirects.
+	Redirects   []string //Urls redirected from, in order. Empty if there were no redirects.
+
+	Collision collisionStrategy //What was done because the file already existed, empty if it didn't. Skipped downloads have the size of the existing file.
+	Subtitles string            //Path of the subtitles saved next to the file, see CobaltResponse.SubtitlesURL. Empty if there were none.
+}
+
+// Stream is returned by OpenStream, read the media from it and close it when done.
+type Stream struct {
+	io.ReadCloser
+	Filename    string   //Filename from Content-Disposition or the url.
+	ContentType string   //Content type reported by the server.
+	Size        int64    //Size reported by the server, -1 if unknown.
+	FinalURL    string   //Url the media is streamed from, after following redirects.
+	Redirects   []string //Urls redirected from, in order. Empty if there were no redirects.
+
+	hls bool //The media is an HLS stream, concatenated from its segments.
+}
+
+// Download saves the media of a tunnel or redirect response to a file. The file is written with a .part suffix
+// and renamed when complete. Picker responses have multiple files, download each item url with OpenStream.
+func Download(media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadContext(context.Background(), media, options)
+}
+
+// DownloadContext is Download with a context. Canceling it stops the download and returns the context error.
+func DownloadContext(ctx context.Context, media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadContext(ctx, media, options)
+}
+
+// Download saves the media using the http client of this Cobalt, see the package level Download.
+func (c *Cobalt) Download(media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	return c.DownloadContext(context.Background(), media, options)
+}
+
+// DownloadContext saves the media using the http client of this Cobalt, see the package level DownloadContext.
+func (c *Cobalt) DownloadContext(ctx context.Context, media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	if media != nil && media.IsLocalProcessing() {
+		return c.downloadLocal(ctx, media, options)
+	}
+	if media == nil || media.URL == "" {
+		return nil, errors.New("the response has no url to download, picker responses must be downloaded item by item")
+	}
+
+	//Skip without requesting the media when the name doesn't depend on the response.
+	if name := knownName(media, options); name != "" && options.Collision == CollisionSkip {
+		if path, collision := collisionPath(filepath.Join(options.Directory, SanitizeFilename(name, options.Sanitize)), CollisionSkip); collision == CollisionSkip {
+			return skippedResult(path), nil
+		}
+	}
+
+	stream, err := c.OpenStreamContext(ctx, media.URL, options)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	path, collision := collisionPath(filepath.Join(options.Directory, SanitizeFilename(downloadName(media, stream, options), options.Sanitize)), options.Collision)
+	if collision == CollisionSkip {
+		result := skippedResult(path)
+		result.ContentType, result.FinalURL, result.Redirects = stream.ContentType, stream.FinalURL, stream.Redirects
+		return result, nil
+	}
+
+	//Fail now instead of in the middle of the download if the media doesn't fit.
+	size := stream.Size
+	if size < 0 && !stream.hls {
+		size = c.probeSize(ctx, stream.FinalURL)
+	}
+	if err := checkSpace(options.Directory, size); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return nil, err
+	}
+	result, err := writeStream(ctx, file, stream, options.Progress)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if !options.KeepPartial {
+			os.Remove(path + ".part")
+		}
+		return nil, err
+	}
+	if err := os.Rename(path+".part", path); err != nil {
+		return nil, err
+	}
+	result.Collision = collision
+	processors := options.PostProcessors
+	if options.EmbedCover {
+		cover, err := c.coverPostProcessor(ctx, media, result.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		if cover != nil {
+			processors = append(slices.Clip(processors), cover)
+		}
+	}
+	result.Path, err = runPostProcessors(ctx, path, processors)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.postDownload(ctx, media, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// downloadName returns the name of the file for the media: options.Filename, or options.Template rendered,
+// or the cobalt filename, or the filename from the server. The extension of the content type is added to names without one.
+func downloadName(media *CobaltResponse, stream *Stream, options DownloadOptions) string {
+	name := options.Filename
+	if name == "" && options.Template != "" {
+		fields := TemplateFields(media, &MediaInfo{Type: stream.ContentType})
+		maps.Copy(fields, options.Fields)
+		name = RenderFilenameTemplate(options.Template, fields)
+	}
+	if name == "" {
+		name = media.Filename
+	}
+	if name == "" {
+		name = stream.Filename
+	}
+	if stream.hls && strings.EqualFold(filepath.Ext(name), ".m3u8") {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + filepath.Ext(stream.Filename)
+	}
+	return withExtension(name, stream.ContentType)
+}
+
+// knownName returns the name downloadName gives the media before its stream is opened, or an empty string if it depends
+// on the stream: the name has no extension, which comes from the content type, or it's an HLS manifest.
+func knownName(media *CobaltResponse, options DownloadOptions) string {
+	name := downloadName(media, &Stream{}, options)
+	if !hasExtension(name) || strings.EqualFold(filepath.Ext(name), ".m3u8") {
+		return ""
+	}
+	return name
+}
+
+// DownloadTo writes the media of a tunnel or redirect response to w, like an http.ResponseWriter or a pipe, instead of a file.
+// Only options.Redirects is used. The result has no Path.
+func DownloadTo(media *CobaltResponse, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadToContext(context.Background(), media, w, options)
+}
+
+// DownloadToContext is DownloadTo with a context. Canceling it stops the download and returns the context error.
+func DownloadToContext(ctx context.Context, media *CobaltResponse, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadToContext(ctx, media, w, options)
+}
+
+// DownloadTo writes the media to w using the http client of this Cobalt, see the package level DownloadTo.
+func (c *Cobalt) DownloadTo(media *CobaltResponse, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	return c.DownloadToContext(context.Background(), media, w, options)
+}
+
+// DownloadToContext writes the media to w using the http client of this Cobalt, see the package level DownloadToContext.
+func (c *Cobalt) DownloadToContext(ctx context.Context, media *CobaltResponse, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	if media == nil || media.URL == "" {
+		return nil, errors.New("the response has no url to download, picker responses must be downloaded item by item")
+	}
+
+	stream, err := c.OpenStreamContext(ctx, media.URL, options)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	return writeStream(ctx, w, stream, options.Progress)
+}
+
+// writeStream copies the stream to w. The content type is detected from the first bytes if the server didn't send a specific one.
+// If ctx is done the error is the context error. progress, if not nil, is called after every write.
+func writeStream(ctx context.Context, w io.Writer, stream *Stream, progress func(Progress)) (*DownloadResult, error) {
+	result := &DownloadResult{
+		ContentType: stream.ContentType,
+		FinalURL:    stream.FinalURL,
+		Redirects:   stream.Redirects,
+	}
+
+	var reader io.Reader = stream
+	if mediaType, _, _ := mime.ParseMediaType(stream.ContentType); mediaType == "" || mediaType == "application/octet-stream" {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(stream, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("download of %v failed: %w", stream.FinalURL, err)
+		}
+		if n > 0 {
+			result.ContentType = http.DetectContentType(head[:n])
+		}
+		reader = io.MultiReader(bytes.NewReader(head[:n]), stream)
+	}
+
+	if progress != nil {
+		reader = NewProgressReader(reader, stream.Size, progress)
+	}
+	written, err := io.Copy(w, reader)
+	result.Size = written
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("download of %v failed after %v bytes: %w", stream.FinalURL, written, err)
+	}
+	return result, nil
+}
+
+// OpenStream starts fetching the media at url, following redirects according to options.Redirects.
+func OpenStream(mediaURL string, options DownloadOptions) (*Stream, error) {
+	return defaultCobalt().OpenStreamContext(context.Background(), mediaURL, options)
+}
+
+// OpenStreamContext is OpenStream with a context, canceling it stops reading the stream.
+func OpenStreamContext(ctx context.Context, mediaURL string, options DownloadOptions) (*Stream, error) {
+	return defaultCobalt().OpenStreamContext(ctx, mediaURL, options)
+}
+
+// OpenStream starts fetching the media using the http client of this Cobalt, see the package level OpenStream.
+func (c *Cobalt) OpenStream(mediaURL string, options DownloadOptions) (*Stream, error) {
+	return c.OpenStreamContext(context.Background(), mediaURL, options)
+}
+
+// OpenStreamContext starts fetching the media using the http client of this Cobalt, see the package level OpenStreamContext.
+func (c *Cobalt) OpenStreamContext(ctx context.Context, mediaURL string, options DownloadOptions) (*Stream, error) {
+	if err := c.downloads.acquire(ctx); err != nil {
+		return nil, err
+	}
+	stream, err := c.openStream(ctx, mediaURL, options)
+	if err != nil {
+		c.downloads.release()
+		return nil, err
+	}
+	if c.downloads != nil {
+		stream.ReadCloser = &releasingBody{ReadCloser: stream.ReadCloser, limit: c.downloads}
+	}
+	return stream, nil
+}
+
+// openStream starts fetching the media, see OpenStreamContext.
+func (c *Cobalt) openStream(ctx context.Context, mediaURL string, options DownloadOptions) (*Stream, error) {
+	//Canceled to drop the connection when the stream stalls, or when it's closed.
+	requestCtx, cancel := context.WithCancel(ctx)
+	request, err := http.NewRequestWithContext(requestCtx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create the request to %v: %w", mediaURL, err)
+	}
+	request.Header.Add("User-Agent", c.userAgent)
+
+	var redirects []string
+	client := options.Redirects.client(c.withTimeout(c.downloadTimeout).httpClient, request.URL, &redirects)
+	response, err := client.Do(request)
+	if err != nil {
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		cancel()
+		if location := response.Header.Get("Location"); options.Redirects.MaxHops < 0 && location != "" {
+			return nil, fmt.Errorf("%w: %v answered with %v to %v, and redirects are not followed", ErrRedirectNotAllowed, mediaURL, response.Status, location)
+		}
+		return nil, fmt.Errorf("request failed with %v", response.Status)
+	}
+	if isHLS(response) {
+		//The manifest is read before openHLS returns, segments are requested with ctx.
+		defer cancel()
+		return c.openHLS(ctx, client, response, redirects)
+	}
+
+	return &Stream{
+		ReadCloser:  resumable(ctx, cancel, client, c.userAgent, response, options),
+		Filename:    filenameFromResponse(response),
+		ContentType: response.Header.Get("Content-Type"),
+		Size:        response.ContentLength,
+		FinalURL:    response.Request.URL.String(),
+		Redirects:   redirects,
+	}, nil
+}
+
+// client returns a copy of base enforcing the policy. Every url redirected from is appended to redirects.
+func (policy RedirectPolicy) client(base *http.Client, start *url.URL, redirects *[]string) *http.Client {
+	client := *base
+	if client.Jar != nil && !policy.KeepCookiesAcrossHosts {
+		client.Jar = &hostLockedJar{jar: client.Jar, host: start.Hostname()}
+	}
+
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if policy.MaxHops < 0 {
+			return http.ErrUseLastResponse
+		}
+		previous := via[len(via)-1]
+		*redirects = append(*redirects, previous.URL.String())
+
+		maxHops := policy.MaxHops
+		if maxHops == 0 {
+			maxHops = 10
+		}
+		if len(via) > maxHops {
+			return fmt.Errorf("%w: stopped after %v redirects", ErrTooManyRedirects, len(via)-1)
+		}
+		for _, visited := range via {
+			if visited.URL.String() == req.URL.String() {
+				return fmt.Errorf("%w: %v was already visited", ErrRedirectLoop, req.URL)
+			}
+		}
+
+		schemes := policy.AllowedSchemes
+		if len(schemes) == 0 {
+			schemes = []string{"http", "https"}
+		}
+		if !slices.Contains(schemes, req.URL.Scheme) {
+			return fmt.Errorf("%w: scheme of %v", ErrRedirectNotAllowed, req.URL)
+		}
+		if len(policy.AllowedHosts) > 0 && !slices.ContainsFunc(policy.AllowedHosts, func(host string) bool { return hostMatches(req.URL.Hostname(), host) }) {
+			return fmt.Errorf("%w: host of %v", ErrRedirectNotAllowed, req.URL)
+		}
+
+		if !policy.KeepCookiesAcrossHosts && !strings.EqualFold(req.URL.Hostname(), previous.URL.Hostname()) {
+			req.Header.Del("Cookie")
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
+	return &client
+}
+
+// hostMatches reports if host is allowed or a subdomain of it.
+func hostMatches(host, allowed string) bool {
+	host, allowed = strings.ToLower(host), strings.ToLower(allowed)
+	return host == allowed || strings.HasSuffix(host, "."+allowed)
+}
+
+// hostLockedJar only hands out cookies for the host the download started on.
+type hostLockedJar struct {
+	jar  http.CookieJar
+	host string
+}
+
+func (j *hostLockedJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+}
+
+func (j *hostLockedJar) Cookies(u *url.URL) []*http.Cookie {
+	if !strings.EqualFold(u.Hostname(), j.host) {
+		return nil
+	}
+	return j.jar.Cookies(u)
+}
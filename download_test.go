@@ -0,0 +1,168 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func redirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/file", http.StatusFound)
+		case "/loop1":
+			http.Redirect(w, r, "/loop2", http.StatusFound)
+		case "/loop2":
+			http.Redirect(w, r, "/loop1", http.StatusFound)
+		case "/elsewhere":
+			http.Redirect(w, r, "https://example.com/file", http.StatusFound)
+		case "/file":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write([]byte("media"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadFollowsRedirects(t *testing.T) {
+	server := redirectServer(t)
+	dir := t.TempDir()
+
+	result, err := Download(&CobaltResponse{Status: "tunnel", URL: server.URL + "/a", Filename: "Song: Live?.mp3"}, DownloadOptions{Directory: dir, Sanitize: SanitizeOptions{GOOS: "windows"}})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if result.FinalURL != server.URL+"/file" || len(result.Redirects) != 2 || result.Redirects[0] != server.URL+"/a" {
+		t.Fatalf("unexpected redirect chain: %v -> %v", result.Redirects, result.FinalURL)
+	}
+	if result.Path != filepath.Join(dir, "Song_ Live_.mp3") || result.Size != 5 || result.ContentType != "audio/mpeg" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if data, _ := os.ReadFile(result.Path); string(data) != "media" {
+		t.Fatalf("unexpected file contents %q", data)
+	}
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	server := redirectServer(t)
+
+	tests := []struct {
+		path     string
+		policy   RedirectPolicy
+		expected error
+	}{
+		{"/loop1", RedirectPolicy{}, ErrRedirectLoop},
+		{"/a", RedirectPolicy{MaxHops: 1}, ErrTooManyRedirects},
+		{"/a", RedirectPolicy{MaxHops: -1}, ErrRedirectNotAllowed},
+		{"/elsewhere", RedirectPolicy{AllowedHosts: []string{"127.0.0.1"}}, ErrRedirectNotAllowed},
+		{"/elsewhere", RedirectPolicy{AllowedSchemes: []string{"http"}}, ErrRedirectNotAllowed},
+	}
+	for _, test := range tests {
+		stream, err := OpenStream(server.URL+test.path, DownloadOptions{Redirects: test.policy})
+		if err == nil {
+			stream.Close()
+		}
+		if !errors.Is(err, test.expected) {
+			t.Errorf("OpenStream(%v) with %+v returned %v, expected %v", test.path, test.policy, err, test.expected)
+		}
+	}
+}
+
+func TestRedirectPolicyNoFollow(t *testing.T) {
+	server := redirectServer(t)
+	policy := RedirectPolicy{MaxHops: -1}
+
+	stream, err := OpenStream(server.URL+"/file", DownloadOptions{Redirects: policy})
+	if err != nil {
+		t.Fatalf("expected media without redirects to open, got %v", err)
+	}
+	stream.Close()
+
+	_, err = OpenStream(server.URL+"/a", DownloadOptions{Redirects: policy})
+	if !errors.Is(err, ErrRedirectNotAllowed) || !strings.Contains(err.Error(), "302") || !strings.Contains(err.Error(), "/b") {
+		t.Errorf("expected the redirect to /b not to be followed, got %v", err)
+	}
+}
+
+func TestRedirectStripsCredentialsAcrossHosts(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != "" || r.Header.Get("Authorization") != "" {
+			t.Errorf("credentials leaked to another host: %v", r.Header)
+		}
+	}))
+	defer target.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer origin.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, origin.URL, nil)
+	request.Header.Set("Cookie", "session=secret")
+	request.Header.Set("Authorization", "Bearer secret")
+	var redirects []string
+	response, err := RedirectPolicy{}.client(&Client, request.URL, &redirects).Do(request)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	response.Body.Close()
+}
+
+func TestDownloadTo(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil
+		w.Write(png)
+	}))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	result, err := DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, &buffer, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), png) || result.Size != int64(len(png)) {
+		t.Errorf("unexpected content %q, size %v", buffer.Bytes(), result.Size)
+	}
+	if result.ContentType != "image/png" || result.Path != "" {
+		t.Errorf("expected the content type to be detected, got %+v", result)
+	}
+}
+
+func TestDownloadCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("partial media"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	for _, keep := range []bool{false, true} {
+		dir := t.TempDir()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := DownloadContext(ctx, &CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "video.mp4"}, DownloadOptions{Directory: dir, KeepPartial: keep})
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the context error, got %v", err)
+		}
+		partial, err := os.ReadFile(filepath.Join(dir, "video.mp4.part"))
+		if keep && string(partial) != "partial media" {
+			t.Errorf("expected the partial file to be kept, got %q, %v", partial, err)
+		}
+		if !keep && !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected the partial file to be removed")
+		}
+	}
+}
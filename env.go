@@ -0,0 +1,86 @@
+package gobalt
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Configuration from environment variables, for containers. Values from the environment override the file in GOBALT_CONFIG,
+// and options passed to NewFromEnv override both.
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvConfig          = "GOBALT_CONFIG"           //Path of a JSON configuration file, see LoadConfig.
+	EnvAPI             = "GOBALT_API"              //See WithAPI.
+	EnvAPIKey          = "GOBALT_API_KEY"          //See WithAPIKey.
+	EnvProxy           = "GOBALT_PROXY"            //See WithProxy.
+	EnvUserAgent       = "GOBALT_USER_AGENT"       //See WithUserAgent.
+	EnvLanguage        = "GOBALT_LANGUAGE"         //See WithLanguage.
+	EnvTimeout         = "GOBALT_TIMEOUT"          //Job timeout, like "30s" or a number of seconds, see WithJobTimeout.
+	EnvHealthTimeout   = "GOBALT_HEALTH_TIMEOUT"   //See WithHealthTimeout.
+	EnvDownloadTimeout = "GOBALT_DOWNLOAD_TIMEOUT" //See WithDownloadTimeout.
+)
+
+// ConfigFromEnv reads the configuration from the GOBALT_* environment variables, on top of the file in GOBALT_CONFIG if set.
+// Empty variables are ignored. Without any variable set, it returns a Config with only the default settings.
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{Settings: CreateDefaultSettings()}
+	if path := os.Getenv(EnvConfig); path != "" {
+		var err error
+		if config, err = LoadConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ApplyEnv overrides the configuration with the GOBALT_* environment variables set, except GOBALT_CONFIG.
+func (config *Config) ApplyEnv() error {
+	for name, value := range map[string]*string{EnvAPI: &config.API, EnvAPIKey: &config.APIKey, EnvUserAgent: &config.UserAgent, EnvLanguage: &config.Language} {
+		if env := os.Getenv(name); env != "" {
+			*value = env
+		}
+	}
+	if env := os.Getenv(EnvProxy); env != "" {
+		proxy, err := url.Parse(env)
+		if err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrInvalidConfig, EnvProxy, err)
+		}
+		config.Proxy = proxy
+	}
+	for name, timeout := range map[string]**time.Duration{EnvTimeout: &config.JobTimeout, EnvHealthTimeout: &config.HealthTimeout, EnvDownloadTimeout: &config.DownloadTimeout} {
+		env := os.Getenv(name)
+		if env == "" {
+			continue
+		}
+		duration, err := parseConfigDuration(env)
+		if err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrInvalidConfig, name, err)
+		}
+		*timeout = &duration
+	}
+	return nil
+}
+
+// NewFromEnv creates a Cobalt configured by ConfigFromEnv. The options passed take precedence over the environment.
+func NewFromEnv(options ...Option) (*Cobalt, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return New(append(config.Options(), options...)...), nil
+}
+
+// parseConfigDuration reads a duration like "1m30s", or a number of seconds.
+func parseConfigDuration(text string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(text)
+}
@@ -0,0 +1,47 @@
+package gobalt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gobalt.json")
+	os.WriteFile(path, []byte(`{"api": "https://file.example.com", "apiKey": "file", "settings": {"videoQuality": 720}}`), 0o644)
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvAPI, "https://env.example.com")
+	t.Setenv(EnvAPIKey, "")
+	t.Setenv(EnvProxy, "http://127.0.0.1:8080")
+	t.Setenv(EnvTimeout, "90")
+	t.Setenv(EnvDownloadTimeout, "5m")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("failed reading the environment: %v", err)
+	}
+	if config.API != "https://env.example.com" || config.APIKey != "file" || config.Proxy == nil || config.Proxy.Host != "127.0.0.1:8080" {
+		t.Errorf("expected the environment over the file, got %+v", config)
+	}
+	if *config.JobTimeout != 90*time.Second || *config.DownloadTimeout != 5*time.Minute || config.HealthTimeout != nil {
+		t.Errorf("unexpected timeouts %v, %v, %v", config.HealthTimeout, config.JobTimeout, config.DownloadTimeout)
+	}
+	if config.Settings.VideoQuality != Q720 {
+		t.Errorf("expected the settings from the file, got %v", config.Settings.VideoQuality)
+	}
+
+	client, err := NewFromEnv(WithAPI("https://explicit.example.com"))
+	if err != nil {
+		t.Fatalf("failed creating the client: %v", err)
+	}
+	if client.API() != "https://explicit.example.com" || client.apiKey != "file" || client.jobTimeout != 90*time.Second {
+		t.Errorf("expected the explicit options over the environment, got %v, %v, %v", client.API(), client.apiKey, client.jobTimeout)
+	}
+
+	t.Setenv(EnvHealthTimeout, "soon")
+	if _, err := ConfigFromEnv(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for an invalid timeout, got %v", err)
+	}
+}
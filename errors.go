@@ -0,0 +1,133 @@
+package gobalt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Categories of the errors returned by cobalt. A *CobaltError unwraps to one of them, so programs can branch on
+// the kind of failure with errors.Is instead of matching error codes.
+var (
+	ErrAuth               = errors.New("cobalt requires authentication")           //error.api.auth.*: missing or invalid api key, session or challenge.
+	ErrRateLimited        = errors.New("rate limited")                             //error.api.rate_exceeded and error.api.fetch.rate.
+	ErrUnsupportedLink    = errors.New("link not supported")                       //error.api.service.* and error.api.link.*.
+	ErrContentUnavailable = errors.New("content unavailable")                      //error.api.content.*: removed, private, age or region restricted, too long...
+	ErrFetchFailed        = errors.New("cobalt failed to fetch the media")         //error.api.fetch.*, error.api.youtube.* and the service being unreachable.
+	ErrInstance           = errors.New("cobalt instance failed to handle the job") //Everything else: capacity, generic and unknown errors.
+)
+
+// ErrServiceNotSupported is returned when the instance has the service of the url disabled, see ServiceNotSupportedError.
+var ErrServiceNotSupported = errors.New("service not supported by the instance")
+
+// ServiceNotSupportedError is returned by Run when the instance has the service of the url disabled,
+// with the services it supports so the job can be sent to an instance supporting it.
+type ServiceNotSupportedError struct {
+	API       string        //Instance api url.
+	Service   ServiceName   //Service of the url, from the error or detected from the url.
+	Supported []ServiceName //Services enabled on the instance, nil if the server info couldn't be requested.
+	Err       *CobaltError  //Error returned by cobalt.
+}
+
+func (e *ServiceNotSupportedError) Error() string {
+	if e.Supported == nil {
+		return fmt.Sprintf("%v doesn't support %v", e.API, e.Service)
+	}
+	supported := make([]string, len(e.Supported))
+	for i, service := range e.Supported {
+		supported[i] = string(service)
+	}
+	return fmt.Sprintf("%v doesn't support %v, it supports %v", e.API, e.Service, strings.Join(supported, ", "))
+}
+
+// Unwrap returns ErrServiceNotSupported and the cobalt error.
+func (e *ServiceNotSupportedError) Unwrap() []error {
+	return []error{ErrServiceNotSupported, e.Err}
+}
+
+// serviceNotSupported returns the error for a job rejected because its service is disabled on the instance.
+// The server info is requested if it wasn't, when the health check was skipped.
+func (c *Cobalt) serviceNotSupported(api string, info *ServerInfo, rawURL string, cobaltErr *CobaltError) error {
+	service := ServiceName(cobaltErr.Service)
+	if service == "" {
+		service = DetectService(rawURL)
+	}
+	if len(info.Cobalt.Services) == 0 {
+		fetched, err := c.serverInfo(api)
+		if err != nil {
+			return &ServiceNotSupportedError{API: api, Service: service, Err: cobaltErr}
+		}
+		info = fetched
+	}
+	return &ServiceNotSupportedError{API: api, Service: service, Supported: info.ServiceNames(), Err: cobaltErr}
+}
+
+// CobaltError is returned when cobalt rejects a request, with the error code and its context.
+type CobaltError struct {
+	Code    string //Error code, like error.api.content.too_long. For 7.x instances, the error text.
+	Service string //Service that failed, if any.
+	Limit   int    //Rate limit or maximum duration, depending on the error. 0 if not sent.
+	Status  int    //HTTP status code of the response, 0 if unknown.
+
+	Response *CobaltResponse //Response with the error status, nil if the error didn't come in one. Run returns it too with WithSoftFail.
+
+	translator ErrorTranslator //From WithErrorTranslator, Translator is used if nil.
+}
+
+// newCobaltError returns the *CobaltError of an error response, translated with the translator of c.
+func (c *Cobalt) newCobaltError(e *Error, status int) *CobaltError {
+	return &CobaltError{Code: e.Code, Service: e.Context.Service, Limit: e.Context.Limit, Status: status, translator: c.translator}
+}
+
+// WithSoftFail makes Run return the response with the error status along with the *CobaltError, instead of nil.
+// The response is always in CobaltError.Response, this only saves the errors.As.
+func WithSoftFail() Option {
+	return func(c *Cobalt) {
+		c.softFail = true
+	}
+}
+
+// errorResponse returns a copy of the response of the *CobaltError in err, nil if there's none.
+func errorResponse(err error) *CobaltResponse {
+	var cobaltErr *CobaltError
+	if !errors.As(err, &cobaltErr) || cobaltErr.Response == nil {
+		return nil
+	}
+	return cobaltErr.Response.clone()
+}
+
+// Message returns a readable message for the error, see Error.Message. Errors returned by a Cobalt created WithErrorTranslator
+// use its translator instead of Translator.
+func (e *CobaltError) Message() string {
+	translator := e.translator
+	if translator == nil {
+		translator = Translator
+	}
+	return (&Error{Code: e.Code, Context: Context{Service: e.Service, Limit: e.Limit}}).message(translator)
+}
+
+func (e *CobaltError) Error() string {
+	message := e.Message()
+	if message == e.Code {
+		return message
+	}
+	return fmt.Sprintf("%v (%v)", message, e.Code)
+}
+
+// Unwrap returns the category of the error, like ErrRateLimited.
+func (e *CobaltError) Unwrap() error {
+	code := strings.TrimPrefix(e.Code, "error.api.")
+	switch {
+	case strings.HasPrefix(code, "auth."):
+		return ErrAuth
+	case code == "rate_exceeded" || code == "fetch.rate":
+		return ErrRateLimited
+	case strings.HasPrefix(code, "service.") || strings.HasPrefix(code, "link."):
+		return ErrUnsupportedLink
+	case strings.HasPrefix(code, "content."):
+		return ErrContentUnavailable
+	case strings.HasPrefix(code, "fetch.") || strings.HasPrefix(code, "youtube.") || code == "unreachable" || code == "timed_out" || code == "unknown_response":
+		return ErrFetchFailed
+	}
+	return ErrInstance
+}
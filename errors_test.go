@@ -0,0 +1,118 @@
+package gobalt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCobaltError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","error":{"code":"error.api.content.too_long","context":{"service":"youtube","limit":180}}}`))
+	}))
+	defer server.Close()
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	_, err := New(WithAPI(server.URL)).Run(settings)
+
+	var cobaltErr *CobaltError
+	if !errors.As(err, &cobaltErr) {
+		t.Fatalf("expected a *CobaltError, got %v", err)
+	}
+	if cobaltErr.Code != "error.api.content.too_long" || cobaltErr.Service != "youtube" || cobaltErr.Limit != 180 || cobaltErr.Status != http.StatusBadRequest {
+		t.Errorf("unexpected error %+v", cobaltErr)
+	}
+	if cobaltErr.Response == nil || cobaltErr.Response.Status != "error" || cobaltErr.Response.Error.Context.Limit != 180 {
+		t.Errorf("expected the error response in the error, got %+v", cobaltErr.Response)
+	}
+	if !errors.Is(err, ErrContentUnavailable) {
+		t.Errorf("expected the error to be ErrContentUnavailable")
+	}
+	if expected := "cobalt rejected our request: The media is too long, the instance allows up to 180 minutes. (error.api.content.too_long)"; err.Error() != expected {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestCobaltErrorCategories(t *testing.T) {
+	tests := map[string]error{
+		"error.api.auth.jwt.missing":      ErrAuth,
+		"error.api.rate_exceeded":         ErrRateLimited,
+		"error.api.fetch.rate":            ErrRateLimited,
+		"error.api.link.unsupported":      ErrUnsupportedLink,
+		"error.api.service.disabled":      ErrUnsupportedLink,
+		"error.api.content.video.age":     ErrContentUnavailable,
+		"error.api.fetch.empty":           ErrFetchFailed,
+		"error.api.youtube.login":         ErrFetchFailed,
+		"error.api.timed_out":             ErrFetchFailed,
+		"error.api.capacity":              ErrInstance,
+		"error.api.something.new":         ErrInstance,
+		"i couldn't process your request": ErrInstance,
+	}
+	for code, expected := range tests {
+		if err := error(&CobaltError{Code: code}); !errors.Is(err, expected) {
+			t.Errorf("expected %v to be %v", code, expected)
+		}
+	}
+}
+
+func TestServiceNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4","services":["youtube","soundcloud"]},"git":{}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","error":{"code":"error.api.service.disabled","context":{"service":"tiktok"}}}`))
+	}))
+	defer server.Close()
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.tiktok.com/@user/video/7123456789012345678"
+	for _, client := range []*Cobalt{New(WithAPI(server.URL)), New(WithAPI(server.URL), WithoutHealthCheck(""))} {
+		_, err := client.Run(settings)
+		var notSupported *ServiceNotSupportedError
+		if !errors.As(err, &notSupported) || !errors.Is(err, ErrServiceNotSupported) || !errors.Is(err, ErrUnsupportedLink) {
+			t.Fatalf("expected a ServiceNotSupportedError, got %v", err)
+		}
+		if notSupported.Service != TikTok || len(notSupported.Supported) != 2 || notSupported.Supported[1] != Soundcloud {
+			t.Errorf("unexpected error %+v", notSupported)
+		}
+	}
+}
+
+func TestSoftFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"status":"error","error":{"code":"error.api.fetch.rate","context":{"service":"instagram"}}}`))
+	}))
+	defer server.Close()
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.instagram.com/p/C1a2b3c4d5e/"
+	if media, err := New(WithAPI(server.URL)).Run(settings); media != nil || err == nil {
+		t.Errorf("expected no response without soft fail, got %+v, %v", media, err)
+	}
+	for _, client := range []*Cobalt{New(WithAPI(server.URL), WithSoftFail()), New(WithAPI(server.URL), WithSoftFail(), WithRequestCoalescing())} {
+		media, err := client.Run(settings)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("expected the error along with the response, got %v", err)
+		}
+		if media == nil || media.Status != "error" || media.Error.Context.Service != "instagram" {
+			t.Errorf("expected the error response, got %+v", media)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Short links, like vm.tiktok.com/... or t.co/..., resolved to the url they point to before cobalt or DetectService see them.
+
+// Hosts serving short links, and the reddit share links (reddit.com/r/<subreddit>/s/<id>).
+var shortLinkHosts = []string{"vm.tiktok.com", "vt.tiktok.com", "t.co", "pin.it", "b23.tv", "xhslink.com", "fb.watch", "on.soundcloud.com", "bit.ly", "tinyurl.com", "goo.gl"}
+
+// IsShortLink reports if the url is a short link that has to be expanded with ExpandURL to know what it points to.
+func IsShortLink(rawURL string) bool {
+	u, err := parseMediaURL(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, short := range shortLinkHosts {
+		if host == short {
+			return true
+		}
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return strings.HasSuffix(host, "reddit.com") && len(parts) == 4 && parts[0] == "r" && parts[2] == "s"
+}
+
+// ExpandURL follows the redirects of a (short) link, see the method.
+func ExpandURL(ctx context.Context, rawURL string) (string, error) {
+	return defaultCobalt().ExpandURL(ctx, rawURL)
+}
+
+// ExpandURL follows the redirects of a link, up to 10, using the http client of this Cobalt, and returns the url it ends at,
+// normalized with NormalizeURL. Only the headers are read.
+func (c *Cobalt) ExpandURL(ctx context.Context, rawURL string) (string, error) {
+	u, err := parseMediaURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the request to %v: %w", u, err)
+	}
+	request.Header.Add("User-Agent", c.userAgent)
+
+	var redirects []string
+	response, err := RedirectPolicy{}.client(c.withTimeout(c.healthTimeout).httpClient, request.URL, &redirects).Do(request)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+	//Services often answer bots with an error page, but the url they redirected to is what matters.
+	if len(redirects) == 0 && response.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("failed to expand %v: %v", u, response.Status)
+	}
+	return NormalizeURL(response.Request.URL.String())
+}
+
+// WithShortLinkExpansion makes Run expand short links (see IsShortLink) with ExpandURL before sending them to the instance.
+func WithShortLinkExpansion() Option {
+	return func(c *Cobalt) {
+		c.expandShortLinks = true
+	}
+}
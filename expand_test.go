@@ -0,0 +1,50 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpandURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/short":
+			http.Redirect(w, r, "/hop", http.StatusMovedPermanently)
+		case "/hop":
+			http.Redirect(w, r, "/@user/video/7123456789012345678?utm_source=copy&lang=en", http.StatusFound)
+		default:
+			//Like services answering bots.
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	expanded, err := ExpandURL(context.Background(), server.URL+"/short")
+	expected := strings.Replace(server.URL, "http://", "https://", 1) + "/@user/video/7123456789012345678?lang=en"
+	if err != nil || expanded != expected {
+		t.Errorf("ExpandURL returned %v, %v, expected %v", expanded, err, expected)
+	}
+	if _, err := ExpandURL(context.Background(), server.URL+"/missing"); err == nil {
+		t.Errorf("expected an error for a link that doesn't redirect and fails")
+	}
+}
+
+func TestIsShortLink(t *testing.T) {
+	tests := map[string]bool{
+		"https://vm.tiktok.com/ZMabcdef/":                  true,
+		"t.co/abc123":                                      true,
+		"https://pin.it/abc":                               true,
+		"https://www.reddit.com/r/videos/s/AbCdEf":         true,
+		"https://www.reddit.com/r/videos/comments/abc/x/":  false,
+		"https://www.tiktok.com/@user/video/7123456789012": false,
+		"https://youtu.be/dQw4w9WgXcQ":                     false,
+	}
+	for rawURL, expected := range tests {
+		if IsShortLink(rawURL) != expected {
+			t.Errorf("IsShortLink(%v) should be %v", rawURL, expected)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Expiry of the urls returned by cobalt. Tunnels only live for a while (90 seconds by default) and say until when in their
+// exp parameter, redirects to YouTube say it in their expire parameter.
+
+// Expired reports if the urls of the response are past ExpiresAt. Responses without a known expiry never expire.
+func (media *CobaltResponse) Expired() bool {
+	return !media.ExpiresAt.IsZero() && time.Now().After(media.ExpiresAt)
+}
+
+// Refresh submits the Settings of the job again, to the same instance with the same Cobalt, replacing the response with the new one.
+// Use it to get fresh urls when the old ones have expired. Only works for responses returned by Run.
+func (media *CobaltResponse) Refresh(ctx context.Context) error {
+	if media.job == nil {
+		return errors.New("the response wasn't returned by Run, there's no job to submit again")
+	}
+	media.job.cobalt.responses.forget(jobKey(media.job.api, media.job.settings))
+	fresh, err := media.job.cobalt.run(ctx, media.job.api, media.job.settings)
+	if err != nil {
+		return err
+	}
+	*media = *fresh
+	return nil
+}
+
+// responseJob is the job a response was returned for, see CobaltResponse.Refresh.
+type responseJob struct {
+	cobalt   *Cobalt
+	api      string
+	settings Settings
+}
+
+// obtained records when the response was obtained, when it expires, and the job to submit again to refresh it.
+func (media *CobaltResponse) obtained(c *Cobalt, api string, settings Settings) {
+	media.ObtainedAt = time.Now()
+	media.job = &responseJob{cobalt: c, api: api, settings: settings}
+	//A picker expires with its first item.
+	for _, mediaURL := range media.AllURLs() {
+		if expiry := urlExpiry(mediaURL); !expiry.IsZero() && (media.ExpiresAt.IsZero() || expiry.Before(media.ExpiresAt)) {
+			media.ExpiresAt = expiry
+		}
+	}
+}
+
+// urlExpiry reads the expiry of an url from its exp (cobalt tunnels, in milliseconds) or expire (YouTube, in seconds) parameter.
+// Zero if it has none.
+func urlExpiry(mediaURL string) time.Time {
+	u, err := url.Parse(mediaURL)
+	if err != nil {
+		return time.Time{}
+	}
+	if exp, err := strconv.ParseInt(u.Query().Get("exp"), 10, 64); err == nil && exp > 0 {
+		return time.UnixMilli(exp)
+	}
+	if expire, err := strconv.ParseInt(u.Query().Get("expire"), 10, 64); err == nil && expire > 0 {
+		return time.Unix(expire, 0)
+	}
+	return time.Time{}
+}
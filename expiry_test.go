@@ -0,0 +1,69 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestURLExpiry(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected time.Time
+	}{
+		{"https://cobalt.example/tunnel?id=abc&exp=1760000000000&sig=x", time.UnixMilli(1760000000000)},
+		{"https://rr1.googlevideo.com/videoplayback?expire=1760000000&ei=x", time.Unix(1760000000, 0)},
+		{"https://cdn.example.com/video.mp4", time.Time{}},
+		{"https://cobalt.example/tunnel?exp=soon", time.Time{}},
+	}
+	for _, test := range tests {
+		if expiry := urlExpiry(test.url); !expiry.Equal(test.expected) {
+			t.Errorf("urlExpiry(%v) = %v, expected %v", test.url, expiry, test.expected)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	var jobs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		//Already expired the first time.
+		exp := time.Now().Add(-time.Minute)
+		if jobs.Add(1) > 1 {
+			exp = time.Now().Add(time.Minute)
+		}
+		fmt.Fprintf(w, `{"status":"tunnel","url":"https://cobalt.example/tunnel?id=%v&exp=%v","filename":"file.mp4"}`, jobs.Load(), exp.UnixMilli())
+	}))
+	defer server.Close()
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	media, err := New(WithAPI(server.URL)).Run(settings)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if media.ObtainedAt.IsZero() || time.Since(media.ObtainedAt) > time.Minute || !media.Expired() {
+		t.Fatalf("expected an expired response, got obtained at %v, expiring at %v", media.ObtainedAt, media.ExpiresAt)
+	}
+	if err := media.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if media.Expired() || media.URL == "" || jobs.Load() != 2 {
+		t.Errorf("expected a fresh response, got %+v after %v jobs", media, jobs.Load())
+	}
+
+	if err := (&CobaltResponse{Status: "tunnel"}).Refresh(context.Background()); err == nil {
+		t.Error("expected an error refreshing a response not returned by Run")
+	}
+	if (&CobaltResponse{Status: "tunnel", URL: "https://cdn.example.com/video.mp4"}).Expired() {
+		t.Error("expected responses without an expiry to never expire")
+	}
+}
@@ -0,0 +1,88 @@
+package gobalt
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Writing the metadata of playlists, search results and feeds as CSV or JSON, to catalog a channel before downloading it.
+
+// MetadataRecord is a row written by ExportCSV and an object written by ExportJSON.
+type MetadataRecord struct {
+	Type      string     `json:"type"` //video, playlist, channel or track.
+	ID        string     `json:"id,omitempty"`
+	URL       string     `json:"url"`
+	Title     string     `json:"title,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Album     string     `json:"album,omitempty"`
+	Index     int        `json:"index,omitempty"`    //Position in the playlist or track number, 0 if not in one.
+	Duration  float64    `json:"duration,omitempty"` //In seconds, 0 if unknown.
+	Thumbnail string     `json:"thumbnail,omitempty"`
+	Published *time.Time `json:"published,omitempty"` //nil if unknown.
+}
+
+// MetadataItem is what ExportCSV and ExportJSON write: PlaylistEntry, SearchResult, MusicTrack or FeedEntry.
+type MetadataItem interface {
+	metadata() MetadataRecord
+}
+
+func (e PlaylistEntry) metadata() MetadataRecord {
+	return MetadataRecord{Type: "video", ID: e.VideoID, URL: e.URL, Title: e.Title, Author: e.Author, Index: e.Index, Duration: e.Duration.Seconds(), Thumbnail: e.Thumbnail}
+}
+
+func (r SearchResult) metadata() MetadataRecord {
+	kind := map[searchType]string{VideoResults: "video", PlaylistResults: "playlist", ChannelResults: "channel"}[r.Type]
+	return MetadataRecord{Type: kind, ID: r.ID, URL: r.URL, Title: r.Title, Author: r.Author, Duration: r.Duration.Seconds(), Thumbnail: r.Thumbnail}
+}
+
+func (t MusicTrack) metadata() MetadataRecord {
+	return MetadataRecord{Type: "track", ID: t.VideoID, URL: t.URL, Title: t.Title, Author: t.Artist, Album: t.Album, Index: t.Index}
+}
+
+func (e FeedEntry) metadata() MetadataRecord {
+	record := MetadataRecord{Type: "video", ID: e.VideoID, URL: e.URL, Title: e.Title, Author: e.Author, Thumbnail: e.Thumbnail}
+	if !e.Published.IsZero() {
+		record.Published = &e.Published
+	}
+	return record
+}
+
+// metadataColumns is the header row of ExportCSV.
+var metadataColumns = []string{"type", "id", "url", "title", "author", "album", "index", "duration", "thumbnail", "published"}
+
+// ExportCSV writes entries to w as CSV, with a header row and a row per entry, see MetadataRecord. Empty numbers and dates
+// are left blank, dates are in RFC 3339.
+func ExportCSV[T MetadataItem](entries []T, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(metadataColumns)
+	for _, entry := range entries {
+		record := entry.metadata()
+		row := []string{record.Type, record.ID, record.URL, record.Title, record.Author, record.Album, "", "", record.Thumbnail, ""}
+		if record.Index > 0 {
+			row[6] = strconv.Itoa(record.Index)
+		}
+		if record.Duration > 0 {
+			row[7] = strconv.FormatFloat(record.Duration, 'f', -1, 64)
+		}
+		if record.Published != nil {
+			row[9] = record.Published.Format(time.RFC3339)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportJSON writes entries to w as an indented JSON array of MetadataRecord.
+func ExportJSON[T MetadataItem](entries []T, w io.Writer) error {
+	records := make([]MetadataRecord, len(entries))
+	for i, entry := range entries {
+		records[i] = entry.metadata()
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
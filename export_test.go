@@ -0,0 +1,52 @@
+package gobalt
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportCSV(t *testing.T) {
+	var output strings.Builder
+	err := ExportCSV([]PlaylistEntry{
+		{URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", VideoID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up, \"Official\"", Author: "Rick Astley", Index: 1, Duration: 212 * time.Second},
+		{URL: "https://www.youtube.com/watch?v=jNQXAC9IVRw", VideoID: "jNQXAC9IVRw", Index: 2},
+	}, &output)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(output.String())).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("invalid csv %q: %v", output.String(), err)
+	}
+	if strings.Join(rows[0], ",") != "type,id,url,title,author,album,index,duration,thumbnail,published" {
+		t.Errorf("unexpected header %v", rows[0])
+	}
+	if rows[1][3] != "Never Gonna Give You Up, \"Official\"" || rows[1][6] != "1" || rows[1][7] != "212" || rows[2][7] != "" {
+		t.Errorf("unexpected rows %q", rows[1:])
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	published := time.Date(2009, 10, 25, 6, 57, 33, 0, time.UTC)
+	var output strings.Builder
+	err := ExportJSON([]FeedEntry{{URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", VideoID: "dQw4w9WgXcQ", Title: "Rickroll", Published: published}}, &output)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !strings.Contains(output.String(), "\n  {\n    \"type\": \"video\"") {
+		t.Errorf("expected indented json, got %v", output.String())
+	}
+	var records []MetadataRecord
+	if err := json.Unmarshal([]byte(output.String()), &records); err != nil || len(records) != 1 || records[0].Published == nil || !records[0].Published.Equal(published) || records[0].ID != "dQw4w9WgXcQ" {
+		t.Errorf("unexpected records %+v, %v", records, err)
+	}
+
+	output.Reset()
+	ExportJSON([]SearchResult{}, &output)
+	if output.String() != "[]\n" {
+		t.Errorf("expected an empty array, got %q", output.String())
+	}
+}
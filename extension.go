@@ -0,0 +1,70 @@
+package gobalt
+
+import (
+	"mime"
+	"path/filepath"
+)
+
+// File extensions from mime types, for filenames without one: cobalt filenames missing it, or names taken from the url.
+
+// Extensions for the media types cobalt and the services serve, the mime package only knows a few of them without system mime files,
+// and some of its answers don't open in players (audio/webm is .weba, not .webm, so players don't expect video).
+var mediaExtensions = map[string]string{
+	"video/mp4": ".mp4", "video/webm": ".webm", "video/quicktime": ".mov", "video/x-matroska": ".mkv", "video/3gpp": ".3gp",
+	"video/mp2t": ".ts", "video/x-flv": ".flv",
+
+	"audio/mpeg": ".mp3", "audio/mp3": ".mp3", "audio/mp4": ".m4a", "audio/x-m4a": ".m4a", "audio/aac": ".aac",
+	"audio/ogg": ".ogg", "audio/opus": ".opus", "audio/webm": ".weba", "audio/x-matroska": ".mka",
+	"audio/flac": ".flac", "audio/x-flac": ".flac",
+	"audio/wav": ".wav", "audio/x-wav": ".wav", "audio/wave": ".wav", "audio/vnd.wave": ".wav",
+
+	"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "image/webp": ".webp", "image/avif": ".avif", "image/heic": ".heic",
+
+	"application/vnd.apple.mpegurl": ".m3u8", "application/x-mpegurl": ".m3u8",
+	"text/vtt": ".vtt", "application/x-subrip": ".srt", "application/zip": ".zip",
+}
+
+// ExtensionByType returns the usual extension, with the dot, for a mime type like "audio/webm; codecs=opus",
+// or an empty string if it's unknown or application/octet-stream.
+func ExtensionByType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" {
+		return ""
+	}
+	if ext, ok := mediaExtensions[mediaType]; ok {
+		return ext
+	}
+	extensions, err := mime.ExtensionsByType(mediaType)
+	if err != nil || len(extensions) == 0 {
+		return ""
+	}
+	return extensions[0]
+}
+
+// withExtension adds the extension of contentType to name if it has none. Dots in titles, like "ft. Artist", aren't extensions.
+func withExtension(name, contentType string) string {
+	if name == "" || name == "." || name == "/" || hasExtension(name) {
+		return name
+	}
+	return name + ExtensionByType(contentType)
+}
+
+// hasExtension reports if name ends with something that looks like an extension: a dot followed by up to 5 letters or digits,
+// at least one a letter.
+func hasExtension(name string) bool {
+	ext := filepath.Ext(name)
+	if len(ext) < 2 || len(ext) > 6 {
+		return false
+	}
+	letter := false
+	for _, char := range ext[1:] {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z':
+			letter = true
+		case char >= '0' && char <= '9':
+		default:
+			return false
+		}
+	}
+	return letter
+}
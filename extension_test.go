@@ -0,0 +1,64 @@
+package gobalt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtensionByType(t *testing.T) {
+	tests := map[string]string{
+		"audio/webm; codecs=opus":  ".weba",
+		"video/webm":               ".webm",
+		"audio/mp4":                ".m4a",
+		"audio/x-wav":              ".wav",
+		"video/x-matroska":         ".mkv",
+		"image/jpeg":               ".jpg",
+		"application/octet-stream": "",
+		"application/x-unknown":    "",
+		"not a type":               "",
+	}
+	for contentType, expected := range tests {
+		if ext := ExtensionByType(contentType); ext != expected {
+			t.Errorf("ExtensionByType(%q) = %q, expected %q", contentType, ext, expected)
+		}
+	}
+}
+
+func TestWithExtension(t *testing.T) {
+	tests := []struct{ name, contentType, expected string }{
+		{"song", "audio/mpeg", "song.mp3"},
+		{"song.opus", "audio/webm", "song.opus"},
+		{"Song ft. Artist", "audio/webm", "Song ft. Artist.weba"},
+		{"Vol.2", "video/mp4", "Vol.2.mp4"},
+		{"file", "application/octet-stream", "file"},
+		{"", "video/mp4", ""},
+	}
+	for _, test := range tests {
+		if name := withExtension(test.name, test.contentType); name != test.expected {
+			t.Errorf("withExtension(%q, %q) = %q, expected %q", test.name, test.contentType, name, test.expected)
+		}
+	}
+}
+
+func TestDownloadAddsExtension(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/webm")
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	result, err := Download(&CobaltResponse{Status: "tunnel", URL: server.URL + "/audio", Filename: "Song ft. Artist"}, DownloadOptions{Directory: dir})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if result.Path != filepath.Join(dir, "Song ft. Artist.weba") {
+		t.Errorf("expected the extension of the content type, got %v", result.Path)
+	}
+	if _, err := os.Stat(result.Path); err != nil {
+		t.Errorf("file not saved: %v", err)
+	}
+}
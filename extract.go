@@ -0,0 +1,49 @@
+package gobalt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Finding media urls in free text, like the messages received by a bot.
+
+// Urls with a scheme, or starting with www. or a known host, ending at whitespace or characters urls don't have unescaped.
+var textURL = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'` + "`" + `]+|\b(?:[a-z0-9-]+\.)+(?:com|be|tv|it|ru|app|watch|ly)/[^\s<>"'` + "`" + `]+`)
+
+// ExtractURLs returns the urls of services cobalt supports (see DetectService) found in text, in order and without duplicates,
+// with https:// added to the ones without a scheme. Punctuation after an url, like the period ending a sentence or the
+// parenthesis around it, isn't included.
+func ExtractURLs(text string) []string {
+	var urls []string
+	seen := map[string]bool{}
+	for _, match := range textURL.FindAllString(text, -1) {
+		match = trimURLPunctuation(match)
+		if !strings.Contains(match, "://") {
+			match = "https://" + match
+		}
+		if seen[match] || DetectService(match) == "" {
+			continue
+		}
+		seen[match] = true
+		urls = append(urls, match)
+	}
+	return urls
+}
+
+// trimURLPunctuation removes the punctuation ending a sentence and closing brackets without an opening one in the url.
+func trimURLPunctuation(match string) string {
+	for match != "" {
+		last := match[len(match)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*_~|", last) >= 0:
+			match = match[:len(match)-1]
+		case last == ')' && strings.Count(match, "(") < strings.Count(match, ")"),
+			last == ']' && strings.Count(match, "[") < strings.Count(match, "]"),
+			last == '}' && strings.Count(match, "{") < strings.Count(match, "}"):
+			match = match[:len(match)-1]
+		default:
+			return match
+		}
+	}
+	return match
+}
@@ -0,0 +1,28 @@
+package gobalt
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractURLs(t *testing.T) {
+	text := `check this out https://youtu.be/dQw4w9WgXcQ!! and (https://www.tiktok.com/@user/video/7123456789012345678).
+also www.instagram.com/reel/C1a2b3c4d5e/, soundcloud.com/artist/song and <https://x.com/user/status/1234567890>
+not media: https://example.com/page, https://en.wikipedia.org/wiki/Go_(programming_language)
+again https://youtu.be/dQw4w9WgXcQ and [link](https://vimeo.com/123456789)`
+
+	expected := []string{
+		"https://youtu.be/dQw4w9WgXcQ",
+		"https://www.tiktok.com/@user/video/7123456789012345678",
+		"https://www.instagram.com/reel/C1a2b3c4d5e/",
+		"https://soundcloud.com/artist/song",
+		"https://x.com/user/status/1234567890",
+		"https://vimeo.com/123456789",
+	}
+	if urls := ExtractURLs(text); !slices.Equal(urls, expected) {
+		t.Errorf("ExtractURLs returned\n%v\nexpected\n%v", urls, expected)
+	}
+	if urls := ExtractURLs("no links here."); len(urls) != 0 {
+		t.Errorf("expected no urls, got %v", urls)
+	}
+}
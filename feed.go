@@ -0,0 +1,136 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// The RSS (Atom) feed of YouTube channels, a cheap way to poll for new uploads.
+
+// youtubeFeedURL is the feed endpoint of YouTube, with the channel id appended.
+var youtubeFeedURL = "https://www.youtube.com/feeds/videos.xml?channel_id="
+
+var youtubeChannelID = regexp.MustCompile(`^UC[\w-]{22}$`)
+
+// ChannelFeed is the feed of a YouTube channel, with its latest uploads (up to 15).
+type ChannelFeed struct {
+	ChannelID string      //YouTube channel id, starting with UC.
+	Title     string      //Name of the channel.
+	URL       string      //Url of the channel.
+	Entries   []FeedEntry //Latest uploads, newest first.
+}
+
+// FeedEntry is an upload in the feed of a channel.
+type FeedEntry struct {
+	URL         string    //Url of the video.
+	VideoID     string    //YouTube video id.
+	Title       string    //Title of the video.
+	Author      string    //Name of the channel.
+	Description string    //Description of the video.
+	Thumbnail   string    //Url of the thumbnail of the video.
+	Views       int64     //View count when the feed was generated.
+	Published   time.Time //When the video was published.
+	Updated     time.Time //When the video was last changed.
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomFeed struct {
+	Title   string     `xml:"http://www.w3.org/2005/Atom title"`
+	Links   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	Entries []struct {
+		VideoID   string     `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+		Title     string     `xml:"http://www.w3.org/2005/Atom title"`
+		Links     []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Author    string     `xml:"http://www.w3.org/2005/Atom author>name"`
+		Published time.Time  `xml:"http://www.w3.org/2005/Atom published"`
+		Updated   time.Time  `xml:"http://www.w3.org/2005/Atom updated"`
+		Media     struct {
+			Description string `xml:"description"`
+			Thumbnail   struct {
+				URL string `xml:"url,attr"`
+			} `xml:"thumbnail"`
+			Statistics struct {
+				Views int64 `xml:"views,attr"`
+			} `xml:"community>statistics"`
+		} `xml:"http://search.yahoo.com/mrss/ group"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// alternate returns the url of the html page from the links of a feed or entry.
+func alternate(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// FetchChannelFeed fetches the feed of a YouTube channel, see the method.
+func FetchChannelFeed(ctx context.Context, channelID string) (*ChannelFeed, error) {
+	return defaultCobalt().ChannelFeed(ctx, channelID)
+}
+
+// ChannelFeed fetches the feed (youtube.com/feeds/videos.xml) of a YouTube channel by its id, like UCuAXFkgsw1L7xaCfnd5JJOw,
+// using the http client of this Cobalt. Much cheaper than scraping the channel page, but only has the latest 15 uploads.
+func (c *Cobalt) ChannelFeed(ctx context.Context, channelID string) (*ChannelFeed, error) {
+	if !youtubeChannelID.MatchString(channelID) {
+		return nil, fmt.Errorf("invalid youtube channel id %q", channelID)
+	}
+	feedURL := youtubeFeedURL + url.QueryEscape(channelID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", feedURL, err)
+	}
+	request.Header.Add("User-Agent", c.userAgent)
+	if c.language != "" {
+		request.Header.Add("Accept-Language", c.language)
+	}
+	c.addScraperCookies(request, "")
+
+	res, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the feed of %v: %v", channelID, res.Status)
+	}
+	body, err := c.readBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var atom atomFeed
+	if err := xml.Unmarshal(body, &atom); err != nil {
+		return nil, fmt.Errorf("failed to parse the feed of %v: %w", channelID, err)
+	}
+	feed := &ChannelFeed{ChannelID: channelID, Title: atom.Title, URL: alternate(atom.Links)}
+	for _, entry := range atom.Entries {
+		videoURL := alternate(entry.Links)
+		if videoURL == "" {
+			videoURL = "https://www.youtube.com/watch?v=" + entry.VideoID
+		}
+		feed.Entries = append(feed.Entries, FeedEntry{
+			URL:         videoURL,
+			VideoID:     entry.VideoID,
+			Title:       entry.Title,
+			Author:      entry.Author,
+			Description: entry.Media.Description,
+			Thumbnail:   entry.Media.Thumbnail.URL,
+			Views:       entry.Media.Statistics.Views,
+			Published:   entry.Published,
+			Updated:     entry.Updated,
+		})
+	}
+	return feed, nil
+}
@@ -0,0 +1,87 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const channelFeedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <link rel="self" href="http://www.youtube.com/feeds/videos.xml?channel_id=UCuAXFkgsw1L7xaCfnd5JJOw"/>
+ <id>yt:channel:uAXFkgsw1L7xaCfnd5JJOw</id>
+ <yt:channelId>uAXFkgsw1L7xaCfnd5JJOw</yt:channelId>
+ <title>Channel</title>
+ <link rel="alternate" href="https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw"/>
+ <published>2006-01-01T00:00:00+00:00</published>
+ <entry>
+  <id>yt:video:aaaaaaaaaaa</id>
+  <yt:videoId>aaaaaaaaaaa</yt:videoId>
+  <yt:channelId>UCuAXFkgsw1L7xaCfnd5JJOw</yt:channelId>
+  <title>New video</title>
+  <link rel="alternate" href="https://www.youtube.com/watch?v=aaaaaaaaaaa"/>
+  <author>
+   <name>Channel</name>
+   <uri>https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw</uri>
+  </author>
+  <published>2026-10-01T12:00:00+00:00</published>
+  <updated>2026-10-02T08:30:00+00:00</updated>
+  <media:group>
+   <media:title>New video</media:title>
+   <media:thumbnail url="https://i1.ytimg.com/vi/aaaaaaaaaaa/hqdefault.jpg" width="480" height="360"/>
+   <media:description>About the video</media:description>
+   <media:community>
+    <media:starRating count="10" average="5.00" min="1" max="5"/>
+    <media:statistics views="1234"/>
+   </media:community>
+  </media:group>
+ </entry>
+</feed>`
+
+func TestChannelFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("channel_id") != "UCuAXFkgsw1L7xaCfnd5JJOw" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, channelFeedXML)
+	}))
+	defer server.Close()
+	oldURL := youtubeFeedURL
+	youtubeFeedURL = server.URL + "?channel_id="
+	defer func() { youtubeFeedURL = oldURL }()
+
+	feed, err := FetchChannelFeed(context.Background(), "UCuAXFkgsw1L7xaCfnd5JJOw")
+	if err != nil {
+		t.Fatalf("failed to fetch the feed: %v", err)
+	}
+	if feed.Title != "Channel" || feed.URL != "https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw" || len(feed.Entries) != 1 {
+		t.Fatalf("unexpected feed %+v", feed)
+	}
+	expected := FeedEntry{
+		URL:         "https://www.youtube.com/watch?v=aaaaaaaaaaa",
+		VideoID:     "aaaaaaaaaaa",
+		Title:       "New video",
+		Author:      "Channel",
+		Description: "About the video",
+		Thumbnail:   "https://i1.ytimg.com/vi/aaaaaaaaaaa/hqdefault.jpg",
+		Views:       1234,
+		Published:   time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Updated:     time.Date(2026, 10, 2, 8, 30, 0, 0, time.UTC),
+	}
+	entry := feed.Entries[0]
+	if !entry.Published.Equal(expected.Published) || !entry.Updated.Equal(expected.Updated) {
+		t.Errorf("unexpected timestamps %v, %v", entry.Published, entry.Updated)
+	}
+	entry.Published, entry.Updated = expected.Published, expected.Updated
+	if entry != expected {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if _, err := FetchChannelFeed(context.Background(), "not a channel"); err == nil {
+		t.Error("expected an error for an invalid channel id")
+	}
+}
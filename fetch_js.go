@@ -0,0 +1,47 @@
+//go:build js && wasm
+
+package gobalt
+
+import (
+	"cmp"
+	"net/http"
+)
+
+// Requests from the browser. net/http already sends them with the Fetch API there, FetchTransport sets the fetch options
+// browser extensions and WASM apps need, as cobalt instances are usually on another origin.
+// Browsers don't let the User-Agent and Cookie headers be set, and they follow redirects themselves, so RedirectPolicy only
+// sees the final url.
+
+// Values of the Fetch API request options, see https://developer.mozilla.org/docs/Web/API/RequestInit.
+const (
+	FetchModeCORS          = "cors"
+	FetchModeNoCORS        = "no-cors"
+	FetchModeSameOrigin    = "same-origin"
+	FetchCredentialsOmit   = "omit"
+	FetchCredentialsSame   = "same-origin"
+	FetchCredentialsAlways = "include"
+)
+
+// FetchTransport makes the requests with the Fetch API of the browser, see WithTransport.
+type FetchTransport struct {
+	Mode        string            //Fetch mode, FetchModeCORS if empty.
+	Credentials string            //If the browser sends its cookies and auth, FetchCredentialsOmit if empty.
+	Base        http.RoundTripper //Transport the requests are sent with, http.DefaultTransport (which uses fetch) if nil.
+}
+
+// NewFetchTransport returns a FetchTransport making cors requests without the cookies of the browser.
+func NewFetchTransport() *FetchTransport {
+	return &FetchTransport{Mode: FetchModeCORS, Credentials: FetchCredentialsOmit}
+}
+
+// RoundTrip implements http.RoundTripper, passing the fetch options to net/http with its js.fetch headers.
+func (t *FetchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("js.fetch:mode", cmp.Or(t.Mode, FetchModeCORS))
+	req.Header.Set("js.fetch:credentials", cmp.Or(t.Credentials, FetchCredentialsOmit))
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
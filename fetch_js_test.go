@@ -0,0 +1,21 @@
+//go:build js && wasm
+
+package gobalt
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFetchTransport(t *testing.T) {
+	recorder := &recordingTransport{}
+	c := New(WithTransport(&FetchTransport{Credentials: FetchCredentialsAlways, Base: recorder}))
+	res, err := c.genericHttpRequest("https://cobalt.example.com/", http.MethodGet, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	res.Body.Close()
+	if recorder.header.Get("js.fetch:mode") != FetchModeCORS || recorder.header.Get("js.fetch:credentials") != FetchCredentialsAlways {
+		t.Errorf("unexpected fetch options %v", recorder.header)
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/lostdusty/gobalt/v2
 
-go 1.22
+go 1.23
 
 require github.com/mcuadros/go-version v0.0.0-20190830083331-035f6764e8d2
@@ -3,6 +3,9 @@
 package gobalt
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,22 +16,49 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mcuadros/go-version"
 )
 
+// Configuration of the package level functions, read on every call. Changing it while other goroutines use the package
+// is a data race, set it once at startup. Programs with concurrent users should create a Cobalt with New instead,
+// its configuration can't change after it's created and it's safe for concurrent use.
 var (
+	// Deprecated: Use New with WithAPI.
 	CobaltApi = "https://cobalt-backend.canine.tools" //Override this value to use your own cobalt instance. See https://instances.hyper.lol/ for alternatives from the main instance.
-	Client    = http.Client{
-		Timeout: 10 * time.Second,
-	} //This allows you to modify the HTTP Client used in requests. This Client will be re-used.
-	useragent = fmt.Sprintf("gobalt/2.0.2 (+https://github.com/lostdusty/gobalt/v2; go/%v; %v/%v)", runtime.Version(), runtime.GOOS, runtime.GOARCH)
-	ApiKey    = os.Getenv("COBALT_API_KEY") //Some instances need an API key to work, set it here. Default is from environment variable `COBALT_API_KEY`.
+	// Deprecated: Use New with WithHTTPClient.
+	Client = http.Client{
+		Timeout:   10 * time.Second,
+		Transport: defaultTransport,
+	} //This allows you to modify the HTTP Client used in requests. This Client will be re-used. Its Timeout doesn't apply to server info, Run and downloads, see DefaultHealthTimeout.
+	// Deprecated: Use New with WithAPIKey.
+	ApiKey = os.Getenv("COBALT_API_KEY") //Some instances need an API key to work, set it here. Default is from environment variable `COBALT_API_KEY`.
+
+	useragent   = fmt.Sprintf("gobalt/2.0.2 (+https://github.com/lostdusty/gobalt/v2; go/%v; %v/%v)", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	useragentMu sync.RWMutex
 )
 
+// SetUserAgent changes the User-Agent header sent by the package level functions and by Cobalts created after the call.
+// Some instances and reverse proxies filter requests by user agent, use it to identify your application.
+// It's safe to call while other goroutines use the package, unlike changing CobaltApi, ApiKey or Client.
+func SetUserAgent(userAgent string) {
+	useragentMu.Lock()
+	defer useragentMu.Unlock()
+	useragent = userAgent
+}
+
+// defaultUserAgent returns the User-Agent set with SetUserAgent.
+func defaultUserAgent() string {
+	useragentMu.RLock()
+	defer useragentMu.RUnlock()
+	return useragent
+}
+
 // ServerInfo is the struct used in the function CobaltServerInfo(). It contains two sub-structs: Cobalt and Git
 type ServerInfo struct {
 	Cobalt CobaltServerInformation `json:"cobalt"`
@@ -37,11 +67,12 @@ type ServerInfo struct {
 
 // This is ServerInfo.Cobalt struct, it contains information about the cobalt backend running on the server.
 type CobaltServerInformation struct {
-	Version       string   `json:"version"`       //Cobalt version running.
-	URL           string   `json:"url"`           //Backend URL of the cobalt server.
-	StartTime     string   `json:"startTime"`     //Time when the server started in Unix miliseconds.
-	DurationLimit int      `json:"durationLimit"` //Maximum media lenght you can download in seconds. 10800 seconds = 3 hours.
-	Services      []string `json:"services"`      //List of configured/enabled services on the instance.
+	Version       string   `json:"version"`          //Cobalt version running.
+	URL           string   `json:"url"`              //Backend URL of the cobalt server.
+	StartTime     string   `json:"startTime"`        //Time when the server started in Unix miliseconds.
+	DurationLimit int      `json:"durationLimit"`    //Maximum media lenght you can download in seconds. 10800 seconds = 3 hours.
+	Services      []string `json:"services"`         //List of configured/enabled services on the instance.
+	TurnstileKey  string   `json:"turnstileSitekey"` //Turnstile sitekey of the instance, if it has bot protection. See ChallengeSolver.
 }
 
 // This is ServerInfo.Git struct, it contains informtions about the git commit (from cobalt) the server is using.
@@ -56,6 +87,10 @@ type CobaltGitInformation struct {
 // This function is called before Run() to check if the cobalt server used is reachable.
 // If you can't contact the main server, try using another instance using GetCobaltinstances().
 func CobaltServerInfo(api string) (*ServerInfo, error) {
+	return defaultCobalt().serverInfo(api)
+}
+
+func (c *Cobalt) serverInfo(api string) (*ServerInfo, error) {
 	if !strings.HasPrefix(api, "http") {
 		api = "http://" + api
 	}
@@ -70,24 +105,44 @@ func CobaltServerInfo(api string) (*ServerInfo, error) {
 	}
 
 	//Check if the server is reachable
-	res, err := genericHttpRequest(parseApiUrl.String(), http.MethodGet, nil)
+	var serverResponse ServerInfo
+	err = c.getInstanceJSON(parseApiUrl.String(), &serverResponse)
+	if err != nil || serverResponse.Cobalt.Version == "" {
+		//cobalt 7.x and older don't answer at the root, they have the server info at /api/serverInfo.
+		if legacy, legacyErr := c.legacyServerInfo(parseApiUrl); legacyErr == nil {
+			return legacy, nil
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
 
-	jsonbody, err := io.ReadAll(res.Body)
+	return &serverResponse, nil
+}
+
+// getInstanceJSON gets url from an instance and decodes the json response into v, with the health check timeout.
+func (c *Cobalt) getInstanceJSON(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to create the request to %v: %w", url, err)
 	}
+	req.Header.Add("User-Agent", c.userAgent)
 
-	var serverResponse ServerInfo
-	err = json.Unmarshal(jsonbody, &serverResponse)
+	res, err := c.withTimeout(c.healthTimeout).doInstance(req)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with %v", res.Status)
 	}
 
-	return &serverResponse, nil
+	jsonbody, err := c.readBody(res)
+	if err != nil {
+		return err
+	}
+
+	return c.decodeInstanceJSON(res, jsonbody, v)
 }
 
 //Server info end
@@ -96,20 +151,63 @@ func CobaltServerInfo(api string) (*ServerInfo, error) {
 
 // Struct Settings contains changable options that you can change before download. An URL MUST be set before calling gobalt.Run(Settings).
 type Settings struct {
-	Url                   string       `json:"url"`                   //Any URL from bilibili.com, instagram, pinterest, reddit, rutube, soundcloud, streamable, tiktok, tumblr, twitch clips, twitter/x, vimeo, vine archive, vk or youtube (as long it's configured on the instance).
-	Mode                  downloadMode `json:"downloadMode"`          //Mode to download the videos, either Auto, Audio or Mute. Default: Auto
-	Proxy                 bool         `json:"alwaysProxy"`           //Tunnel downloaded file thru cobalt, bypassing potential restrictions and protecting your identity and privacy. Default: false
-	AudioBitrate          int          `json:"audioBitrate,string"`   //Audio Bitrate settings. Values: 320Kbps, 256Kbps, 128Kbps, 96Kbps, 64Kbps or 8Kbps. Default: 128
-	AudioFormat           audioCodec   `json:"audioFormat"`           //"Best", .mp3, .opus, .ogg or .wav. If not specified will default to "Best".
-	FilenameStyle         pattern      `json:"filenameStyle"`         //"Classic", "Basic", "Pretty" or "Nerdy". Default is "Basic".
-	DisableMetadata       bool         `json:"disableMetadata"`       //Don't include file metadata. Default: false
-	TikTokH265            bool         `json:"tiktokH265"`            //Allows downloading TikTok videos in 1080p at cost of compatibility. Default: false
-	TikTokFullAudio       bool         `json:"tiktokFullAudio"`       //Enables download of original sound used in a TikTok video. Default: false
-	TwitterConvertGif     bool         `json:"twitterGif"`            //Changes whether twitter gifs should be converted to .gif (Twitter gifs are usually looping .mp4s). Default: true
-	VideoQuality          int          `json:"videoQuality,string"`   //144p to 2160p (4K), if not specified will default to 1080p.
-	YoutubeDubbedAudio    bool         `json:"youtubeDubBrowserLang"` //Downloads the YouTube dubbed audio according to the value set in YoutubeDubbedLanguage (and if present). Default is English (US). Follows the ISO 639-1 standard.
-	YoutubeDubbedLanguage string       `json:"youtubeDubLang"`        //Language code to download the dubbed audio, Default is "en".
-	YoutubeVideoFormat    videoCodecs  `json:"youtubeVideoCodec"`     //Which video format to download from YouTube, see videoCodecs type for details.
+	Url                   string       `json:"url"`                           //Any URL from bilibili.com, instagram, pinterest, reddit, rutube, soundcloud, streamable, tiktok, tumblr, twitch clips, twitter/x, vimeo, vine archive, vk or youtube (as long it's configured on the instance).
+	Mode                  downloadMode `json:"downloadMode"`                  //Mode to download the videos, either Auto, Audio or Mute. Default: Auto
+	Proxy                 bool         `json:"alwaysProxy"`                   //Tunnel downloaded file thru cobalt, bypassing potential restrictions and protecting your identity and privacy. Default: false
+	AudioBitrate          audioBitrate `json:"audioBitrate,string,omitempty"` //Audio Bitrate settings, see the audioBitrate constants. 0 uses the default of the instance, 128.
+	AudioFormat           audioCodec   `json:"audioFormat"`                   //"Best", .mp3, .opus, .ogg or .wav. If not specified will default to "Best".
+	FilenameStyle         pattern      `json:"filenameStyle"`                 //"Classic", "Basic", "Pretty" or "Nerdy". Default is "Basic".
+	DisableMetadata       bool         `json:"disableMetadata"`               //Don't include file metadata. Default: false
+	TikTokH265            bool         `json:"tiktokH265"`                    //Allows downloading TikTok videos in 1080p at cost of compatibility. Default: false
+	TikTokFullAudio       bool         `json:"tiktokFullAudio"`               //Enables download of original sound used in a TikTok video. Default: false
+	TwitterConvertGif     bool         `json:"twitterGif"`                    //Changes whether twitter gifs should be converted to .gif (Twitter gifs are usually looping .mp4s). Default: true
+	VideoQuality          videoQuality `json:"videoQuality,omitempty"`        //From Q144 to Q4320 (8K), or QMax for the best available. 0 uses the default of the instance, 1080p.
+	YoutubeDubbedAudio    bool         `json:"youtubeDubBrowserLang"`         //Downloads the YouTube dubbed audio according to the value set in YoutubeDubbedLanguage (and if present). Default is English (US). Follows the ISO 639-1 standard.
+	YoutubeDubbedLanguage string       `json:"youtubeDubLang"`                //Language code to download the dubbed audio, Default is "en".
+	YoutubeVideoFormat    videoCodecs  `json:"youtubeVideoCodec"`             //Which video format to download from YouTube, see videoCodecs type for details.
+
+	SubtitleLanguage string `json:"subtitleLang,omitempty"` //Language code of the subtitles to get, like "en". Tunnels have them embedded, local-processing responses return them as a separate file, see SubtitlesURL. 11.0+ only.
+
+	Extra map[string]any `json:"-"` //Request fields gobalt doesn't know yet, sent as they are. The fields above take precedence. Ignored by 7.x instances.
+}
+
+// ErrInvalidSettings is returned by Settings.Validate, and by Run, for settings cobalt would reject.
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// Validate checks the settings with a fixed set of values, so they fail before reaching the instance. The url isn't checked, see ValidateURL.
+func (s Settings) Validate() error {
+	if s.AudioBitrate != 0 && !slices.Contains(audioBitrates, s.AudioBitrate) {
+		return fmt.Errorf("%w: audio bitrate %v isn't one of %v", ErrInvalidSettings, int(s.AudioBitrate), audioBitrates)
+	}
+	if s.VideoQuality != 0 && !slices.Contains(videoQualities, s.VideoQuality) {
+		return fmt.Errorf("%w: video quality %v isn't one of %v", ErrInvalidSettings, int(s.VideoQuality), videoQualities)
+	}
+	return nil
+}
+
+// MarshalJSON validates the settings and adds the Extra fields to the request.
+func (s Settings) MarshalJSON() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	type settings Settings //Without the MarshalJSON method.
+	body, err := json.Marshal(settings(s))
+	if err != nil || len(s.Extra) == 0 {
+		return body, err
+	}
+
+	fields := make(map[string]json.RawMessage, len(s.Extra))
+	for name, value := range s.Extra {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extra field %v: %w", name, err)
+		}
+		fields[name] = raw
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
 }
 
 type downloadMode string
@@ -147,6 +245,69 @@ const (
 	Pretty  pattern = "pretty"  //Looks like: Video Title (1080p, h264, youtube).mp4 | audio: Audio Title - Audio Author (soundcloud).mp3
 )
 
+type audioBitrate int
+
+const (
+	Bitrate320 audioBitrate = 320 //Best quality, for music.
+	Bitrate256 audioBitrate = 256
+	Bitrate128 audioBitrate = 128 //Default of cobalt.
+	Bitrate96  audioBitrate = 96
+	Bitrate64  audioBitrate = 64
+	Bitrate8   audioBitrate = 8 //Smallest files, only good enough for speech.
+)
+
+// Bitrates accepted by cobalt, from the best.
+var audioBitrates = []audioBitrate{Bitrate320, Bitrate256, Bitrate128, Bitrate96, Bitrate64, Bitrate8}
+
+type videoQuality int
+
+const (
+	QMax  videoQuality = -1 //Best quality available.
+	Q4320 videoQuality = 4320
+	Q2160 videoQuality = 2160 //4K.
+	Q1440 videoQuality = 1440
+	Q1080 videoQuality = 1080 //Default of cobalt.
+	Q720  videoQuality = 720
+	Q480  videoQuality = 480
+	Q360  videoQuality = 360
+	Q240  videoQuality = 240
+	Q144  videoQuality = 144
+)
+
+// Qualities accepted by cobalt, from the best.
+var videoQualities = []videoQuality{QMax, Q4320, Q2160, Q1440, Q1080, Q720, Q480, Q360, Q240, Q144}
+
+// String returns the quality as cobalt expects it, "max" or the height.
+func (q videoQuality) String() string {
+	if q == QMax {
+		return "max"
+	}
+	return strconv.Itoa(int(q))
+}
+
+// MarshalJSON sends the quality as a string, like "1080" or "max".
+func (q videoQuality) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.String())
+}
+
+// UnmarshalJSON reads the quality from a string or a number.
+func (q *videoQuality) UnmarshalJSON(data []byte) error {
+	var quality string
+	if err := json.Unmarshal(data, &quality); err != nil {
+		quality = string(data)
+	}
+	if quality == "max" {
+		*q = QMax
+		return nil
+	}
+	height, err := strconv.Atoi(quality)
+	if err != nil {
+		return fmt.Errorf("invalid video quality %s", data)
+	}
+	*q = videoQuality(height)
+	return nil
+}
+
 // This function creates the Settings struct with these default values:
 //
 //   - Url: "" (empty)
@@ -163,9 +324,9 @@ func CreateDefaultSettings() Settings {
 	options := Settings{
 		Url:                   "",
 		YoutubeVideoFormat:    H264,
-		VideoQuality:          1080,
+		VideoQuality:          Q1080,
 		AudioFormat:           Best,
-		AudioBitrate:          128,
+		AudioBitrate:          Bitrate128,
 		FilenameStyle:         Basic,
 		TwitterConvertGif:     true,
 		Mode:                  Auto,
@@ -176,17 +337,48 @@ func CreateDefaultSettings() Settings {
 
 // Cobalt response to your request
 type CobaltResponse struct {
-	Status string      `json:"status"` //4 possible status. Error = Something went wrong, see CobaltResponse.Error.Code | Tunnel or Redirect = Everything is right. | Picker = Multiple media, see CobaltResponse.Picker.
-	Picker *[]struct { //This is an array of items, each containing the media type, url to download and thumbnail.
-		Type  string `json:"type"`  //Type of the media, either photo, video or gif
-		URL   string `json:"url"`   //Url to download.
-		Thumb string `json:"thumb"` //Media preview url, optional.
-	} `json:"picker"`
-	URL      string `json:"url"`      //Returns the download link. If the status is picker this field will be empty. Direct link to a file or a link to cobalt's live render.
-	Filename string `json:"filename"` //Various text, mostly used for errors.
-	Error    *Error `json:"error"`    //Error information, may be <NIL> if theres no error.
+	Status   string        `json:"status"`   //4 possible status. Error = Something went wrong, see CobaltResponse.Error.Code | Tunnel or Redirect = Everything is right. | Picker = Multiple media, see CobaltResponse.Picker.
+	Picker   *[]PickerItem `json:"picker"`   //This is an array of items, each containing the media type, url to download and thumbnail.
+	URL      string        `json:"url"`      //Returns the download link. If the status is picker this field will be empty. Direct link to a file or a link to cobalt's live render.
+	Filename string        `json:"filename"` //Various text, mostly used for errors.
+	Error    *Error        `json:"error"`    //Error information, may be <NIL> if theres no error.
+
+	Audio         string `json:"audio"`         //Background audio of a picker, like the sound of a TikTok slideshow. Empty if there's none.
+	AudioFilename string `json:"audioFilename"` //Filename of the background audio of a picker.
+
+	Tunnel []string     `json:"tunnel"` //Urls of a local-processing response, to be merged by the client. The subtitles are last if Output.Subtitles is set.
+	Output *LocalOutput `json:"output"` //File to make from the Tunnel urls of a local-processing response.
+
+	ObtainedAt time.Time `json:"-"` //When Run got the response.
+	ExpiresAt  time.Time `json:"-"` //When the urls stop working, if the instance or service says it. Zero if unknown, see Expired.
+
+	httpStatus int           //Status code of the http response.
+	retryAfter time.Duration //Wait before retrying a rate limited request, from the response headers. -1 if not sent.
+	job        *responseJob  //Job the response was returned for, see Refresh.
+}
+
+// LocalOutput describes the file a local-processing response is made into.
+type LocalOutput struct {
+	Type      string `json:"type"`      //Mime type of the file, like video/mp4.
+	Filename  string `json:"filename"`  //Name of the file.
+	Subtitles bool   `json:"subtitles"` //If the last Tunnel url is the subtitles.
+}
+
+// PickerItem is one of the media of a picker response.
+type PickerItem struct {
+	Type  PickerMediaType `json:"type"`  //Type of the media, either Photo, Video or Gif.
+	URL   string          `json:"url"`   //Url to download.
+	Thumb string          `json:"thumb"` //Media preview url, optional.
 }
 
+type PickerMediaType string
+
+const (
+	Photo PickerMediaType = "photo" //An image.
+	Video PickerMediaType = "video" //A video, with audio if it has any.
+	Gif   PickerMediaType = "gif"   //An animated gif, or a looping video without audio, depending on Settings.TwitterConvertGif.
+)
+
 type Error struct {
 	Code    string  `json:"code"`    // Machine-readable error code explaining the failure reason.
 	Context Context `json:"context"` //(optional) container for providing more context.
@@ -199,52 +391,237 @@ type Context struct {
 
 // Run(gobalt.Settings) sends the request to the provided cobalt api and returns the server response (gobalt.CobaltResponse) and error, use this to download something AFTER setting your desired configuration.
 func Run(options Settings) (*CobaltResponse, error) {
+	return defaultCobalt().Run(options)
+}
+
+// RunContext is Run with a context. It's used for the job request and the waits before retrying rate limited jobs, see WithRateLimitRetry.
+func RunContext(ctx context.Context, options Settings) (*CobaltResponse, error) {
+	return defaultCobalt().RunContext(ctx, options)
+}
+
+// Run sends the request to the instance of this Cobalt, see the package level Run.
+func (c *Cobalt) Run(options Settings) (*CobaltResponse, error) {
+	return c.run(context.Background(), c.api, options)
+}
+
+// RunContext sends the request to the instance of this Cobalt, see the package level RunContext.
+func (c *Cobalt) RunContext(ctx context.Context, options Settings) (*CobaltResponse, error) {
+	return c.run(ctx, c.api, options)
+}
+
+// run sends the request to the cobalt instance at api. With WithSoftFail, error responses are returned along with the error.
+func (c *Cobalt) run(ctx context.Context, api string, options Settings) (*CobaltResponse, error) {
+	media, err := c.runShared(ctx, api, options)
+	if err != nil && c.softFail {
+		return errorResponse(err), err
+	}
+	return media, err
+}
+
+// runShared sends the request to the cobalt instance at api. If enabled, cached responses are returned and identical concurrent requests share the job.
+func (c *Cobalt) runShared(ctx context.Context, api string, options Settings) (*CobaltResponse, error) {
+	if (c.flights == nil && c.responses == nil) || options.Url == "" {
+		return c.runOnce(ctx, api, options)
+	}
+	key := jobKey(api, options)
+	if media := c.responses.get(key); media != nil {
+		if media.job == nil {
+			//Loaded from the store.
+			media.job = &responseJob{cobalt: c, api: api, settings: options}
+		}
+		return media, nil
+	}
+	job := func(ctx context.Context) (*CobaltResponse, error) {
+		media, err := c.runOnce(ctx, api, options)
+		if err == nil {
+			c.responses.set(key, media)
+		}
+		return media, err
+	}
+	if c.flights == nil || key == "" {
+		return job(ctx)
+	}
+	return c.flights.do(ctx, key, job)
+}
+
+// runOnce sends the request to the cobalt instance at api.
+func (c *Cobalt) runOnce(ctx context.Context, api string, options Settings) (*CobaltResponse, error) {
 	//Check if an url is set.
 	if options.Url == "" {
 		return nil, errors.New("no url was provided in Settings.Url")
 	}
+	if err := c.jobs.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.jobs.release()
+	original := options
 
-	//Do a basic check to see if the server is online and handling requests
-	_, err := CobaltServerInfo(CobaltApi)
+	if c.expandShortLinks && IsShortLink(options.Url) {
+		expanded, err := c.ExpandURL(ctx, options.Url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand the short link %v: %w", options.Url, err)
+		}
+		options.Url = expanded
+	}
+	if c.normalizeURLs {
+		normalized, err := NormalizeURL(options.Url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url %v: %w", options.Url, err)
+		}
+		options.Url = normalized
+	}
+
+	if err := c.preSubmit(ctx, &options); err != nil {
+		return nil, err
+	}
+
+	//Reject urls that can't point to media (channels, profiles, playlists...) before bothering the instance.
+	if err := ValidateURL(options.Url); err != nil {
+		return nil, err
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	//Do a basic check to see if the server is online and handling requests, unless it was done recently or it's skipped.
+	info := c.infoCache.get(api)
+	if info == nil && !c.skipHealthCheck && !skipsHealthCheck(ctx) {
+		var err error
+		info, err = c.cachedServerInfo(api)
+		if err != nil {
+			return nil, fmt.Errorf("hello to cobalt instance %v failed, reason: %v", api, err)
+		}
+	}
+	if info == nil {
+		info = &ServerInfo{Cobalt: CobaltServerInformation{Version: cmp.Or(c.assumedVersion, latestCobaltVersion)}}
+	}
+	media, err := c.runJob(ctx, api, options, info)
+	if err == nil && c.verifyURLs {
+		media, err = c.verifyJob(ctx, api, options, info, media)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("hello to cobalt instance %v failed, reason: %v", CobaltApi, err)
+		var cobaltErr *CobaltError
+		if errors.As(err, &cobaltErr) && cobaltErr.Code == "error.api.service.disabled" {
+			err = c.serviceNotSupported(api, info, options.Url, cobaltErr)
+		}
+		if staleInfo(ctx, err) {
+			//The instance may have changed (updated, restarted with another configuration), check it again before the next job.
+			c.infoCache.forget(api)
+		}
+		return nil, err
+	}
+	media.obtained(c, api, original)
+	if err := c.postResponse(ctx, options, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// runJob sends the request to the cobalt instance at api, with its server info.
+func (c *Cobalt) runJob(ctx context.Context, api string, options Settings, info *ServerInfo) (*CobaltResponse, error) {
+	if isLegacyVersion(info.Cobalt.Version) {
+		return c.runLegacy(ctx, api, options)
 	}
 
-	jsonBody, err := json.Marshal(options)
+	//Drop the fields the instance version doesn't know, it would reject the request.
+	jsonBody, err := shapeSettings(options, info.Cobalt.Version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal json body due of the following error: %v", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, CobaltApi, strings.NewReader(string(jsonBody)))
-	req.Header.Add("User-Agent", useragent)
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Api-Key "+ApiKey)
+	//Refresh the session before it expires instead of having the job rejected.
+	if c.solver != nil && c.session.expiring(api) {
+		if err := c.newSession(ctx, api, info, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	media, err := c.submit(ctx, api, jsonBody)
 	if err != nil {
 		return nil, err
 	}
+	//Keyless instances with bot protection want a session, or a new one if it expired. Get one with the challenge solver and try again, once.
+	if media.Status == "error" && media.Error != nil && c.solver != nil && needsSession(media.Error.Code) {
+		if err := c.newSession(ctx, api, info, media.Error.Code); err != nil {
+			return nil, err
+		}
+		media, err = c.submit(ctx, api, jsonBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+	//Wait for the rate limit to reset and try again, if enabled and the wait fits.
+	for waited := time.Duration(0); isRateLimited(media); {
+		wait, ok := c.rateLimitWait(ctx, media, waited)
+		if !ok {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		waited += wait
+		media, err = c.submit(ctx, api, jsonBody)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	res, err := Client.Do(req)
+	if media.Status == "error" {
+		//Some instances answer with an error status but without the error object.
+		if media.Error == nil {
+			return nil, errors.New("cobalt rejected our request without an error code")
+		}
+		cobaltErr := c.newCobaltError(media.Error, media.httpStatus)
+		cobaltErr.Response = media
+		return nil, fmt.Errorf("cobalt rejected our request: %w", cobaltErr)
+	}
+	if media.Status == "picker" && media.Picker == nil {
+		return nil, fmt.Errorf("%w: picker response without the picker items", ErrUnexpectedResponse)
+	}
+
+	return media, nil
+}
+
+// submit posts the job to the instance, authenticated with the session token if there's one, or its credential, see WithCredentials.
+func (c *Cobalt) submit(ctx context.Context, api string, jsonBody []byte) (*CobaltResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api, strings.NewReader(string(jsonBody)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", api, err)
+	}
+	req.Header.Add("User-Agent", c.userAgent)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Content-Type", "application/json")
+	if c.language != "" {
+		req.Header.Add("Accept-Language", c.language)
+	}
+	req.Header.Add("Authorization", c.authorization(api))
+
+	res, err := c.withTimeout(c.jobTimeout).doInstance(req)
 	if err != nil {
-		return nil, fmt.Errorf("unable to send your request, %v", err)
+		return nil, fmt.Errorf("unable to send your request, %w", err)
 	}
 	defer res.Body.Close()
 
-	jsonbody, err := io.ReadAll(res.Body)
+	jsonbody, err := c.readBody(res)
 	if err != nil {
 		return nil, err
 	}
 
 	var media CobaltResponse
-	err = json.Unmarshal(jsonbody, &media)
+	err = c.decodeInstanceJSON(res, jsonbody, &media)
+	if err != nil && res.StatusCode == http.StatusTooManyRequests {
+		//Rate limits from a proxy in front of the instance don't come as cobalt json.
+		media, err = CobaltResponse{Status: "error", Error: &Error{Code: "error.api.rate_exceeded"}}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-
-	if media.Status == "error" {
-		return nil, fmt.Errorf("cobalt rejected our request: %v", media.Error.Code)
-	}
-
+	media.httpStatus = res.StatusCode
+	media.retryAfter = retryAfter(res.Header)
 	return &media, nil
 }
 
@@ -294,18 +671,24 @@ type Services struct {
 	Vine          bool `json:"vine"`
 }
 
+// instanceListDisabled turns GetCobaltInstances off, temporary disabled due of instance scraping abuse.
+const instanceListDisabled = true
+
 // GetCobaltInstances makes a request to instances.hyper.lol and returns a list of all online cobalt instances.
+// Use DiscoverInstances for the instances with live reachability and latency.
 func GetCobaltInstances() ([]CobaltInstance, error) {
-	//Temporary disabled due of instance scraping abuse.
-	return nil, errors.New("service unavailable")
+	if instanceListDisabled {
+		return nil, errors.New("service unavailable")
+	}
 
-	res, err := genericHttpRequest("https://instances.hyper.lol/instances.json", http.MethodGet, nil)
+	c := defaultCobalt()
+	res, err := c.genericHttpRequest("https://instances.hyper.lol/instances.json", http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	jsonbody, err := io.ReadAll(res.Body)
+	jsonbody, err := c.readBody(res)
 	if err != nil {
 		return nil, err
 	}
@@ -328,61 +711,191 @@ func GetCobaltInstances() ([]CobaltInstance, error) {
 }
 
 type MediaInfo struct {
-	Size uint   //Media size in bytes.
-	Name string //Media name.
-	Type string //Mime type of the media.
+	Size       uint          //Media size in bytes.
+	Name       string        //Media name.
+	Type       string        //Mime type of the media.
+	Duration   time.Duration //Media duration, read from the container headers. 0 if it couldn't be probed.
+	Width      int           //Video width in pixels, 0 if there's no video or it couldn't be probed.
+	Height     int           //Video height in pixels, 0 if there's no video or it couldn't be probed.
+	VideoCodec string        //Video codec, like h264, vp9 or av1. Empty if there's no video or it couldn't be probed.
+	AudioCodec string        //Audio codec, like aac or opus. Empty if there's no audio or it couldn't be probed.
+	Bitrate    int           //Average bitrate in bits per second, calculated from Size and Duration.
+	FinalURL   string        //Url the media is served from, after following redirects, to hand the media off to another downloader.
+	Redirects  []string      //Urls redirected from, in order. Empty if there were no redirects.
 }
 
 // ProcessMedia(url) attempts to fetch the file size, mime type and name.
+//
+// A HEAD request is tried first. Many tunnels and CDNs refuse HEAD or omit Content-Length, in that case
+// a GET asking for a single byte (Range: bytes=0-0) is made and the real size is read from Content-Range.
+//
+// For audio and video, the container headers (MP4 or WebM) are then read with ranged requests to get the duration,
+// resolution, codecs and bitrate. Probing is best effort, those fields are left empty if it fails.
+//
+// Redirects are followed like Download does with the default RedirectPolicy, the url they end at is FinalURL.
 func ProcessMedia(url string) (*MediaInfo, error) {
-	req, err := genericHttpRequest(url, http.MethodHead, nil)
+	return defaultCobalt().ProcessMedia(url)
+}
+
+// ProcessMediaContext is ProcessMedia with a context and options, see the method.
+func ProcessMediaContext(ctx context.Context, url string, options ProcessMediaOptions) (*MediaInfo, error) {
+	return defaultCobalt().ProcessMediaContext(ctx, url, options)
+}
+
+// ProcessMediaOptions changes the requests made by ProcessMediaContext.
+type ProcessMediaOptions struct {
+	Header    http.Header    //Extra headers sent with every request, like a Referer or Cookie some media servers require.
+	Redirects RedirectPolicy //How redirects are followed.
+}
+
+// ProcessMedia fetches the media information using the http client of this Cobalt, see the package level ProcessMedia.
+func (c *Cobalt) ProcessMedia(url string) (*MediaInfo, error) {
+	return c.ProcessMediaContext(context.Background(), url, ProcessMediaOptions{})
+}
+
+// ProcessMediaContext fetches the media information like ProcessMedia, using the http client, proxy and User-Agent of this Cobalt.
+// Every request is canceled with ctx and sends the headers of options.
+func (c *Cobalt) ProcessMediaContext(ctx context.Context, mediaURL string, options ProcessMediaOptions) (*MediaInfo, error) {
+	start, err := url.Parse(mediaURL)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", mediaURL, err)
+	}
+	var redirects []string
+	traced := *c
+	traced.httpClient = options.Redirects.client(c.httpClient, start, &redirects)
+
+	info, err := traced.processMediaHeaders(ctx, mediaURL, options.Header, &redirects)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
-	_, parsefilename, err := mime.ParseMediaType(req.Header.Get("Content-Disposition"))
-	filename := parsefilename["filename"]
+
+	if info.Type == "" || strings.HasPrefix(info.Type, "video/") || strings.HasPrefix(info.Type, "audio/") || info.Type == "application/octet-stream" {
+		probeContainer(info, traced.rangeFetcher(ctx, info.FinalURL, options.Header))
+	}
+
+	return info, nil
+}
+
+// processMediaHeaders gets the size, name, mime type and redirect chain of the media without downloading it.
+// redirects is where the http client of c records the redirects it follows.
+func (c *Cobalt) processMediaHeaders(ctx context.Context, mediaURL string, header http.Header, redirects *[]string) (*MediaInfo, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, mediaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", mediaURL, err)
+	}
+	addHeaders(request, c.userAgent, header)
+	res, err := c.httpClient.Do(request)
+	if err == nil {
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK && res.Header.Get("Content-Length") != "" {
+			return mediaInfoFromResponse(res, res.Header.Get("Content-Length"), *redirects)
+		}
+	}
+
+	*redirects = nil
+	res, err = c.rangedHttpRequest(ctx, mediaURL, header, 0, 0)
 	if err != nil {
-		filename = path.Base(req.Request.URL.Path)
+		return nil, err
 	}
-	size := req.Header.Get("Content-Length")
+	res.Body.Close()
+
+	size := res.Header.Get("Content-Length")
+	if res.StatusCode == http.StatusPartialContent {
+		size = sizeFromContentRange(res.Header.Get("Content-Range"))
+	}
+
+	return mediaInfoFromResponse(res, size, *redirects)
+}
+
+// mediaInfoFromResponse builds MediaInfo from the response headers, size is passed separately since it may come from Content-Range.
+func mediaInfoFromResponse(res *http.Response, size string, redirects []string) (*MediaInfo, error) {
 	if size == "" {
 		size = "0"
 	}
 	parseSize, err := strconv.Atoi(size)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid media size %q: %w", size, err)
+	}
+	if parseSize < 0 {
+		return nil, fmt.Errorf("invalid media size %q: negative length", size)
 	}
 
 	return &MediaInfo{
-		Size: uint(parseSize),
-		Name: filename,
-		Type: req.Header.Get("Content-Type"),
+		Size:      uint(parseSize),
+		Name:      filenameFromResponse(res),
+		Type:      res.Header.Get("Content-Type"),
+		FinalURL:  res.Request.URL.String(),
+		Redirects: redirects,
 	}, nil
 }
 
+// filenameFromResponse gets the filename from Content-Disposition, then from the tunnel query parameters, then from the url path.
+func filenameFromResponse(res *http.Response) string {
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition"))
+	if err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+
+	query := res.Request.URL.Query()
+	for _, key := range []string{"filename", "name", "fn"} {
+		if v := query.Get(key); v != "" {
+			return path.Base(v)
+		}
+	}
+
+	return path.Base(res.Request.URL.Path)
+}
+
+// sizeFromContentRange returns the complete length from a Content-Range header, like "bytes 0-0/1234".
+// Returns an empty string if the length is unknown ("*") or the header is malformed.
+func sizeFromContentRange(contentRange string) string {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found || total == "*" {
+		return ""
+	}
+	return strings.TrimSpace(total)
+}
+
 // This slice will contain urls of Youtube videos
 type Playlist []string
 
 // Function GetYoutubePlaylist(string) gets an Youtube playlist has parameter, and returns a slice []Playlist with the urls of the playlist.
 func GetYoutubePlaylist(playlist string) (Playlist, error) {
+	return defaultCobalt().Playlist(playlist)
+}
+
+// Playlist gets the urls of a Youtube playlist using the http client of this Cobalt, see GetYoutubePlaylist.
+func (c *Cobalt) Playlist(playlist string) (Playlist, error) {
 	//Parse param url
 	newYoutubePlaylistUrl, err := url.Parse(playlist)
 	if err != nil {
 		return nil, err
 	}
+	//The playlist api doesn't know YouTube Music albums, and music playlists are read with their own api.
+	if newYoutubePlaylistUrl.Hostname() == "music.youtube.com" || strings.HasPrefix(newYoutubePlaylistUrl.Query().Get("list"), "OLAK5uy_") {
+		music, err := c.MusicPlaylist(playlist)
+		if err != nil {
+			return nil, err
+		}
+		return music.URLs(), nil
+	}
 	if !strings.HasSuffix(newYoutubePlaylistUrl.Host, "youtube.com") || newYoutubePlaylistUrl.Path != "/playlist" {
 		return nil, errors.New("non youtube playlist url provided")
 	}
 
-	getUrls, err := genericHttpRequest(fmt.Sprintf("https://playlist.kwiatekmiki.pl/api/getvideos?url=%v", newYoutubePlaylistUrl.String()), http.MethodGet, nil)
+	getUrls, err := c.genericHttpRequest(fmt.Sprintf("https://playlist.kwiatekmiki.pl/api/getvideos?url=%v", newYoutubePlaylistUrl.String()), http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer getUrls.Body.Close()
 	if getUrls.StatusCode != 200 {
 		return nil, fmt.Errorf("failed to get playlists: %v", getUrls.Status)
 	}
 
-	unmarshalBody, err := io.ReadAll(getUrls.Body)
+	unmarshalBody, err := c.readBody(getUrls)
 	if err != nil {
 		return nil, err
 	}
@@ -397,22 +910,107 @@ func GetYoutubePlaylist(playlist string) (Playlist, error) {
 }
 
 // Function to do generic, less complex http requests, to avoid code repetitions. Internal use of the library only.
-func genericHttpRequest(url, method string, body io.Reader) (*http.Response, error) {
+func (c *Cobalt) genericHttpRequest(url, method string, body io.Reader) (*http.Response, error) {
 	request, err := http.NewRequest(method, url, body)
-	request.Header.Add("User-Agent", useragent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", url, err)
+	}
+	request.Header.Add("User-Agent", c.userAgent)
 
+	response, err := c.httpClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := Client.Do(request)
+	if response.StatusCode != 200 {
+		response.Body.Close()
+		return nil, fmt.Errorf("request failed with %v", response.Status)
+	}
+
+	return response, nil
+}
+
+// Function to do a GET request for only part of the file (Range: bytes=start-end), with extra headers. Internal use of the library only.
+//
+// Servers ignoring the Range header answer with 200 and the full body, so the caller must close it without reading everything.
+func (c *Cobalt) rangedHttpRequest(ctx context.Context, url string, header http.Header, start, end int64) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	addHeaders(request, c.userAgent, header)
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
 
-	if response.StatusCode != 200 {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusPartialContent {
+		response.Body.Close()
 		return nil, fmt.Errorf("request failed with %v", response.Status)
 	}
 
 	return response, nil
 }
+
+// addHeaders sets the User-Agent and then the extra headers of a request, which can replace the User-Agent.
+func addHeaders(request *http.Request, userAgent string, header http.Header) {
+	request.Header.Set("User-Agent", userAgent)
+	for key, values := range header {
+		request.Header[key] = values
+	}
+}
+
+// ErrResponseTooLarge is returned when a response is bigger than the maximum response size, see WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// readBody reads the body of a response from an instance or a scraped service, up to the maximum response size.
+// Media isn't read with it, downloads are streamed.
+func (c *Cobalt) readBody(res *http.Response) ([]byte, error) {
+	if c.maxResponseSize <= 0 {
+		return io.ReadAll(res.Body)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, c.maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > c.maxResponseSize {
+		return nil, fmt.Errorf("%w: %v sent more than %v bytes", ErrResponseTooLarge, res.Request.URL.Host, c.maxResponseSize)
+	}
+	return body, nil
+}
+
+// ErrUnexpectedResponse is returned when a cobalt instance doesn't answer with json, usually because the url points to the web app instead of the api.
+var ErrUnexpectedResponse = errors.New("cobalt instance did not answer with json")
+
+// ErrSchemaDrift is returned in strict mode when a cobalt instance answers with fields gobalt doesn't know, see WithStrictJSON.
+var ErrSchemaDrift = errors.New("cobalt instance answered with an unexpected schema")
+
+// decodeInstanceJSON unmarshals the body of a cobalt api response into v. If the body isn't json, the error includes the status code,
+// content type and the start of the body, with a hint when it looks like a web page.
+func (c *Cobalt) decodeInstanceJSON(res *http.Response, body []byte, v any) error {
+	if json.Valid(body) {
+		if !c.strictJSON {
+			return json.Unmarshal(body, v)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(v); err != nil {
+			return fmt.Errorf("%w: %v: %v, the instance may run a cobalt version gobalt doesn't support yet", ErrSchemaDrift, res.Request.URL, err)
+		}
+		return nil
+	}
+
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > 200 {
+		snippet = snippet[:200] + "..."
+	}
+	err := fmt.Errorf("%w: %v answered with %v (%v): %q", ErrUnexpectedResponse, res.Request.URL, res.Status, res.Header.Get("Content-Type"), snippet)
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType == "text/html" || strings.HasPrefix(strings.TrimSpace(string(body)), "<") {
+		err = fmt.Errorf("%w. This looks like a web page, make sure you're using the api url of the instance and not the url of its web app", err)
+	}
+	return err
+}
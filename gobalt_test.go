@@ -1,8 +1,17 @@
 package gobalt
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCobaltDownload(t *testing.T) {
@@ -74,3 +83,202 @@ func TestPlaylistGet(t *testing.T) {
 		t.Log(v)
 	}
 }
+
+func TestProcessMediaRangeFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") == "" {
+			t.Errorf("expected a range request")
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(make([]byte, 123456)))
+	}))
+	defer server.Close()
+
+	media, err := ProcessMedia(server.URL + "/tunnel?id=abc&filename=video.mp4")
+	if err != nil {
+		t.Fatalf("failed processing media because %v", err)
+	}
+	if media.Size != 123456 || media.Name != "video.mp4" || media.Type != "video/mp4" {
+		t.Fatalf("got unexpected media info: %+v", media)
+	}
+}
+
+func TestProcessMediaRedirects(t *testing.T) {
+	server := redirectServer(t)
+
+	media, err := ProcessMedia(server.URL + "/a")
+	if err != nil {
+		t.Fatalf("failed processing media because %v", err)
+	}
+	if media.FinalURL != server.URL+"/file" || len(media.Redirects) != 2 || media.Redirects[0] != server.URL+"/a" || media.Redirects[1] != server.URL+"/b" {
+		t.Errorf("unexpected redirect chain: %v -> %v", media.Redirects, media.FinalURL)
+	}
+	if media.Size != 5 || media.Name != "file" {
+		t.Errorf("got unexpected media info: %+v", media)
+	}
+
+	if _, err := ProcessMedia(server.URL + "/loop1"); !errors.Is(err, ErrRedirectLoop) {
+		t.Errorf("expected a redirect loop error, got %v", err)
+	}
+}
+
+func TestProcessMediaContext(t *testing.T) {
+	var referers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referers = append(referers, r.Header.Get("Referer"))
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(make([]byte, 64)))
+	}))
+	defer server.Close()
+
+	header := http.Header{"Referer": {"https://example.com/"}}
+	if _, err := ProcessMediaContext(context.Background(), server.URL+"/video.mp4", ProcessMediaOptions{Header: header}); err != nil {
+		t.Fatalf("failed processing media because %v", err)
+	}
+	for _, referer := range referers {
+		if referer != "https://example.com/" {
+			t.Errorf("expected every request to send the headers, got %q", referers)
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ProcessMediaContext(ctx, server.URL+"/slow", ProcessMediaOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+}
+
+func TestRunMalformedResponses(t *testing.T) {
+	responses := []struct {
+		body     string
+		expected error  //Kind of the error, nil if there's none to check.
+		explains string //Part of the error message.
+	}{
+		{`{"status":"error"}`, nil, "without an error code"},
+		{`{"status":"error","error":null}`, nil, "without an error code"},
+		{`<!DOCTYPE html><html></html>`, ErrUnexpectedResponse, "looks like a web page"},
+		{``, ErrUnexpectedResponse, "200 OK"},
+		{`{"status":"picker","picker":null}`, ErrUnexpectedResponse, "without the picker items"},
+	}
+	for _, response := range responses {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`{"cobalt":{"version":"10.0.0"}}`))
+				return
+			}
+			w.Write([]byte(response.body))
+		}))
+		t.Cleanup(server.Close)
+
+		oldApi := CobaltApi
+		CobaltApi = server.URL
+		t.Cleanup(func() { CobaltApi = oldApi })
+		settings := CreateDefaultSettings()
+		settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+		media, err := Run(settings)
+		if err == nil || media != nil {
+			t.Errorf("%q: expected an error and no response, got %+v, %v", response.body, media, err)
+			continue
+		}
+		if response.expected != nil && !errors.Is(err, response.expected) {
+			t.Errorf("%q: expected %v, got %v", response.body, response.expected, err)
+		}
+		if !strings.Contains(err.Error(), response.explains) {
+			t.Errorf("%q: expected %q in the error, got %v", response.body, response.explains, err)
+		}
+	}
+}
+
+func TestRunInvalidInstanceURL(t *testing.T) {
+	oldApi := CobaltApi
+	defer func() { CobaltApi = oldApi }()
+
+	CobaltApi = "http://bad\x7f host"
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	if _, err := Run(settings); err == nil {
+		t.Fatal("expected an error using an invalid instance url")
+	}
+	if _, err := ProcessMedia("http://[::1"); err == nil {
+		t.Fatal("expected an error processing an invalid url")
+	}
+}
+
+func TestServerInfoHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<!DOCTYPE html>\n<html><head><title>cobalt</title></head></html>"))
+	}))
+	defer server.Close()
+
+	_, err := CobaltServerInfo(server.URL)
+	if !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("expected ErrUnexpectedResponse, got %v", err)
+	}
+	for _, expected := range []string{"200 OK", "text/html", "<!DOCTYPE html> <html>", "api url"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in the error: %v", expected, err)
+		}
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	if err := settings.Validate(); err != nil {
+		t.Errorf("expected the default settings to be valid, got %v", err)
+	}
+
+	settings.AudioBitrate = 100
+	if err := settings.Validate(); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("expected ErrInvalidSettings, got %v", err)
+	}
+	if _, err := json.Marshal(settings); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("expected marshaling to fail, got %v", err)
+	}
+	if _, err := New(WithAPI("http://127.0.0.1:1")).Run(settings); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("expected Run to fail before the request, got %v", err)
+	}
+
+	settings.AudioBitrate = 0
+	body, err := json.Marshal(settings)
+	if err != nil || strings.Contains(string(body), "audioBitrate") {
+		t.Errorf("expected no bitrate to leave the instance default, got %s, %v", body, err)
+	}
+}
+
+func TestVideoQuality(t *testing.T) {
+	settings := Settings{Url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", VideoQuality: QMax}
+	body, err := json.Marshal(settings)
+	if err != nil || !strings.Contains(string(body), `"videoQuality":"max"`) {
+		t.Errorf("unexpected request %s, %v", body, err)
+	}
+	settings.VideoQuality = Q720
+	if body, _ := json.Marshal(settings); !strings.Contains(string(body), `"videoQuality":"720"`) {
+		t.Errorf("unexpected request %s", body)
+	}
+	if legacy := legacySettingsFrom(Settings{VideoQuality: QMax}); legacy.VideoQuality != "max" {
+		t.Errorf("unexpected 7.x quality %q", legacy.VideoQuality)
+	}
+
+	settings.VideoQuality = 1000
+	if err := settings.Validate(); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("expected ErrInvalidSettings, got %v", err)
+	}
+
+	var decoded struct {
+		Quality []videoQuality `json:"quality"`
+	}
+	if err := json.Unmarshal([]byte(`{"quality":["max","1080",2160]}`), &decoded); err != nil || !slices.Equal(decoded.Quality, []videoQuality{QMax, Q1080, Q2160}) {
+		t.Errorf("unexpected qualities %v, %v", decoded.Quality, err)
+	}
+}
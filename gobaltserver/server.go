@@ -0,0 +1,117 @@
+// Package gobaltserver serves gobalt over a small REST API, to run it as a sidecar of applications not written in Go.
+//
+//	POST /download  Settings as json, answers with the cobalt response.
+//	GET  /instances Instances from the trackers, see gobalt.MergeInstanceTrackers.
+//	GET  /healthz   Server info of the cobalt instance, 503 if it's unreachable.
+//
+// Errors are answered as {"error": "message", "code": "error.api..."}, code only for errors returned by cobalt.
+//
+//	log.Fatal(gobaltserver.ListenAndServe(":8080", gobalt.New()))
+package gobaltserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lostdusty/gobalt/v2"
+)
+
+// MaxRequestSize is the maximum size of a request body, in bytes.
+const MaxRequestSize = 64 * 1024
+
+// Server is the http.Handler of the API.
+type Server struct {
+	Client   gobalt.CobaltClient //Client the requests are made with.
+	Trackers []string            //Instance trackers of /instances, gobalt.DefaultTrackers if empty.
+
+	mux *http.ServeMux
+}
+
+// New returns a Server answering with client.
+func New(client gobalt.CobaltClient) *Server {
+	s := &Server{Client: client, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /download", s.download)
+	s.mux.HandleFunc("GET /instances", s.instances)
+	s.mux.HandleFunc("GET /healthz", s.healthz)
+	return s
+}
+
+// ListenAndServe serves the API for client on addr, like http.ListenAndServe.
+func ListenAndServe(addr string, client gobalt.CobaltClient) error {
+	return http.ListenAndServe(addr, New(client))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	settings := gobalt.CreateDefaultSettings()
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestSize))
+	if err := decoder.Decode(&settings); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid settings: %w", err))
+		return
+	}
+	media, err := s.Client.RunContext(r.Context(), settings)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, media)
+}
+
+func (s *Server) instances(w http.ResponseWriter, r *http.Request) {
+	instances, err := s.Client.MergeInstanceTrackers(r.Context(), s.Trackers...)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, instances)
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	info, err := s.Client.ServerInfo()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Status   string             `json:"status"`
+		Instance string             `json:"instance"`
+		Info     *gobalt.ServerInfo `json:"info"`
+	}{"ok", s.Client.API(), info})
+}
+
+// errorStatus returns the http status answered for an error of Run.
+func errorStatus(err error) int {
+	var urlErr *gobalt.URLError
+	switch {
+	case errors.As(err, &urlErr), errors.Is(err, gobalt.ErrInvalidSettings):
+		return http.StatusBadRequest
+	case errors.Is(err, gobalt.ErrUnsupportedLink), errors.Is(err, gobalt.ErrContentUnavailable), errors.Is(err, gobalt.ErrServiceNotSupported):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, gobalt.ErrRateLimited):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := struct {
+		Error string `json:"error"`
+		Code  string `json:"code,omitempty"`
+	}{Error: err.Error()}
+	var cobaltErr *gobalt.CobaltError
+	if errors.As(err, &cobaltErr) {
+		body.Error, body.Code = cobaltErr.Message(), cobaltErr.Code
+	}
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
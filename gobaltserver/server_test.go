@@ -0,0 +1,88 @@
+package gobaltserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lostdusty/gobalt/v2"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/instances.json":
+			w.Write([]byte(`[{"api":"cobalt.example.com","version":"10.5.4"}]`))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4","url":"http://` + r.Host + `/"},"git":{}}`))
+		default:
+			var settings gobalt.Settings
+			json.NewDecoder(r.Body).Decode(&settings)
+			if strings.Contains(settings.Url, "private") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"status":"error","error":{"code":"error.api.content.video.private"}}`))
+				return
+			}
+			w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+		}
+	}))
+	t.Cleanup(instance.Close)
+
+	server := New(gobalt.New(gobalt.WithAPI(instance.URL)))
+	server.Trackers = []string{instance.URL + "/instances.json"}
+	api := httptest.NewServer(server)
+	t.Cleanup(api.Close)
+	return api
+}
+
+func request(t *testing.T, method, url, body string, v any) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("invalid json %q: %v", data, err)
+		}
+	}
+	return res.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	api := testServer(t)
+
+	var media gobalt.CobaltResponse
+	if status := request(t, http.MethodPost, api.URL+"/download", `{"url":"https://www.youtube.com/watch?v=dQw4w9WgXcQ"}`, &media); status != http.StatusOK || media.URL != "https://example.com/file" {
+		t.Errorf("unexpected download answer %v %+v", status, media)
+	}
+
+	var failure struct{ Error, Code string }
+	if status := request(t, http.MethodPost, api.URL+"/download", `{"url":"https://www.youtube.com/watch?v=private0000"}`, &failure); status != http.StatusUnprocessableEntity || failure.Code != "error.api.content.video.private" || failure.Error != "The video is private." {
+		t.Errorf("unexpected error answer %v %+v", status, failure)
+	}
+	if status := request(t, http.MethodPost, api.URL+"/download", `{"url":`, &failure); status != http.StatusBadRequest {
+		t.Errorf("expected invalid json to be a bad request, got %v", status)
+	}
+
+	var instances []gobalt.CobaltInstance
+	if status := request(t, http.MethodGet, api.URL+"/instances", "", &instances); status != http.StatusOK || len(instances) != 1 {
+		t.Errorf("unexpected instances %v %+v", status, instances)
+	}
+
+	var health struct{ Status string }
+	if status := request(t, http.MethodGet, api.URL+"/healthz", "", &health); status != http.StatusOK || health.Status != "ok" {
+		t.Errorf("unexpected health %v %+v", status, health)
+	}
+	if status := request(t, http.MethodGet, api.URL+"/download", "", nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET /download to be rejected, got %v", status)
+	}
+}
@@ -0,0 +1,101 @@
+package gobalt
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Extra headers for instances behind gateways that require them, like an X-API-Gateway-Key.
+
+// WithHeader adds a header to every request this Cobalt sends: jobs, server info, downloads and requests to the services.
+// It replaces the header when gobalt sets it too, like User-Agent. Use WithInstanceHeader for credentials only the instance
+// should get.
+func WithHeader(key, value string) Option {
+	return func(c *Cobalt) {
+		if c.header == nil {
+			c.header = http.Header{}
+		}
+		c.header.Add(key, value)
+	}
+}
+
+// WithHeaders adds every header to every request, see WithHeader.
+func WithHeaders(header http.Header) Option {
+	return func(c *Cobalt) {
+		for key, values := range header {
+			for _, value := range values {
+				WithHeader(key, value)(c)
+			}
+		}
+	}
+}
+
+// WithInstanceHeader adds a header to the requests sent to the instance host: jobs, server info and tunnel downloads.
+// Requests to other hosts, like YouTube or the targets of redirects, don't get it, so gateway credentials don't leak.
+// It takes precedence over WithHeader for the same key.
+func WithInstanceHeader(key, value string) Option {
+	return func(c *Cobalt) {
+		if c.instanceHeader == nil {
+			c.instanceHeader = http.Header{}
+		}
+		c.instanceHeader.Add(key, value)
+	}
+}
+
+// WithInstanceHeaders adds every header to the requests sent to the instance host, see WithInstanceHeader.
+func WithInstanceHeaders(header http.Header) Option {
+	return func(c *Cobalt) {
+		for key, values := range header {
+			for _, value := range values {
+				WithInstanceHeader(key, value)(c)
+			}
+		}
+	}
+}
+
+// headerTransport adds header to every request, and instance to the requests sent to host.
+type headerTransport struct {
+	base     http.RoundTripper
+	header   http.Header
+	host     string
+	instance http.Header
+}
+
+// withHeaders wraps the transport of client so every request gets header, and the requests to the instance at api get instance too.
+func withHeaders(client *http.Client, api string, header, instance http.Header) *http.Client {
+	var host string
+	if len(instance) > 0 {
+		if !strings.Contains(api, "://") {
+			api = "https://" + api
+		}
+		if apiURL, err := url.Parse(api); err == nil {
+			host = apiURL.Hostname()
+		}
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &headerTransport{base: base, header: header, host: host, instance: instance}
+	return &wrapped
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	toInstance := t.host != "" && strings.EqualFold(req.URL.Hostname(), t.host)
+	if len(t.header) == 0 && !toInstance {
+		return t.base.RoundTrip(req)
+	}
+	//RoundTrippers must not change the request.
+	req = req.Clone(req.Context())
+	for key, values := range t.header {
+		req.Header[key] = values
+	}
+	if toInstance {
+		for key, values := range t.instance {
+			req.Header[key] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
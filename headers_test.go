@@ -0,0 +1,46 @@
+package gobalt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithHeader(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPI(server.URL),
+		WithHeader("X-Request-Source", "archiver"),
+		WithHeaders(http.Header{"User-Agent": {"gateway-client"}}),
+		WithInstanceHeader("X-API-Gateway-Key", "secret"),
+		WithInstanceHeaders(http.Header{"X-Request-Source": {"instance"}}),
+	)
+	if _, err := client.ServerInfo(); err != nil {
+		t.Fatalf("server info failed: %v", err)
+	}
+	//Same server, another host name.
+	stream, err := client.OpenStream(strings.Replace(server.URL, "127.0.0.1", "localhost", 1), DownloadOptions{})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	stream.Close()
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 requests, got %v", len(received))
+	}
+	instance, other := received[0], received[1]
+	if instance.Get("X-API-Gateway-Key") != "secret" || instance.Get("User-Agent") != "gateway-client" || instance.Get("X-Request-Source") != "instance" {
+		t.Errorf("expected every header on the instance request, the instance ones taking precedence, got %v", instance)
+	}
+	if other.Get("X-API-Gateway-Key") != "" || other.Get("User-Agent") != "gateway-client" || other.Get("X-Request-Source") != "archiver" {
+		t.Errorf("expected only the headers of WithHeader on a request to another host, got %v", other)
+	}
+}
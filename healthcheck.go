@@ -0,0 +1,121 @@
+package gobalt
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Checking discovered instances concurrently, so the instance lists carry live reachability and latency
+// instead of whatever the trackers reported last.
+
+// Default settings of CheckInstances.
+const (
+	DefaultCheckWorkers = 16              //Instances checked at the same time.
+	DefaultCheckTimeout = 5 * time.Second //Time an instance has to answer.
+)
+
+// CheckOptions configures CheckInstances.
+type CheckOptions struct {
+	Workers int           //Instances checked at the same time. DefaultCheckWorkers if 0 or less.
+	Timeout time.Duration //Time each instance has to answer the server info request. DefaultCheckTimeout if 0 or less.
+}
+
+// CheckInstances health checks every instance concurrently, see the method.
+func CheckInstances(ctx context.Context, instances []CobaltInstance, options CheckOptions) []Candidate {
+	return defaultCobalt().CheckInstances(ctx, instances, options)
+}
+
+// CheckInstances makes a server info request to every instance, at most options.Workers at the same time, and returns them
+// as candidates in the same order. APIOnline is set to whether the instance answered before options.Timeout and Latency to the
+// time it took, -1 if it didn't answer. The version, commit, branch and start time it reports replace the ones from the tracker.
+func (c *Cobalt) CheckInstances(ctx context.Context, instances []CobaltInstance, options CheckOptions) []Candidate {
+	if options.Workers <= 0 {
+		options.Workers = DefaultCheckWorkers
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultCheckTimeout
+	}
+
+	candidates := make([]Candidate, len(instances))
+	workers := make(chan struct{}, options.Workers)
+	var wg sync.WaitGroup
+	for i, instance := range instances {
+		candidates[i] = Candidate{CobaltInstance: instance, Latency: -1}
+		candidates[i].APIOnline = false //Until the instance answers.
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		wg.Add(1)
+		go func(candidate *Candidate) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			c.checkInstance(ctx, candidate, options.Timeout)
+		}(&candidates[i])
+	}
+	wg.Wait()
+	return candidates
+}
+
+// checkInstance annotates candidate with the answer of the instance to a server info request.
+func (c *Cobalt) checkInstance(ctx context.Context, candidate *Candidate, timeout time.Duration) {
+	type answer struct {
+		info *ServerInfo
+		err  error
+	}
+	done := make(chan answer, 1)
+	start := time.Now()
+	go func() {
+		info, err := c.serverInfo(candidate.apiURL())
+		done <- answer{info, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case answer := <-done:
+		if answer.err != nil {
+			return
+		}
+		candidate.APIOnline = true
+		candidate.Latency = max(time.Since(start), time.Nanosecond)
+		info := answer.info
+		for _, field := range []struct {
+			instance *string
+			live     string
+		}{
+			{&candidate.Version, info.Cobalt.Version},
+			{&candidate.Commit, info.Git.Commit},
+			{&candidate.Branch, info.Git.Branch},
+		} {
+			if field.live != "" {
+				*field.instance = field.live
+			}
+		}
+		if startTime, err := strconv.ParseInt(info.Cobalt.StartTime, 10, 64); err == nil && startTime > 0 {
+			candidate.StartTime = startTime
+		}
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// DiscoverInstances gets the instances from trackers and health checks them, see the method.
+func DiscoverInstances(ctx context.Context, options CheckOptions, trackers ...string) ([]Candidate, error) {
+	return defaultCobalt().DiscoverInstances(ctx, options, trackers...)
+}
+
+// DiscoverInstances is MergeInstanceTrackers followed by CheckInstances: the instances listed by trackers (DefaultTrackers if none),
+// with live reachability and latency. Rank them with RankInstances, which drops the ones that didn't answer.
+func (c *Cobalt) DiscoverInstances(ctx context.Context, options CheckOptions, trackers ...string) ([]Candidate, error) {
+	instances, err := c.MergeInstanceTrackers(ctx, trackers...)
+	if err != nil {
+		return nil, err
+	}
+	return c.CheckInstances(ctx, instances, options), nil
+}
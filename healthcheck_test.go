@@ -0,0 +1,57 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckInstances(t *testing.T) {
+	var running, peak atomic.Int32
+	instance := func(delay time.Duration) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if now := running.Add(1); now > peak.Load() {
+				peak.Store(now)
+			}
+			defer running.Add(-1)
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4","startTime":"1700000000000"},"git":{"commit":"abcdef1","branch":"main"}}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	fast, slow := instance(0), instance(500*time.Millisecond)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	instances := []CobaltInstance{
+		{API: fast.URL, Version: "10.0.0", APIOnline: false},
+		{API: slow.URL, APIOnline: true},
+		{API: down.URL, APIOnline: true},
+		{API: fast.URL},
+	}
+	candidates := CheckInstances(context.Background(), instances, CheckOptions{Workers: 2, Timeout: 200 * time.Millisecond})
+	if len(candidates) != len(instances) {
+		t.Fatalf("expected every instance, got %+v", candidates)
+	}
+	live := candidates[0]
+	if !live.APIOnline || live.Latency <= 0 || live.Version != "10.5.4" || live.Commit != "abcdef1" || live.StartTime != 1700000000000 {
+		t.Errorf("unexpected live instance %+v", live)
+	}
+	for _, candidate := range candidates[1:3] {
+		if candidate.APIOnline || candidate.Latency != -1 {
+			t.Errorf("expected %v to be offline, got %+v", candidate.API, candidate)
+		}
+	}
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 checks at the same time, got %v", peak.Load())
+	}
+}
@@ -0,0 +1,341 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HLS (.m3u8) streams are downloaded by fetching the segments of the best variant concurrently and concatenating them,
+// so Download and OpenStream give the media instead of the playlist file. Separate audio renditions aren't merged.
+
+// ErrUnsupportedHLS is returned for HLS features gobalt can't download, like byte ranges or SAMPLE-AES encryption.
+var ErrUnsupportedHLS = errors.New("unsupported hls stream")
+
+const (
+	hlsConcurrency     = 4       //Segments fetched at the same time, and kept in memory.
+	hlsMaxManifestSize = 8 << 20 //Maximum size of a playlist file.
+)
+
+var hlsAttribute = regexp.MustCompile(`([A-Z0-9-]+)=("[^"]*"|[^,]*)`)
+
+type hlsSegment struct {
+	url string
+	key string //Url of the AES-128 key, empty if not encrypted.
+	iv  []byte
+}
+
+// hlsPlaylist is a parsed playlist, with variants if it's a master playlist or segments if it's a media playlist.
+type hlsPlaylist struct {
+	variants []hlsVariant
+	init     string //Url of the initialization segment (EXT-X-MAP) of fragmented mp4 streams.
+	segments []hlsSegment
+}
+
+type hlsVariant struct {
+	url       string
+	bandwidth int
+}
+
+// isHLS reports if the response is an HLS playlist, from its content type or, for generic content types, its url.
+func isHLS(res *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	switch strings.ToLower(mediaType) {
+	case "application/vnd.apple.mpegurl", "application/x-mpegurl", "audio/mpegurl", "audio/x-mpegurl":
+		return true
+	case "", "application/octet-stream", "text/plain":
+		return strings.EqualFold(path.Ext(res.Request.URL.Path), ".m3u8")
+	}
+	return false
+}
+
+// parseHLS parses the playlist at base.
+func parseHLS(base *url.URL, manifest string) (*hlsPlaylist, error) {
+	lines := strings.Split(strings.ReplaceAll(manifest, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(lines[0]) != "#EXTM3U" {
+		return nil, fmt.Errorf("%w: %v isn't an m3u8 playlist", ErrUnsupportedHLS, base)
+	}
+	resolve := func(ref string) (string, error) {
+		u, err := base.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid url %q in %v: %w", ref, base, err)
+		}
+		return u.String(), nil
+	}
+
+	playlist := &hlsPlaylist{}
+	var sequence uint64
+	var key string
+	var keyIV []byte
+	var variant *hlsVariant
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		tag, value, _ := strings.Cut(line, ":")
+		attributes := map[string]string{}
+		for _, m := range hlsAttribute.FindAllStringSubmatch(value, -1) {
+			attributes[m[1]] = strings.Trim(m[2], `"`)
+		}
+
+		switch {
+		case line == "":
+		case tag == "#EXT-X-STREAM-INF":
+			bandwidth, _ := strconv.Atoi(attributes["BANDWIDTH"])
+			variant = &hlsVariant{bandwidth: bandwidth}
+		case tag == "#EXT-X-MEDIA-SEQUENCE":
+			sequence, _ = strconv.ParseUint(value, 10, 64)
+		case tag == "#EXT-X-BYTERANGE":
+			return nil, fmt.Errorf("%w: byte range segments", ErrUnsupportedHLS)
+		case tag == "#EXT-X-MAP":
+			if _, ok := attributes["BYTERANGE"]; ok {
+				return nil, fmt.Errorf("%w: byte range segments", ErrUnsupportedHLS)
+			}
+			init, err := resolve(attributes["URI"])
+			if err != nil {
+				return nil, err
+			}
+			playlist.init = init
+		case tag == "#EXT-X-KEY":
+			switch attributes["METHOD"] {
+			case "NONE":
+				key, keyIV = "", nil
+			case "AES-128":
+				resolved, err := resolve(attributes["URI"])
+				if err != nil {
+					return nil, err
+				}
+				key, keyIV = resolved, nil
+				if iv := attributes["IV"]; iv != "" {
+					keyIV, err = hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(iv, "0x"), "0X"))
+					if err != nil || len(keyIV) != aes.BlockSize {
+						return nil, fmt.Errorf("invalid IV %q in %v", iv, base)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("%w: %v encryption", ErrUnsupportedHLS, attributes["METHOD"])
+			}
+		case strings.HasPrefix(line, "#"):
+			//Other tags and comments don't change how the media is downloaded.
+		default:
+			resolved, err := resolve(line)
+			if err != nil {
+				return nil, err
+			}
+			if variant != nil {
+				variant.url = resolved
+				playlist.variants = append(playlist.variants, *variant)
+				variant = nil
+				continue
+			}
+			segment := hlsSegment{url: resolved, key: key, iv: keyIV}
+			if key != "" && keyIV == nil {
+				//Without an explicit IV, the media sequence number is the IV.
+				segment.iv = make([]byte, aes.BlockSize)
+				binary.BigEndian.PutUint64(segment.iv[8:], sequence)
+			}
+			playlist.segments = append(playlist.segments, segment)
+			sequence++
+		}
+	}
+
+	if len(playlist.variants) == 0 && len(playlist.segments) == 0 {
+		return nil, fmt.Errorf("%w: %v has no segments", ErrUnsupportedHLS, base)
+	}
+	return playlist, nil
+}
+
+// hlsFetcher gets the manifests, keys and segments of an HLS stream.
+type hlsFetcher struct {
+	ctx       context.Context
+	client    *http.Client
+	userAgent string
+}
+
+func (f *hlsFetcher) get(rawURL string, limit int64) ([]byte, error) {
+	request, err := http.NewRequestWithContext(f.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", rawURL, err)
+	}
+	request.Header.Add("User-Agent", f.userAgent)
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %v failed with %v", rawURL, response.Status)
+	}
+	if limit > 0 {
+		return io.ReadAll(io.LimitReader(response.Body, limit))
+	}
+	return io.ReadAll(response.Body)
+}
+
+// openHLS turns the playlist response into a Stream of the concatenated segments of the variant with the highest bandwidth.
+func (c *Cobalt) openHLS(ctx context.Context, client *http.Client, response *http.Response, redirects []string) (*Stream, error) {
+	defer response.Body.Close()
+	fetcher := &hlsFetcher{ctx: ctx, client: client, userAgent: c.userAgent}
+
+	manifestURL := response.Request.URL
+	manifest, err := io.ReadAll(io.LimitReader(response.Body, hlsMaxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+	playlist, err := parseHLS(manifestURL, string(manifest))
+	if err != nil {
+		return nil, err
+	}
+	if len(playlist.variants) > 0 {
+		best := playlist.variants[0]
+		for _, variant := range playlist.variants[1:] {
+			if variant.bandwidth > best.bandwidth {
+				best = variant
+			}
+		}
+		manifest, err = fetcher.get(best.url, hlsMaxManifestSize)
+		if err != nil {
+			return nil, err
+		}
+		manifestURL, _ = url.Parse(best.url)
+		playlist, err = parseHLS(manifestURL, string(manifest))
+		if err != nil {
+			return nil, err
+		}
+		if len(playlist.segments) == 0 {
+			return nil, fmt.Errorf("%w: nested master playlists", ErrUnsupportedHLS)
+		}
+	}
+
+	//Keys are usually shared by all segments, get each one once.
+	keys := map[string][]byte{}
+	for _, segment := range playlist.segments {
+		if segment.key == "" || keys[segment.key] != nil {
+			continue
+		}
+		key, err := fetcher.get(segment.key, aes.BlockSize+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the hls key: %w", err)
+		}
+		if len(key) != aes.BlockSize {
+			return nil, fmt.Errorf("invalid hls key from %v", segment.key)
+		}
+		keys[segment.key] = key
+	}
+
+	segments := playlist.segments
+	extension, contentType := "ts", "video/mp2t"
+	if playlist.init != "" {
+		segments = append([]hlsSegment{{url: playlist.init}}, segments...)
+		extension, contentType = "mp4", "video/mp4"
+	}
+
+	filename := filenameFromResponse(response)
+	filename = strings.TrimSuffix(filename, path.Ext(filename)) + "." + extension
+
+	return &Stream{
+		ReadCloser:  newHLSReader(fetcher, segments, keys),
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        -1,
+		FinalURL:    response.Request.URL.String(),
+		Redirects:   redirects,
+		hls:         true,
+	}, nil
+}
+
+// hlsReader reads the segments in order while the next ones are fetched.
+type hlsReader struct {
+	cancel  context.CancelFunc
+	results []chan hlsResult
+	slots   chan struct{} //Taken when a segment fetch starts, released when it's read.
+	next    int
+	current *bytes.Reader
+	err     error
+}
+
+type hlsResult struct {
+	data []byte
+	err  error
+}
+
+func newHLSReader(fetcher *hlsFetcher, segments []hlsSegment, keys map[string][]byte) *hlsReader {
+	ctx, cancel := context.WithCancel(fetcher.ctx)
+	fetcher = &hlsFetcher{ctx: ctx, client: fetcher.client, userAgent: fetcher.userAgent}
+	r := &hlsReader{cancel: cancel, results: make([]chan hlsResult, len(segments)), slots: make(chan struct{}, hlsConcurrency)}
+	for i := range r.results {
+		r.results[i] = make(chan hlsResult, 1)
+	}
+
+	go func() {
+		for i, segment := range segments {
+			select {
+			case r.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int, segment hlsSegment) {
+				data, err := fetcher.get(segment.url, 0)
+				if err == nil && segment.key != "" {
+					data, err = decryptHLSSegment(data, keys[segment.key], segment.iv)
+				}
+				r.results[i] <- hlsResult{data: data, err: err}
+			}(i, segment)
+		}
+	}()
+	return r
+}
+
+func (r *hlsReader) Read(p []byte) (int, error) {
+	for r.current == nil || r.current.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.next == len(r.results) {
+			return 0, io.EOF
+		}
+		result := <-r.results[r.next]
+		<-r.slots
+		if result.err != nil {
+			r.err = fmt.Errorf("hls segment %v: %w", r.next, result.err)
+			r.cancel()
+			return 0, r.err
+		}
+		r.current = bytes.NewReader(result.data)
+		r.next++
+	}
+	return r.current.Read(p)
+}
+
+func (r *hlsReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// decryptHLSSegment decrypts an AES-128-CBC segment and removes the PKCS#7 padding.
+func decryptHLSSegment(data, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted segment isn't a multiple of the block size")
+	}
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(data, data)
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(data) {
+		return nil, errors.New("invalid padding, wrong key?")
+	}
+	return data[:len(data)-padding], nil
+}
@@ -0,0 +1,93 @@
+package gobalt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encryptSegment(t *testing.T, data, key, iv []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	data = append(data, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data
+}
+
+func TestDownloadHLS(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := make([]byte, aes.BlockSize)
+	iv[15] = 7 //Media sequence of the third segment.
+	files := map[string]string{
+		"/master.m3u8":    "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=100000,RESOLUTION=640x360\nlow/index.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS=\"avc1.4d401f,mp4a.40.2\"\nhigh/index.m3u8\n",
+		"/low/index.m3u8": "#EXTM3U\n#EXTINF:4,\nsegment.ts\n#EXT-X-ENDLIST\n",
+		"/high/index.m3u8": "#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:5\n#EXTINF:4,\nsegment0.ts\n#EXTINF:4,\n/high/segment1.ts\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"\n#EXTINF:4,\nsegment2.ts\n#EXT-X-ENDLIST\n",
+		"/high/segment0.ts": "first ",
+		"/high/segment1.ts": "second ",
+		"/high/segment2.ts": string(encryptSegment(t, []byte("third, encrypted"), key, iv)),
+		"/high/key.bin":     string(key),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if filepath.Ext(r.URL.Path) == ".m3u8" {
+			w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		}
+		w.Write([]byte(file))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	result, err := Download(&CobaltResponse{Status: "tunnel", URL: server.URL + "/master.m3u8", Filename: "video.m3u8"}, DownloadOptions{Directory: dir})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if result.Path != filepath.Join(dir, "video.ts") || result.ContentType != "video/mp2t" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if data, _ := os.ReadFile(result.Path); string(data) != "first second third, encrypted" {
+		t.Errorf("unexpected file contents %q", data)
+	}
+
+	delete(files, "/high/segment1.ts")
+	if _, err := Download(&CobaltResponse{Status: "tunnel", URL: server.URL + "/master.m3u8", Filename: "video.m3u8"}, DownloadOptions{Directory: t.TempDir()}); err == nil {
+		t.Errorf("expected a missing segment to fail the download")
+	}
+}
+
+func TestParseHLS(t *testing.T) {
+	base, _ := url.Parse("https://example.com/stream/index.m3u8")
+	playlist, err := parseHLS(base, "#EXTM3U\r\n#EXT-X-MAP:URI=\"init.mp4\"\r\n#EXTINF:2,\r\nhttps://cdn.example.com/a.m4s\r\n")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if playlist.init != "https://example.com/stream/init.mp4" || len(playlist.segments) != 1 || playlist.segments[0].url != "https://cdn.example.com/a.m4s" {
+		t.Errorf("unexpected playlist: %+v", playlist)
+	}
+
+	for _, manifest := range []string{
+		"not a playlist",
+		"#EXTM3U\n#EXT-X-BYTERANGE:100@0\n#EXTINF:2,\na.ts\n",
+		"#EXTM3U\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"key\"\n#EXTINF:2,\na.ts\n",
+		"#EXTM3U\n#EXT-X-ENDLIST\n",
+	} {
+		if _, err := parseHLS(base, manifest); !errors.Is(err, ErrUnsupportedHLS) {
+			t.Errorf("parseHLS(%q) returned %v, expected ErrUnsupportedHLS", manifest, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package http3 is an opt-in HTTP/3 (QUIC) transport for gobalt, falling back to HTTP/2 and HTTP/1.1 when an instance
+// or the network doesn't support it. It's a separate module so gobalt itself doesn't depend on quic-go.
+//
+//	client := gobalt.New(gobalt.WithHTTPClient(&http.Client{Transport: http3.NewTransport(nil)}))
+package http3
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	quichttp3 "github.com/quic-go/quic-go/http3"
+)
+
+// DefaultRetryAfter is how long hosts that failed over HTTP/3 are only contacted with the fallback transport.
+const DefaultRetryAfter = 10 * time.Minute
+
+// Transport sends https requests over HTTP/3, and with the fallback transport if that fails.
+// Plain http requests always use the fallback transport.
+type Transport struct {
+	HTTP3      *quichttp3.Transport //HTTP/3 transport, its QUIC handshake timeout is how long an unreachable host takes to fall back.
+	Fallback   http.RoundTripper    //Transport used when HTTP/3 fails. Default: http.DefaultTransport.
+	RetryAfter time.Duration        //How long a host that failed over HTTP/3 uses the fallback only. Default: DefaultRetryAfter.
+
+	mu     sync.Mutex
+	failed map[string]time.Time //Hosts that failed over HTTP/3, and when.
+}
+
+// NewTransport returns a Transport falling back to fallback, or http.DefaultTransport if nil.
+func NewTransport(fallback http.RoundTripper) *Transport {
+	if fallback == nil {
+		fallback = http.DefaultTransport
+	}
+	return &Transport{
+		HTTP3:    &quichttp3.Transport{QUICConfig: &quic.Config{HandshakeIdleTimeout: 3 * time.Second}},
+		Fallback: fallback,
+	}
+}
+
+// RoundTrip implements http.RoundTripper. Requests with a body that can't be replayed (no GetBody) aren't retried
+// with the fallback transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || t.recentlyFailed(req.URL.Host) {
+		return t.fallback().RoundTrip(req)
+	}
+
+	res, err := t.HTTP3.RoundTrip(req)
+	if err == nil || req.Context().Err() != nil {
+		return res, err
+	}
+	t.markFailed(req.URL.Host)
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return t.fallback().RoundTrip(req)
+}
+
+// Close closes the HTTP/3 connections.
+func (t *Transport) Close() error {
+	return t.HTTP3.Close()
+}
+
+func (t *Transport) fallback() http.RoundTripper {
+	if t.Fallback == nil {
+		return http.DefaultTransport
+	}
+	return t.Fallback
+}
+
+func (t *Transport) recentlyFailed(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	failedAt, ok := t.failed[host]
+	if !ok {
+		return false
+	}
+	retryAfter := t.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = DefaultRetryAfter
+	}
+	if time.Since(failedAt) > retryAfter {
+		delete(t.failed, host)
+		return false
+	}
+	return true
+}
+
+func (t *Transport) markFailed(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failed == nil {
+		t.failed = map[string]time.Time{}
+	}
+	t.failed[host] = time.Now()
+}
@@ -0,0 +1,78 @@
+package http3
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	quichttp3 "github.com/quic-go/quic-go/http3"
+)
+
+var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Write([]byte(r.Proto + " " + string(body)))
+})
+
+// testTransport returns a Transport trusting the certificate of server, with a short QUIC handshake timeout.
+func testTransport(server *httptest.Server) *Transport {
+	transport := NewTransport(server.Client().Transport)
+	transport.HTTP3.TLSClientConfig = &tls.Config{RootCAs: server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+	transport.HTTP3.QUICConfig = &quic.Config{HandshakeIdleTimeout: 200 * time.Millisecond}
+	return transport
+}
+
+func post(t *testing.T, transport http.RoundTripper, url string) string {
+	t.Helper()
+	res, err := (&http.Client{Transport: transport}).Post(url, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return string(body)
+}
+
+func TestTransportHTTP3(t *testing.T) {
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	conn, err := net.ListenPacket("udp", server.Listener.Addr().String())
+	if err != nil {
+		t.Skipf("can't listen on udp: %v", err)
+	}
+	h3 := &quichttp3.Server{Handler: handler, TLSConfig: quichttp3.ConfigureTLSConfig(server.TLS.Clone())}
+	go h3.Serve(conn)
+	defer h3.Close()
+
+	transport := testTransport(server)
+	defer transport.Close()
+	if body := post(t, transport, server.URL); body != "HTTP/3.0 body" {
+		t.Errorf("expected an HTTP/3 request, got %q", body)
+	}
+}
+
+func TestTransportFallback(t *testing.T) {
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	transport := testTransport(server)
+	defer transport.Close()
+	if body := post(t, transport, server.URL); body != "HTTP/1.1 body" {
+		t.Errorf("expected the fallback to replay the request, got %q", body)
+	}
+	if !transport.recentlyFailed(server.Listener.Addr().String()) {
+		t.Errorf("expected the host to be remembered as failed")
+	}
+
+	start := time.Now()
+	post(t, transport, server.URL)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the fallback to be used right away, took %v", elapsed)
+	}
+}
@@ -0,0 +1,149 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cache of the server info of each instance, so Run doesn't make a server info request before every job.
+// The server info of an instance is requested again after the ttl, or after a job failed in a way the instance changing explains.
+
+// DefaultServerInfoTTL is how long the server info of an instance is reused, see WithServerInfoTTL.
+const DefaultServerInfoTTL = time.Minute
+
+// defaultInfoCache is shared by the package level functions.
+var defaultInfoCache = newInfoCache(DefaultServerInfoTTL)
+
+type infoCache struct {
+	ttl   time.Duration
+	store Store //Also keeps the server info, if set. See WithStore.
+
+	mu        sync.Mutex
+	instances map[string]cachedInfo
+}
+
+type cachedInfo struct {
+	info    *ServerInfo
+	expires time.Time
+}
+
+// storedInfo is cachedInfo in a Store.
+type storedInfo struct {
+	Info    *ServerInfo `json:"info"`
+	Expires time.Time   `json:"expires"`
+}
+
+func serverInfoKey(api string) string {
+	return "gobalt/serverinfo/" + api
+}
+
+// newInfoCache returns nil, a disabled cache, if ttl is 0 or less.
+func newInfoCache(ttl time.Duration) *infoCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &infoCache{ttl: ttl, instances: map[string]cachedInfo{}}
+}
+
+func (ic *infoCache) get(api string) *ServerInfo {
+	if ic == nil {
+		return nil
+	}
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	cached, ok := ic.instances[api]
+	if ok && time.Now().Before(cached.expires) {
+		return cached.info
+	}
+	delete(ic.instances, api)
+	var stored storedInfo
+	if storeGet(ic.store, serverInfoKey(api), &stored) && stored.Info != nil && time.Now().Before(stored.Expires) {
+		ic.instances[api] = cachedInfo{info: stored.Info, expires: stored.Expires}
+		return stored.Info
+	}
+	return nil
+}
+
+func (ic *infoCache) set(api string, info *ServerInfo) {
+	if ic == nil {
+		return
+	}
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	cached := cachedInfo{info: info, expires: time.Now().Add(ic.ttl)}
+	ic.instances[api] = cached
+	storeSet(ic.store, serverInfoKey(api), storedInfo{Info: info, Expires: cached.expires}, ic.ttl)
+}
+
+func (ic *infoCache) forget(api string) {
+	if ic == nil {
+		return
+	}
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	delete(ic.instances, api)
+	if ic.store != nil {
+		ic.store.Delete(serverInfoKey(api))
+	}
+}
+
+// staleInfo reports if a job error may come from the instance having changed since its server info was cached: it couldn't
+// be reached or sent something unexpected, failed with a 5xx, or rejected the request of its version or a disabled service.
+// Errors about the link or the content, and ctx being canceled, keep the server info.
+func staleInfo(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var cobaltErr *CobaltError
+	if !errors.As(err, &cobaltErr) {
+		return true
+	}
+	return cobaltErr.Status >= http.StatusInternalServerError || cobaltErr.Code == "error.api.invalid_body" || cobaltErr.Code == "error.api.service.disabled"
+}
+
+// WithServerInfoTTL sets for how long Run reuses the server info of an instance, 0 or less to request it before every job.
+// Default: DefaultServerInfoTTL.
+func WithServerInfoTTL(ttl time.Duration) Option {
+	return func(c *Cobalt) {
+		c.infoCache = newInfoCache(ttl)
+	}
+}
+
+// cachedServerInfo returns the cached server info of the instance at api, requesting it if it isn't cached or expired.
+func (c *Cobalt) cachedServerInfo(api string) (*ServerInfo, error) {
+	if info := c.infoCache.get(api); info != nil {
+		return info, nil
+	}
+	info, err := c.serverInfo(api)
+	if err != nil {
+		return nil, err
+	}
+	c.infoCache.set(api, info)
+	return info, nil
+}
+
+// WithoutHealthCheck makes Run post jobs without requesting the server info of the instance first, for programs that already
+// monitor their instance. Requests are shaped for instanceVersion, or the latest cobalt version gobalt knows if it's empty,
+// unless the server info is cached. See SkipHealthCheck to skip it for a single job.
+func WithoutHealthCheck(instanceVersion string) Option {
+	return func(c *Cobalt) {
+		c.skipHealthCheck = true
+		c.assumedVersion = instanceVersion
+	}
+}
+
+type skipHealthCheckKey struct{}
+
+// SkipHealthCheck returns a context making RunContext post the job without requesting the server info first, see WithoutHealthCheck.
+// The request is shaped for the version given to WithoutHealthCheck, or the latest cobalt version gobalt knows.
+func SkipHealthCheck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipHealthCheckKey{}, true)
+}
+
+func skipsHealthCheck(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipHealthCheckKey{}).(bool)
+	return skip
+}
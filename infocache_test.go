@@ -0,0 +1,134 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServerInfoCache(t *testing.T) {
+	var infoRequests atomic.Int32
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			infoRequests.Add(1)
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"status":"error","error":{"code":"error.api.generic"}}`))
+			return
+		}
+		w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+	}))
+	defer server.Close()
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	run := func(c *Cobalt, times int) {
+		for i := 0; i < times; i++ {
+			c.Run(settings)
+		}
+	}
+
+	run(New(WithAPI(server.URL)), 3)
+	if infoRequests.Load() != 1 {
+		t.Errorf("expected the server info to be reused, got %v requests", infoRequests.Load())
+	}
+
+	infoRequests.Store(0)
+	run(New(WithAPI(server.URL), WithServerInfoTTL(0)), 3)
+	if infoRequests.Load() != 3 {
+		t.Errorf("expected a server info request per job without the cache, got %v", infoRequests.Load())
+	}
+
+	infoRequests.Store(0)
+	client := New(WithAPI(server.URL))
+	fail.Store(true)
+	run(client, 2)
+	fail.Store(false)
+	run(client, 2)
+	if infoRequests.Load() != 3 {
+		t.Errorf("expected the server info to be requested again after each failed job, got %v requests", infoRequests.Load())
+	}
+}
+
+func TestServerInfoCacheKeptOnContentErrors(t *testing.T) {
+	var infoRequests atomic.Int32
+	var code atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			infoRequests.Add(1)
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"status":"error","error":{"code":%q}}`, code.Load())
+	}))
+	defer server.Close()
+
+	client := New(WithAPI(server.URL))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	for _, content := range []string{"error.api.link.invalid", "error.api.content.video.unavailable", "error.api.content.video.private"} {
+		code.Store(content)
+		if _, err := client.Run(settings); err == nil {
+			t.Fatalf("expected %v", content)
+		}
+	}
+	if infoRequests.Load() != 1 {
+		t.Errorf("expected content errors to keep the cached server info, got %v requests", infoRequests.Load())
+	}
+
+	//The instance may have been updated and reject the request of the old version.
+	code.Store("error.api.invalid_body")
+	client.Run(settings)
+	client.Run(settings)
+	if infoRequests.Load() != 2 {
+		t.Errorf("expected the server info to be requested again after an invalid body, got %v requests", infoRequests.Load())
+	}
+}
+
+func TestSkipHealthCheck(t *testing.T) {
+	var infoRequests atomic.Int32
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			infoRequests.Add(1)
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+	}))
+	defer server.Close()
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	if _, err := New(WithAPI(server.URL), WithoutHealthCheck("")).Run(settings); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if _, ok := body["allowH265"]; !ok {
+		t.Errorf("expected the request to be shaped for the latest version, got %v", body)
+	}
+	if _, err := New(WithAPI(server.URL), WithoutHealthCheck("10.5.4")).Run(settings); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if _, ok := body["tiktokH265"]; !ok {
+		t.Errorf("expected the request to be shaped for 10.5.4, got %v", body)
+	}
+	if _, err := New(WithAPI(server.URL)).RunContext(SkipHealthCheck(context.Background()), settings); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if infoRequests.Load() != 0 {
+		t.Errorf("expected no server info requests, got %v", infoRequests.Load())
+	}
+}
@@ -0,0 +1,177 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mcuadros/go-version"
+)
+
+// Compatibility with cobalt 7.x and older instances, which use the /api/json endpoint with different field names.
+// Run detects them from the server info and translates the request and the response.
+
+// legacyServerInfo is the response of /api/serverInfo on cobalt 7.x.
+type legacyServerInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Branch    string `json:"branch"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	StartTime string `json:"startTime"`
+}
+
+// legacySettings is the body of a request to /api/json on cobalt 7.x.
+type legacySettings struct {
+	Url             string `json:"url"`
+	VideoCodec      string `json:"vCodec"`
+	VideoQuality    string `json:"vQuality,omitempty"` //Empty uses the default of the instance, 720p.
+	AudioFormat     string `json:"aFormat"`
+	FilenamePattern string `json:"filenamePattern"`
+	IsAudioOnly     bool   `json:"isAudioOnly"`
+	IsAudioMuted    bool   `json:"isAudioMuted"`
+	IsTTFullAudio   bool   `json:"isTTFullAudio"`
+	DubLang         bool   `json:"dubLang"`
+	DisableMetadata bool   `json:"disableMetadata"`
+	TwitterGif      bool   `json:"twitterGif"`
+	TikTokH265      bool   `json:"tiktokH265"`
+}
+
+// legacyResponse is the response of /api/json on cobalt 7.x.
+type legacyResponse struct {
+	Status string       `json:"status"` //error, redirect, stream, success, rate-limit or picker.
+	Text   string       `json:"text"`
+	URL    string       `json:"url"`
+	Picker []PickerItem `json:"picker"`
+	Audio  string       `json:"audio"` //Background audio of a picker.
+}
+
+// isLegacyVersion reports if the instance version is older than cobalt 10, which changed the api.
+func isLegacyVersion(v string) bool {
+	return v != "" && version.Compare(v, "10.0.0", "<")
+}
+
+// legacyServerInfo gets the server info of a cobalt 7.x instance and converts it to ServerInfo.
+func (c *Cobalt) legacyServerInfo(api *url.URL) (*ServerInfo, error) {
+	var legacy legacyServerInfo
+	if err := c.getInstanceJSON(api.JoinPath("api", "serverInfo").String(), &legacy); err != nil {
+		return nil, err
+	}
+	if legacy.Version == "" {
+		return nil, errors.New("no version in the legacy server info")
+	}
+	return &ServerInfo{
+		Cobalt: CobaltServerInformation{Version: legacy.Version, URL: legacy.URL, StartTime: legacy.StartTime},
+		Git:    CobaltGitInformation{Branch: legacy.Branch, Commit: legacy.Commit},
+	}, nil
+}
+
+// legacySettingsFrom translates the settings to the 7.x field names. The audio bitrate and always proxy settings
+// don't exist on 7.x and are dropped.
+func legacySettingsFrom(options Settings) legacySettings {
+	var quality string
+	if options.VideoQuality != 0 {
+		quality = options.VideoQuality.String()
+	}
+	return legacySettings{
+		Url:             options.Url,
+		VideoCodec:      string(options.YoutubeVideoFormat),
+		VideoQuality:    quality,
+		AudioFormat:     string(options.AudioFormat),
+		FilenamePattern: string(options.FilenameStyle),
+		IsAudioOnly:     options.Mode == Audio,
+		IsAudioMuted:    options.Mode == Mute,
+		IsTTFullAudio:   options.TikTokFullAudio,
+		DubLang:         options.YoutubeDubbedAudio,
+		DisableMetadata: options.DisableMetadata,
+		TwitterGif:      options.TwitterConvertGif,
+		TikTokH265:      options.TikTokH265,
+	}
+}
+
+// runLegacy sends the request to the /api/json endpoint of a cobalt 7.x instance and converts the response.
+func (c *Cobalt) runLegacy(ctx context.Context, api string, options Settings) (*CobaltResponse, error) {
+	if !strings.HasPrefix(api, "http") {
+		api = "https://" + api
+	}
+	endpoint, err := url.JoinPath(api, "api", "json")
+	if err != nil {
+		return nil, fmt.Errorf("net/url failed to parse provided url, check it and try again (details: %v, url: %v)", err, api)
+	}
+
+	jsonBody, err := json.Marshal(legacySettingsFrom(options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json body due of the following error: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(string(jsonBody)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", endpoint, err)
+	}
+	req.Header.Add("User-Agent", c.userAgent)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Content-Type", "application/json")
+	if options.YoutubeDubbedAudio && options.YoutubeDubbedLanguage != "" {
+		//7.x takes the dub language from the browser language.
+		req.Header.Add("Accept-Language", options.YoutubeDubbedLanguage)
+	} else if c.language != "" {
+		req.Header.Add("Accept-Language", c.language)
+	}
+
+	res, err := c.withTimeout(c.jobTimeout).doInstance(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send your request, %w", err)
+	}
+	defer res.Body.Close()
+
+	jsonbody, err := c.readBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var legacy legacyResponse
+	err = c.decodeInstanceJSON(res, jsonbody, &legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := legacy.convert()
+	var cobaltErr *CobaltError
+	if errors.As(err, &cobaltErr) {
+		cobaltErr.Status, cobaltErr.translator = res.StatusCode, c.translator
+	}
+	return media, err
+}
+
+// convert translates a 7.x response to the current CobaltResponse. Errors have their text as code, 7.x has no error codes.
+func (legacy legacyResponse) convert() (*CobaltResponse, error) {
+	media := &CobaltResponse{URL: legacy.URL}
+	switch legacy.Status {
+	case "stream":
+		media.Status = "tunnel"
+	case "redirect", "success":
+		media.Status = "redirect"
+	case "picker":
+		if len(legacy.Picker) == 0 {
+			return nil, fmt.Errorf("%w: picker response without the picker items", ErrUnexpectedResponse)
+		}
+		media.Status = "picker"
+		media.Picker = &legacy.Picker
+		media.Audio = legacy.Audio
+	case "rate-limit":
+		return nil, fmt.Errorf("cobalt rejected our request: %w", &CobaltError{Code: "error.api.rate_exceeded"})
+	case "error":
+		if legacy.Text == "" {
+			return nil, errors.New("cobalt rejected our request without an error code")
+		}
+		media.Status, media.Error = "error", &Error{Code: legacy.Text}
+		return nil, fmt.Errorf("cobalt rejected our request: %w", &CobaltError{Code: legacy.Text, Response: media})
+	default:
+		return nil, fmt.Errorf("%w: unknown legacy status %q", ErrUnexpectedResponse, legacy.Status)
+	}
+	return media, nil
+}
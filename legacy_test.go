@@ -0,0 +1,122 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunLegacyInstance(t *testing.T) {
+	var request map[string]any
+	var acceptLanguage string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/serverInfo":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"version":"7.15","commit":"0a1b2c3","branch":"current","name":"legacy","url":"https://legacy.example/","cors":1,"startTime":"1700000000000"}`))
+		case "/api/json":
+			acceptLanguage = r.Header.Get("Accept-Language")
+			json.NewDecoder(r.Body).Decode(&request)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"stream","url":"https://legacy.example/api/stream?t=abc"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithAPI(server.URL))
+	info, err := client.ServerInfo()
+	if err != nil {
+		t.Fatalf("failed getting legacy server info: %v", err)
+	}
+	if info.Cobalt.Version != "7.15" || info.Git.Commit != "0a1b2c3" {
+		t.Errorf("unexpected server info %+v", info)
+	}
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	settings.Mode = Audio
+	settings.AudioFormat = MP3
+	settings.YoutubeDubbedAudio = true
+	settings.YoutubeDubbedLanguage = "pt"
+	media, err := client.Run(settings)
+	if err != nil {
+		t.Fatalf("failed running on a legacy instance: %v", err)
+	}
+	if media.Status != "tunnel" || media.URL != "https://legacy.example/api/stream?t=abc" {
+		t.Errorf("unexpected response %+v", media)
+	}
+
+	expected := map[string]any{"url": settings.Url, "isAudioOnly": true, "aFormat": "mp3", "vQuality": "1080", "vCodec": "h264", "filenamePattern": "basic", "dubLang": true}
+	for key, value := range expected {
+		if request[key] != value {
+			t.Errorf("expected %v to be %v, got %v", key, value, request[key])
+		}
+	}
+	if _, ok := request["downloadMode"]; ok {
+		t.Errorf("current field names should not be sent to legacy instances: %v", request)
+	}
+	if acceptLanguage != "pt" {
+		t.Errorf("expected the dub language in Accept-Language, got %q", acceptLanguage)
+	}
+}
+
+func TestLegacyResponseConvert(t *testing.T) {
+	var legacy legacyResponse
+	json.Unmarshal([]byte(`{"status":"picker","picker":[{"type":"photo","url":"https://example.com/1.jpg"}],"audio":"https://example.com/audio.mp3"}`), &legacy)
+	media, err := legacy.convert()
+	if err != nil || media.Status != "picker" || media.Picker == nil || (*media.Picker)[0].URL != "https://example.com/1.jpg" || media.Audio != "https://example.com/audio.mp3" {
+		t.Errorf("unexpected picker conversion %+v, %v", media, err)
+	}
+
+	for _, body := range []string{`{"status":"picker"}`, `{"status":"picker","picker":[]}`} {
+		legacy = legacyResponse{}
+		json.Unmarshal([]byte(body), &legacy)
+		if media, err := legacy.convert(); media != nil || !errors.Is(err, ErrUnexpectedResponse) {
+			t.Errorf("expected a picker without items to be ErrUnexpectedResponse, got %+v, %v", media, err)
+		}
+	}
+
+	for _, status := range []string{"error", "rate-limit"} {
+		legacy = legacyResponse{Status: status, Text: "something went wrong"}
+		if _, err := legacy.convert(); err == nil {
+			t.Errorf("expected an error for status %v", status)
+		}
+	}
+	if _, err := (legacyResponse{Status: "rate-limit"}).convert(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected a rate limit to be ErrRateLimited, got %v", err)
+	}
+}
+
+func TestRunLegacyContext(t *testing.T) {
+	requests := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/serverInfo":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"version":"7.15","commit":"0a1b2c3","branch":"current","name":"legacy","url":"https://legacy.example/","cors":1,"startTime":"1700000000000"}`))
+		case "/api/json":
+			var request map[string]any
+			json.NewDecoder(r.Body).Decode(&request)
+			requests <- request
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	settings := Settings{Url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := New(WithAPI(server.URL)).RunContext(ctx, settings); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context to cancel the legacy request, got %v", err)
+	}
+	//Without a quality the instance uses its default, "0" would be rejected.
+	if quality, ok := (<-requests)["vQuality"]; ok {
+		t.Errorf("expected no vQuality without a video quality, got %v", quality)
+	}
+}
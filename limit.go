@@ -0,0 +1,99 @@
+package gobalt
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Limits on how many downloads and cobalt jobs run at the same time, so batches don't open hundreds of connections.
+
+// concurrencyLimit is a semaphore. A nil limit is unlimited.
+type concurrencyLimit struct {
+	slots chan struct{}
+}
+
+func newConcurrencyLimit(limit int) *concurrencyLimit {
+	if limit <= 0 {
+		return nil
+	}
+	return &concurrencyLimit{slots: make(chan struct{}, limit)}
+}
+
+// acquire waits for a free slot, or until ctx is done.
+func (l *concurrencyLimit) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *concurrencyLimit) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// Limits shared by the package level functions, see SetMaxConcurrentDownloads and SetMaxConcurrentJobs.
+var (
+	defaultDownloads *concurrencyLimit
+	defaultJobs      *concurrencyLimit
+	defaultLimitsMu  sync.RWMutex
+)
+
+// SetMaxConcurrentDownloads limits how many downloads the package level functions run at the same time, 0 removes the limit.
+// Downloads past the limit wait for one to end. A download lasts until its Stream is closed. Default: unlimited.
+func SetMaxConcurrentDownloads(limit int) {
+	defaultLimitsMu.Lock()
+	defer defaultLimitsMu.Unlock()
+	defaultDownloads = newConcurrencyLimit(limit)
+}
+
+// SetMaxConcurrentJobs limits how many requests the package level functions send to cobalt at the same time, 0 removes the limit.
+// Cached responses don't count. Default: unlimited.
+func SetMaxConcurrentJobs(limit int) {
+	defaultLimitsMu.Lock()
+	defer defaultLimitsMu.Unlock()
+	defaultJobs = newConcurrencyLimit(limit)
+}
+
+// defaultLimits returns the limits set with SetMaxConcurrentDownloads and SetMaxConcurrentJobs.
+func defaultLimits() (downloads, jobs *concurrencyLimit) {
+	defaultLimitsMu.RLock()
+	defer defaultLimitsMu.RUnlock()
+	return defaultDownloads, defaultJobs
+}
+
+// WithMaxConcurrentDownloads limits how many downloads run at the same time, the rest wait for one to end.
+// A download lasts until its Stream is closed. Default: unlimited.
+func WithMaxConcurrentDownloads(limit int) Option {
+	return func(c *Cobalt) {
+		c.downloads = newConcurrencyLimit(limit)
+	}
+}
+
+// WithMaxConcurrentJobs limits how many requests are sent to cobalt at the same time, the rest wait for one to end.
+// Cached and coalesced responses don't count. Default: unlimited.
+func WithMaxConcurrentJobs(limit int) Option {
+	return func(c *Cobalt) {
+		c.jobs = newConcurrencyLimit(limit)
+	}
+}
+
+// releasingBody releases a download slot when closed.
+type releasingBody struct {
+	io.ReadCloser
+	once  sync.Once
+	limit *concurrencyLimit
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.limit.release)
+	return err
+}
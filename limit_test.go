@@ -0,0 +1,96 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentDownloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+	client := New(WithMaxConcurrentDownloads(2))
+
+	first, err := client.OpenStream(server.URL, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.OpenStream(server.URL, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.OpenStreamContext(ctx, server.URL, DownloadOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the third download to wait for a free slot, got %v", err)
+	}
+
+	first.Close()
+	first.Close()
+	third, err := client.OpenStream(server.URL, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("expected a slot after closing a stream, got %v", err)
+	}
+	third.Close()
+	second.Close()
+	if len(client.downloads.slots) != 0 {
+		t.Errorf("expected every slot to be released, %v are taken", len(client.downloads.slots))
+	}
+}
+
+func TestMaxConcurrentJobs(t *testing.T) {
+	var running, most atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		now := running.Add(1)
+		if now > most.Load() {
+			most.Store(now)
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+	}))
+	defer server.Close()
+	client := New(WithAPI(server.URL), WithMaxConcurrentJobs(2))
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			settings := CreateDefaultSettings()
+			settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+			if _, err := client.Run(settings); err != nil {
+				t.Errorf("run failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if most.Load() > 2 {
+		t.Errorf("expected at most 2 jobs at the same time, got %v", most.Load())
+	}
+}
+
+func TestSetMaxConcurrentDownloads(t *testing.T) {
+	defer SetMaxConcurrentDownloads(0)
+	SetMaxConcurrentDownloads(1)
+	if defaultCobalt().downloads == nil {
+		t.Fatal("expected the package level functions to be limited")
+	}
+	SetMaxConcurrentDownloads(0)
+	if defaultCobalt().downloads != nil {
+		t.Error("expected the limit to be removed")
+	}
+}
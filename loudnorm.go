@@ -0,0 +1,124 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EBU R128 loudness normalization with the ffmpeg loudnorm filter, so archives of podcasts or music play at the same volume.
+
+// Default targets of NormalizeLoudness, the usual ones for podcasts and streaming.
+const (
+	DefaultLoudness      = -16.0 //Integrated loudness, in LUFS.
+	DefaultTruePeak      = -1.5  //Maximum true peak, in dBTP.
+	DefaultLoudnessRange = 11.0  //Loudness range, in LU.
+)
+
+// LoudnessOptions configures NormalizeLoudness. Zero values use the defaults.
+type LoudnessOptions struct {
+	Integrated float64 //Target integrated loudness in LUFS, from -70 to -5. Default: DefaultLoudness.
+	TruePeak   float64 //Maximum true peak in dBTP, from -9 to 0. Default: DefaultTruePeak.
+	Range      float64 //Target loudness range in LU, from 1 to 50. Default: DefaultLoudnessRange.
+	SinglePass bool    //Normalizes in a single pass, dynamically. Faster, but the loudness isn't as exact and the dynamics change.
+	SampleRate int     //Sample rate of the output, loudnorm works at 192 kHz. Default: 48000.
+}
+
+func (o LoudnessOptions) withDefaults() LoudnessOptions {
+	if o.Integrated == 0 {
+		o.Integrated = DefaultLoudness
+	}
+	if o.TruePeak == 0 {
+		o.TruePeak = DefaultTruePeak
+	}
+	if o.Range == 0 {
+		o.Range = DefaultLoudnessRange
+	}
+	if o.SampleRate <= 0 {
+		o.SampleRate = 48000
+	}
+	return o
+}
+
+// loudnessMeasurement is what the first loudnorm pass prints, as json.
+type loudnessMeasurement struct {
+	InputI      string `json:"input_i"`
+	InputTP     string `json:"input_tp"`
+	InputLRA    string `json:"input_lra"`
+	InputThresh string `json:"input_thresh"`
+	Offset      string `json:"target_offset"`
+}
+
+// NormalizeLoudness normalizes the audio of the file to the EBU R128 targets of options with ffmpeg. By default the loudness
+// is measured first and then corrected linearly, in two passes. The audio is re-encoded with the default codec of the
+// extension, video is copied as is.
+func NormalizeLoudness(options LoudnessOptions) PostProcessor {
+	options = options.withDefaults()
+	return PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		var measured *loudnessMeasurement
+		if !options.SinglePass {
+			output, err := ffmpegOutput(ctx, "info", []string{"-i", input, "-map", "0:a:0", "-af", loudnormFilter(options, nil) + ":print_format=json", "-f", "null", "-"})
+			if err != nil {
+				return "", err
+			}
+			if measured, err = parseLoudnorm(output); err != nil {
+				return "", err
+			}
+		}
+		ext := strings.TrimPrefix(filepath.Ext(input), ".")
+		return ffmpeg(ctx, input, ext, func(output string) []string {
+			return []string{"-i", input, "-map", "0", "-c", "copy", "-c:a", defaultAudioCodec(ext), "-af", loudnormFilter(options, measured), "-ar", strconv.Itoa(options.SampleRate), output}
+		})
+	})
+}
+
+// loudnormFilter returns the loudnorm filter for the targets, with the values of the first pass if measured isn't nil.
+func loudnormFilter(options LoudnessOptions, measured *loudnessMeasurement) string {
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	filter := fmt.Sprintf("loudnorm=I=%v:TP=%v:LRA=%v", format(options.Integrated), format(options.TruePeak), format(options.Range))
+	if measured != nil {
+		filter += fmt.Sprintf(":measured_I=%v:measured_TP=%v:measured_LRA=%v:measured_thresh=%v:offset=%v:linear=true",
+			measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.Offset)
+	}
+	return filter
+}
+
+// parseLoudnorm reads the measurement printed by loudnorm at the end of the ffmpeg output.
+func parseLoudnorm(output string) (*loudnessMeasurement, error) {
+	start, end := strings.LastIndex(output, "{"), strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("ffmpeg didn't print the loudness measurement")
+	}
+	var measured loudnessMeasurement
+	if err := json.Unmarshal([]byte(output[start:end+1]), &measured); err != nil {
+		return nil, fmt.Errorf("invalid loudness measurement: %w", err)
+	}
+	for _, value := range []string{measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.Offset} {
+		//Silence measures as -inf, which the second pass doesn't take.
+		if _, err := strconv.ParseFloat(value, 64); err != nil || strings.Contains(value, "inf") {
+			return nil, fmt.Errorf("the loudness couldn't be measured, the audio may be silent: %q", value)
+		}
+	}
+	return &measured, nil
+}
+
+// defaultAudioCodec returns the ffmpeg audio encoder for files with the extension.
+func defaultAudioCodec(ext string) string {
+	switch strings.ToLower(ext) {
+	case "mp3":
+		return "libmp3lame"
+	case "opus", "ogg", "webm", "mkv":
+		return "libopus"
+	case "flac":
+		return "flac"
+	case "wav":
+		return "pcm_s16le"
+	}
+	return "aac"
+}
@@ -0,0 +1,88 @@
+package gobalt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const loudnormOutput = `[Parsed_loudnorm_0 @ 0x55d0c8a0b2c0] 
+{
+	"input_i" : "-27.61",
+	"input_tp" : "-4.47",
+	"input_lra" : "18.06",
+	"input_thresh" : "-39.20",
+	"output_i" : "-16.58",
+	"output_tp" : "-1.50",
+	"output_lra" : "14.78",
+	"output_thresh" : "-27.71",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.58"
+}
+`
+
+func TestLoudnormFilter(t *testing.T) {
+	options := LoudnessOptions{Integrated: -14}.withDefaults()
+	if filter := loudnormFilter(options, nil); filter != "loudnorm=I=-14:TP=-1.5:LRA=11" {
+		t.Errorf("unexpected single pass filter %v", filter)
+	}
+
+	measured, err := parseLoudnorm(loudnormOutput)
+	if err != nil {
+		t.Fatalf("failed parsing the measurement: %v", err)
+	}
+	expected := "loudnorm=I=-14:TP=-1.5:LRA=11:measured_I=-27.61:measured_TP=-4.47:measured_LRA=18.06:measured_thresh=-39.20:offset=0.58:linear=true"
+	if filter := loudnormFilter(options, measured); filter != expected {
+		t.Errorf("unexpected second pass filter %v", filter)
+	}
+
+	if _, err := parseLoudnorm(`{"input_i" : "-inf", "input_tp" : "-inf", "input_lra" : "0.00", "input_thresh" : "-70.00", "target_offset" : "0.00"}`); err == nil {
+		t.Errorf("expected silent audio to fail")
+	}
+	if _, err := parseLoudnorm("no json"); err == nil {
+		t.Errorf("expected an error without measurement")
+	}
+}
+
+func TestNormalizeLoudness(t *testing.T) {
+	if !FFmpegAvailable() {
+		t.Skip("ffmpeg isn't installed")
+	}
+	input := filepath.Join(t.TempDir(), "tone.m4a")
+	generate := PostProcessorFunc(func(ctx context.Context, _ string) (string, error) {
+		return ffmpeg(ctx, input, "m4a", func(output string) []string {
+			return []string{"-f", "lavfi", "-i", "sine=duration=3", output}
+		})
+	})
+	if _, err := generate.Process(context.Background(), input); err != nil {
+		t.Fatalf("failed generating a test file: %v", err)
+	}
+
+	output, err := NormalizeLoudness(LoudnessOptions{}).Process(context.Background(), input)
+	if err != nil || output != input {
+		t.Fatalf("normalization failed: %v, %v", output, err)
+	}
+}
+
+func TestNormalizeLoudnessArgs(t *testing.T) {
+	runs := fakeFFmpeg(t, loudnormOutput, 0)
+	input := filepath.Join(t.TempDir(), "episode.mp3")
+	os.WriteFile(input, []byte("media"), 0o644)
+
+	options := LoudnessOptions{Integrated: -14}
+	if _, err := NormalizeLoudness(options).Process(context.Background(), input); err != nil {
+		t.Fatalf("normalization failed: %v", err)
+	}
+	measured, _ := parseLoudnorm(loudnormOutput)
+	options = options.withDefaults()
+	expected := [][]string{
+		{"-y", "-hide_banner", "-loglevel", "info", "-i", input, "-map", "0:a:0", "-af", loudnormFilter(options, nil) + ":print_format=json", "-f", "null", "-"},
+		{"-y", "-hide_banner", "-loglevel", "error", "-i", input, "-map", "0", "-c", "copy", "-c:a", "libmp3lame", "-af", loudnormFilter(options, measured), "-ar", "48000", strings.TrimSuffix(input, ".mp3") + ".ffmpeg.mp3"},
+	}
+	if got := runs(); !slices.EqualFunc(got, expected, slices.Equal) {
+		t.Errorf("unexpected ffmpeg runs\n%q\nexpected\n%q", got, expected)
+	}
+}
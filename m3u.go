@@ -0,0 +1,70 @@
+package gobalt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Writing playlists, search results and feeds as M3U playlists, for media players like VLC and mpv.
+
+// M3UEntry is an entry of an M3U playlist, use it to export urls that aren't a PlaylistEntry, SearchResult, MusicTrack or FeedEntry.
+type M3UEntry struct {
+	URL      string
+	Title    string
+	Author   string        //Shown before the title, as "Author - Title".
+	Duration time.Duration //0 if unknown.
+}
+
+// M3UItem is what ExportM3U writes: PlaylistEntry, SearchResult, MusicTrack, FeedEntry or M3UEntry.
+type M3UItem interface {
+	m3uEntry() M3UEntry
+}
+
+func (e M3UEntry) m3uEntry() M3UEntry { return e }
+
+func (e PlaylistEntry) m3uEntry() M3UEntry {
+	return M3UEntry{URL: e.URL, Title: e.Title, Author: e.Author, Duration: e.Duration}
+}
+
+func (r SearchResult) m3uEntry() M3UEntry {
+	return M3UEntry{URL: r.URL, Title: r.Title, Author: r.Author, Duration: r.Duration}
+}
+
+func (t MusicTrack) m3uEntry() M3UEntry {
+	return M3UEntry{URL: t.URL, Title: t.Title, Author: t.Artist}
+}
+
+func (e FeedEntry) m3uEntry() M3UEntry {
+	return M3UEntry{URL: e.URL, Title: e.Title, Author: e.Author}
+}
+
+// ExportM3U writes entries to w as an extended M3U playlist, in UTF-8 so it can be saved as .m3u8. Every entry has an
+// #EXTINF line with its duration in seconds (-1 if unknown) and its title. Entries without url are skipped.
+func ExportM3U[T M3UItem](entries []T, w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString("#EXTM3U\n")
+	for _, item := range entries {
+		entry := item.m3uEntry()
+		if entry.URL == "" {
+			continue
+		}
+		seconds := -1
+		if entry.Duration > 0 {
+			seconds = int(entry.Duration.Round(time.Second) / time.Second)
+		}
+		title := entry.Title
+		if entry.Author != "" && title != "" {
+			title = entry.Author + " - " + title
+		}
+		fmt.Fprintf(buffered, "#EXTINF:%d,%v\n%v\n", seconds, m3uLine(title), m3uLine(entry.URL))
+	}
+	return buffered.Flush()
+}
+
+// m3uLine keeps s in a single line, as M3U is line based.
+func m3uLine(s string) string {
+	return strings.Join(strings.Fields(strings.NewReplacer("\r", " ", "\n", " ").Replace(s)), " ")
+}
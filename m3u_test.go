@@ -0,0 +1,31 @@
+package gobalt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportM3U(t *testing.T) {
+	var playlist strings.Builder
+	err := ExportM3U([]PlaylistEntry{
+		{URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Title: "Never Gonna\nGive You Up", Author: "Rick Astley", Duration: 212*time.Second + 600*time.Millisecond},
+		{URL: "https://www.youtube.com/watch?v=jNQXAC9IVRw", Title: "Me at the zoo"},
+		{Title: "No url"},
+	}, &playlist)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "#EXTM3U\n" +
+		"#EXTINF:213,Rick Astley - Never Gonna Give You Up\nhttps://www.youtube.com/watch?v=dQw4w9WgXcQ\n" +
+		"#EXTINF:-1,Me at the zoo\nhttps://www.youtube.com/watch?v=jNQXAC9IVRw\n"
+	if playlist.String() != expected {
+		t.Errorf("unexpected playlist:\n%v", playlist.String())
+	}
+
+	var search strings.Builder
+	ExportM3U([]SearchResult{{URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Title: "Rickroll", Duration: time.Minute}}, &search)
+	if !strings.HasSuffix(search.String(), "#EXTINF:60,Rickroll\nhttps://www.youtube.com/watch?v=dQw4w9WgXcQ\n") {
+		t.Errorf("unexpected search playlist:\n%v", search.String())
+	}
+}
@@ -0,0 +1,105 @@
+package gobalt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Readable messages for the error codes returned by cobalt, like error.api.content.too_long.
+
+// ErrorTranslator returns the message for an error code in the language of your users, or an empty string to use the English message.
+type ErrorTranslator func(code string, context Context) string
+
+// Translator is used by Error.Message before the English messages, set it to show errors in another language.
+// It's read without synchronization every time an error is formatted, changing it while jobs run is a data race.
+//
+// Deprecated: Use New with WithErrorTranslator.
+var Translator ErrorTranslator
+
+// WithErrorTranslator translates the messages of the errors returned by this Cobalt, instead of Translator.
+func WithErrorTranslator(translator ErrorTranslator) Option {
+	return func(c *Cobalt) {
+		c.translator = translator
+	}
+}
+
+// ErrorMessages has the English message of every known cobalt error code. {service} and {limit} are replaced with the error context.
+// It's read without synchronization every time an error is formatted, only change it during init, before any job runs.
+var ErrorMessages = map[string]string{
+	"error.api.auth.jwt.missing":        "The instance requires a session token, but none was sent.",
+	"error.api.auth.jwt.invalid":        "The session token is invalid or expired, get a new one.",
+	"error.api.auth.jwt.expired":        "The session token expired, get a new one.",
+	"error.api.auth.turnstile.missing":  "The instance requires a Turnstile challenge to be solved.",
+	"error.api.auth.turnstile.invalid":  "The Turnstile challenge response was rejected.",
+	"error.api.auth.key.missing":        "The instance requires an API key, set ApiKey or use WithAPIKey.",
+	"error.api.auth.key.invalid":        "The API key is invalid.",
+	"error.api.auth.key.not_found":      "The API key doesn't exist on this instance.",
+	"error.api.auth.key.invalid_ip":     "The API key can't be used from your IP address.",
+	"error.api.auth.key.ip_not_allowed": "The API key can't be used from your IP address.",
+	"error.api.auth.key.ua_not_allowed": "The API key can't be used with this User-Agent.",
+
+	"error.api.unreachable":      "The instance couldn't reach {service}, try again in a few seconds.",
+	"error.api.timed_out":        "{service} took too long to respond, try again in a few seconds.",
+	"error.api.rate_exceeded":    "Too many requests, try again in {limit} seconds.",
+	"error.api.capacity":         "The instance is at capacity, try again later or use another instance.",
+	"error.api.generic":          "Something went wrong on the instance and it couldn't get anything.",
+	"error.api.unknown_response": "The instance couldn't read the response from {service}.",
+	"error.api.invalid_body":     "The instance couldn't understand the request, it may run a different cobalt version.",
+
+	"error.api.service.unsupported": "This service isn't supported by cobalt, check that the link is right.",
+	"error.api.service.disabled":    "{service} is disabled on this instance, try another instance.",
+	"error.api.link.invalid":        "The link is invalid or isn't supported by cobalt.",
+	"error.api.link.unsupported":    "{service} is supported, but this kind of link isn't.",
+
+	"error.api.fetch.fail":       "Something went wrong when fetching the media from {service}.",
+	"error.api.fetch.critical":   "{service} returned an error the instance can't recover from.",
+	"error.api.fetch.empty":      "{service} returned nothing, the media may not exist.",
+	"error.api.fetch.rate":       "The instance got rate limited by {service}, try again later or use another instance.",
+	"error.api.fetch.short_link": "The short link couldn't be resolved, try the full link.",
+
+	"error.api.content.too_long":          "The media is too long, the instance allows up to {limit} minutes.",
+	"error.api.content.video.unavailable": "The video isn't available, it may have been removed.",
+	"error.api.content.video.live":        "The video is a live stream, it can't be downloaded until it ends.",
+	"error.api.content.video.private":     "The video is private.",
+	"error.api.content.video.age":         "The video is age restricted.",
+	"error.api.content.video.region":      "The video isn't available in the region of the instance.",
+	"error.api.content.post.unavailable":  "The post isn't available, it may have been removed.",
+	"error.api.content.post.private":      "The post is private.",
+	"error.api.content.post.age":          "The post is age restricted.",
+
+	"error.api.youtube.codec":              "YouTube doesn't have the video in the chosen codec and quality, try another codec.",
+	"error.api.youtube.decipher":           "The instance couldn't decipher the YouTube stream, try again later.",
+	"error.api.youtube.login":              "YouTube requires a login for this video and the instance has no account set up.",
+	"error.api.youtube.token_expired":      "The YouTube token of the instance expired, try again in a few seconds.",
+	"error.api.youtube.no_matching_format": "YouTube has no format matching the chosen settings.",
+}
+
+// Message returns a readable message for the error, from Translator, ErrorMessages or, for unknown codes, the code itself.
+func (e *Error) Message() string {
+	return e.message(Translator)
+}
+
+// message returns the message from translator if it has one, see Message.
+func (e *Error) message(translator ErrorTranslator) string {
+	if translator != nil {
+		if message := translator(e.Code, e.Context); message != "" {
+			return message
+		}
+	}
+	message, ok := ErrorMessages[e.Code]
+	if !ok {
+		return e.Code
+	}
+
+	service := e.Context.Service
+	if service == "" {
+		service = "the service"
+	}
+	limit := "a few"
+	if e.Context.Limit > 0 {
+		limit = strconv.Itoa(e.Context.Limit)
+	}
+	message = strings.NewReplacer("{service}", service, "{limit}", limit).Replace(message)
+	//Messages starting with the service name, like "{service} is disabled".
+	return strings.ToUpper(message[:1]) + message[1:]
+}
@@ -0,0 +1,67 @@
+package gobalt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		err      Error
+		expected string
+	}{
+		{Error{Code: "error.api.content.too_long", Context: Context{Limit: 180}}, "The media is too long, the instance allows up to 180 minutes."},
+		{Error{Code: "error.api.service.disabled", Context: Context{Service: "youtube"}}, "Youtube is disabled on this instance, try another instance."},
+		{Error{Code: "error.api.fetch.empty"}, "The service returned nothing, the media may not exist."},
+		{Error{Code: "error.api.something.new"}, "error.api.something.new"},
+	}
+	for _, test := range tests {
+		if message := test.err.Message(); message != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.err.Code, test.expected, message)
+		}
+	}
+}
+
+func TestErrorTranslator(t *testing.T) {
+	defer func() { Translator = nil }()
+	Translator = func(code string, context Context) string {
+		if code == "error.api.content.video.private" {
+			return "O vídeo é privado."
+		}
+		return ""
+	}
+
+	if message := (&Error{Code: "error.api.content.video.private"}).Message(); message != "O vídeo é privado." {
+		t.Errorf("expected the translated message, got %q", message)
+	}
+	if message := (&Error{Code: "error.api.content.video.age"}).Message(); message != ErrorMessages["error.api.content.video.age"] {
+		t.Errorf("expected the english message for untranslated codes, got %q", message)
+	}
+}
+
+func TestWithErrorTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","error":{"code":"error.api.content.video.private"}}`))
+	}))
+	defer server.Close()
+	settings := Settings{Url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"}
+
+	translated := New(WithAPI(server.URL), WithErrorTranslator(func(code string, context Context) string {
+		return "O vídeo é privado."
+	}))
+	var cobaltErr *CobaltError
+	if _, err := translated.Run(settings); !errors.As(err, &cobaltErr) || cobaltErr.Message() != "O vídeo é privado." {
+		t.Errorf("expected the message of the translator of the client, got %v", err)
+	}
+	if _, err := New(WithAPI(server.URL)).Run(settings); !errors.As(err, &cobaltErr) || cobaltErr.Message() != ErrorMessages["error.api.content.video.private"] {
+		t.Errorf("expected other clients to keep the english message, got %v", err)
+	}
+}
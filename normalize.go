@@ -0,0 +1,117 @@
+package gobalt
+
+import (
+	"strings"
+)
+
+// Url normalization, so the same media shared from different apps gives the same url, for caching and deduplication.
+
+// Query parameters added by share buttons and ad campaigns, removed from every url. Keys ending in * are prefixes.
+var trackingParams = []string{"utm_*", "si", "igshid", "igsh", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "ref_src", "ref_url", "share_*"}
+
+// Tracking parameters of a single service, which may mean something else on other services (like t, a timestamp on YouTube).
+var serviceTrackingParams = map[ServiceName][]string{
+	YouTube: {"feature", "pp", "ab_channel"},
+	Twitter: {"s", "t", "ref"},
+	TikTok:  {"is_from_webapp", "sender_device", "is_copy_url", "web_id", "_r", "_t", "u_code", "preview_pb", "language", "timestamp", "user_id", "sec_user_id", "utm_*"},
+	Reddit:  {"context", "rdt", "share_id"},
+	Vimeo:   {"share"},
+}
+
+// Mobile and alternative hosts, replaced by the canonical host of the service.
+var canonicalHosts = map[string]string{
+	"m.youtube.com":            "www.youtube.com",
+	"youtube.com":              "www.youtube.com",
+	"mobile.twitter.com":       "twitter.com",
+	"mobile.x.com":             "x.com",
+	"m.facebook.com":           "www.facebook.com",
+	"facebook.com":             "www.facebook.com",
+	"m.tiktok.com":             "www.tiktok.com",
+	"tiktok.com":               "www.tiktok.com",
+	"m.reddit.com":             "www.reddit.com",
+	"old.reddit.com":           "www.reddit.com",
+	"new.reddit.com":           "www.reddit.com",
+	"reddit.com":               "www.reddit.com",
+	"m.soundcloud.com":         "soundcloud.com",
+	"www.soundcloud.com":       "soundcloud.com",
+	"m.vk.com":                 "vk.com",
+	"m.twitch.tv":              "www.twitch.tv",
+	"twitch.tv":                "www.twitch.tv",
+	"m.bilibili.com":           "www.bilibili.com",
+	"m.dailymotion.com":        "www.dailymotion.com",
+	"instagram.com":            "www.instagram.com",
+	"m.ok.ru":                  "ok.ru",
+	"www.streamable.com":       "streamable.com",
+	"www.rutube.ru":            "rutube.ru",
+	"www.vimeo.com":            "vimeo.com",
+	"player.vimeo.com":         "vimeo.com",
+	"youtube-nocookie.com":     "www.youtube.com",
+	"www.youtube-nocookie.com": "www.youtube.com",
+}
+
+// NormalizeURL returns a canonical form of a media url: lowercase scheme and host, https, mobile and alternative hosts replaced
+// by the canonical one, youtu.be and embed urls turned into watch urls, tracking parameters (si, utm_*, igshid...) and the
+// fragment removed, and the remaining parameters sorted. It doesn't make requests, see ExpandURL for short links.
+func NormalizeURL(rawURL string) (string, error) {
+	u, err := parseMediaURL(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = "https"
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if canonical, ok := canonicalHosts[host]; ok {
+		host = canonical
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	query := u.Query()
+	switch {
+	case host == "youtu.be":
+		//youtu.be/ID?t=42 is www.youtube.com/watch?v=ID&t=42.
+		query.Set("v", strings.Trim(u.Path, "/"))
+		u.Host, u.Path = "www.youtube.com", "/watch"
+	case host == "www.youtube.com" && strings.HasPrefix(u.Path, "/embed/"):
+		query.Set("v", strings.Trim(strings.TrimPrefix(u.Path, "/embed/"), "/"))
+		u.Path = "/watch"
+	case host == "vimeo.com" && strings.HasPrefix(u.Path, "/video/"):
+		//player.vimeo.com/video/ID.
+		u.Path = strings.TrimPrefix(u.Path, "/video")
+	}
+
+	service := serviceFromHost(u.Hostname())
+	for key := range query {
+		if matchesParam(key, trackingParams) || matchesParam(key, serviceTrackingParams[service]) {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	if u.Path != "/" {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+	}
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+func matchesParam(key string, params []string) bool {
+	key = strings.ToLower(key)
+	for _, param := range params {
+		if prefix, ok := strings.CutSuffix(param, "*"); ok && strings.HasPrefix(key, prefix) || key == param {
+			return true
+		}
+	}
+	return false
+}
+
+// WithURLNormalization makes Run send the urls normalized with NormalizeURL.
+func WithURLNormalization() Option {
+	return func(c *Cobalt) {
+		c.normalizeURLs = true
+	}
+}
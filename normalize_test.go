@@ -0,0 +1,30 @@
+package gobalt
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"https://youtu.be/dQw4w9WgXcQ?si=abc123&t=42":                                                     "https://www.youtube.com/watch?t=42&v=dQw4w9WgXcQ",
+		"HTTP://M.YouTube.com/watch?v=dQw4w9WgXcQ&feature=share&pp=ygU":                                   "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ":                                              "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		"https://mobile.twitter.com/user/status/1234567890?s=20&t=abcdef":                                 "https://twitter.com/user/status/1234567890",
+		"https://www.instagram.com/reel/C1a2b3c4d5e/?igshid=MzRlODBiNWFlZA==":                             "https://www.instagram.com/reel/C1a2b3c4d5e",
+		"https://old.reddit.com/r/videos/comments/abc123/title/?utm_source=share":                         "https://www.reddit.com/r/videos/comments/abc123/title",
+		"https://m.tiktok.com/@user/video/7123456789012345678?is_from_webapp=1&sender_device=pc#comments": "https://www.tiktok.com/@user/video/7123456789012345678",
+		"https://player.vimeo.com/video/123456789":                                                        "https://vimeo.com/123456789",
+		"soundcloud.com/artist/song?utm_medium=text&in=artist/sets/album":                                 "https://soundcloud.com/artist/song?in=artist%2Fsets%2Falbum",
+	}
+	for rawURL, expected := range tests {
+		normalized, err := NormalizeURL(rawURL)
+		if err != nil || normalized != expected {
+			t.Errorf("NormalizeURL(%v) = %v, %v, expected %v", rawURL, normalized, err, expected)
+		}
+		if again, _ := NormalizeURL(normalized); again != normalized {
+			t.Errorf("NormalizeURL isn't idempotent for %v: %v", normalized, again)
+		}
+	}
+
+	if _, err := NormalizeURL("https://"); err == nil {
+		t.Errorf("expected an error for an url without host")
+	}
+}
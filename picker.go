@@ -0,0 +1,139 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Downloading every item of a picker response, with numbered filenames.
+
+// DefaultPickerTemplate names the items of a picker, like "Title - 01 (photo).jpg", or "01 (photo).jpg" without a title field.
+const DefaultPickerTemplate = "{title} - {index} ({type}).{ext}"
+
+// DownloadPicker downloads every item of a picker response, see the method.
+func DownloadPicker(ctx context.Context, media *CobaltResponse, options DownloadOptions) ([]*DownloadResult, error) {
+	return defaultCobalt().DownloadPicker(ctx, media, options)
+}
+
+// DownloadPicker downloads every item of a picker response in order, and then its background audio, like DownloadContext.
+// Items are named with options.Template, or DefaultPickerTemplate, with these fields added:
+//
+//   - {index}: position of the item from 1, zero-padded to the digits of the item count so names sort in order.
+//   - {type}: photo, video, gif, or audio for the background audio.
+//   - {title}: from options.Fields, or the cobalt filename.
+//
+// The background audio is named after AudioFilename if cobalt sent one. options.Filename is ignored, unless options.Zip is set.
+// On error, the results of the items already downloaded are returned with it.
+func (c *Cobalt) DownloadPicker(ctx context.Context, media *CobaltResponse, options DownloadOptions) ([]*DownloadResult, error) {
+	if media == nil || !media.IsPicker() || media.Picker == nil {
+		return nil, errors.New("the response isn't a picker, use Download")
+	}
+	if options.Zip {
+		result, err := c.savePickerZip(ctx, media, options)
+		if err != nil {
+			return nil, err
+		}
+		return []*DownloadResult{result}, nil
+	}
+
+	var results []*DownloadResult
+	for _, job := range pickerJobs(media, options) {
+		result, err := c.DownloadContext(ctx, job.media, job.options)
+		if err != nil {
+			return results, fmt.Errorf("failed to download %v of the picker: %w", job.label, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// pickerJob is the download of an item of a picker, or of its background audio.
+type pickerJob struct {
+	label   string //Like "photo 01", for errors.
+	media   *CobaltResponse
+	options DownloadOptions
+}
+
+// pickerJobs returns the downloads of the items of a picker followed by its background audio, with their index and type fields.
+func pickerJobs(media *CobaltResponse, options DownloadOptions) []pickerJob {
+	template := options.Template
+	if template == "" {
+		template = DefaultPickerTemplate
+	}
+	job := func(item PickerItem, index string) pickerJob {
+		jobOptions := options
+		jobOptions.Filename = ""
+		jobOptions.Template = template
+		jobOptions.Fields = map[string]string{"index": index, "type": string(item.Type)}
+		maps.Copy(jobOptions.Fields, options.Fields)
+		return pickerJob{
+			label:   strings.TrimSpace(string(item.Type) + " " + index),
+			media:   &CobaltResponse{Status: "tunnel", URL: item.URL, Filename: media.Filename},
+			options: jobOptions,
+		}
+	}
+
+	picker := *media.Picker
+	width := len(strconv.Itoa(len(picker)))
+	jobs := make([]pickerJob, 0, len(picker)+1)
+	for i, item := range picker {
+		jobs = append(jobs, job(item, fmt.Sprintf("%0*d", width, i+1)))
+	}
+	if media.Audio != "" {
+		audio := job(PickerItem{Type: "audio", URL: media.Audio}, "")
+		audio.options.Filename = media.AudioFilename
+		jobs = append(jobs, audio)
+	}
+	return jobs
+}
+
+// savePickerZip downloads the items of a picker in a zip archive saved in options.Directory, named after options.Filename,
+// or the title field, or picker.zip.
+func (c *Cobalt) savePickerZip(ctx context.Context, media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	path, collision := collisionPath(pickerOutputPath(options, "picker", ".zip"), options.Collision)
+	if collision == CollisionSkip {
+		return skippedResult(path), nil
+	}
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return nil, err
+	}
+	options.Zip = false
+	result, err := c.DownloadZip(ctx, file, []*CobaltResponse{media}, options)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if !options.KeepPartial {
+			os.Remove(path + ".part")
+		}
+		return nil, err
+	}
+	if err := os.Rename(path+".part", path); err != nil {
+		return nil, err
+	}
+	result.Path, result.Collision = path, collision
+	return result, nil
+}
+
+// pickerOutputPath returns the path of a single file made from a picker, named after options.Filename,
+// or the title field, or fallback, with the ext extension.
+func pickerOutputPath(options DownloadOptions, fallback, ext string) string {
+	name := options.Filename
+	if name == "" {
+		name = RenderFilenameTemplate("{title}", options.Fields)
+	}
+	if name == "" {
+		name = fallback
+	}
+	if !strings.EqualFold(filepath.Ext(name), ext) {
+		name += ext
+	}
+	return filepath.Join(options.Directory, SanitizeFilename(name, options.Sanitize))
+}
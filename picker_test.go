@@ -0,0 +1,61 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadPicker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio":
+			w.Header().Set("Content-Type", "audio/mpeg")
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		default:
+			w.Header().Set("Content-Type", "image/jpeg")
+		}
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	var picker []PickerItem
+	for i := range 10 {
+		picker = append(picker, PickerItem{Type: Photo, URL: fmt.Sprintf("%v/%v", server.URL, i)})
+	}
+	media := &CobaltResponse{Status: "picker", Picker: &picker, Audio: server.URL + "/audio"}
+	dir := t.TempDir()
+	results, err := DownloadPicker(context.Background(), media, DownloadOptions{Directory: dir, Fields: map[string]string{"title": "Slideshow"}})
+	if err != nil {
+		t.Fatalf("failed to download the picker: %v", err)
+	}
+	if len(results) != 11 {
+		t.Fatalf("expected 11 files, got %v", len(results))
+	}
+	expected := map[int]string{0: "Slideshow - 01 (photo).jpg", 9: "Slideshow - 10 (photo).jpg", 10: "Slideshow (audio).mp3"}
+	for i, name := range expected {
+		if results[i].Path != filepath.Join(dir, name) {
+			t.Errorf("expected file %v to be %v, got %v", i, name, results[i].Path)
+		}
+	}
+
+	media.AudioFilename = "sound.mp3"
+	results, err = DownloadPicker(context.Background(), media, DownloadOptions{Directory: dir, Template: "{index}.{ext}"})
+	if err != nil || results[0].Path != filepath.Join(dir, "01.jpg") || results[10].Path != filepath.Join(dir, "sound.mp3") {
+		t.Errorf("unexpected results %v, %v", results, err)
+	}
+
+	picker[3].URL = server.URL + "/missing"
+	results, err = DownloadPicker(context.Background(), media, DownloadOptions{Directory: dir})
+	if err == nil || len(results) != 3 {
+		t.Errorf("expected the downloaded items with the error, got %v results and %v", len(results), err)
+	}
+	if _, err := DownloadPicker(context.Background(), &CobaltResponse{Status: "tunnel", URL: server.URL}, DownloadOptions{}); err == nil {
+		t.Error("expected an error for a response that isn't a picker")
+	}
+}
@@ -0,0 +1,162 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// Downloading every item of a playlist concurrently, with the progress of the whole playlist.
+
+type itemState string
+
+const (
+	ItemPending  itemState = "pending"  //Not started yet.
+	ItemRunning  itemState = "running"  //Being processed by cobalt or downloaded.
+	ItemDone     itemState = "done"     //Downloaded.
+	ItemFailed   itemState = "failed"   //Failed, see PlaylistItem.Err.
+	ItemCanceled itemState = "canceled" //Not done because the context was canceled.
+)
+
+// PlaylistOptions changes how DownloadPlaylist processes the items.
+type PlaylistOptions struct {
+	Settings    Settings               //Settings of the cobalt job of every item, the url is replaced by the item url. Default: CreateDefaultSettings().
+	Download    DownloadOptions        //How every item is downloaded. Filename and Progress are ignored.
+	Concurrency int                    //Items processed at the same time. Default: 4.
+	Progress    func(PlaylistProgress) //Called every time an item changes state or gets more bytes, never concurrently.
+
+	Instances []Candidate       //Instances the jobs are spread across, with failover, see RunFailover. Empty to use the instance of the Cobalt.
+	Strategy  SelectionStrategy //How the instance of each job is picked among Instances. Default: RoundRobin().
+}
+
+// PlaylistItem is the status of an item of the playlist.
+type PlaylistItem struct {
+	URL     string    //Url of the item.
+	State   itemState //ItemPending, ItemRunning, ItemDone, ItemFailed or ItemCanceled.
+	Written int64     //Bytes written so far.
+	Size    int64     //Size reported by the server, -1 if unknown or not started.
+	Path    string    //Where the item was saved, when done.
+	Err     error     //Why the item failed.
+}
+
+// PlaylistProgress is the progress of the whole playlist, passed to PlaylistOptions.Progress.
+type PlaylistProgress struct {
+	Total   int            //Number of items.
+	Done    int            //Items downloaded.
+	Failed  int            //Items failed or canceled.
+	Written int64          //Bytes written for every item.
+	Current int            //Index of the item that changed.
+	Items   []PlaylistItem //Status of every item, a copy.
+}
+
+// DownloadPlaylist submits every url to cobalt and downloads it, see Cobalt.DownloadPlaylist.
+func DownloadPlaylist(ctx context.Context, urls Playlist, options PlaylistOptions) ([]PlaylistItem, error) {
+	return defaultCobalt().DownloadPlaylist(ctx, urls, options)
+}
+
+// DownloadPlaylist submits every url to cobalt and downloads it, options.Concurrency items at a time. Picker responses
+// fail, download them with Download item by item. Returns the status of every item, in the order of urls, and the context
+// error if it was canceled. Failed items don't stop the others.
+func (c *Cobalt) DownloadPlaylist(ctx context.Context, urls Playlist, options PlaylistOptions) ([]PlaylistItem, error) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	if len(options.Instances) > 0 && options.Strategy == nil {
+		options.Strategy = RoundRobin()
+	}
+
+	var mu sync.Mutex
+	items := make([]PlaylistItem, len(urls))
+	for i, url := range urls {
+		items[i] = PlaylistItem{URL: url, State: ItemPending, Size: -1}
+	}
+	//update changes an item and reports the progress, serialized by mu.
+	update := func(index int, change func(item *PlaylistItem)) {
+		mu.Lock()
+		defer mu.Unlock()
+		change(&items[index])
+		if options.Progress == nil {
+			return
+		}
+		progress := PlaylistProgress{Total: len(items), Current: index, Items: make([]PlaylistItem, len(items))}
+		copy(progress.Items, items)
+		for _, item := range items {
+			progress.Written += item.Written
+			switch item.State {
+			case ItemDone:
+				progress.Done++
+			case ItemFailed, ItemCanceled:
+				progress.Failed++
+			}
+		}
+		options.Progress(progress)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i := range urls {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			update(i, func(item *PlaylistItem) { item.State, item.Err = ItemCanceled, ctx.Err() })
+			continue
+		}
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			update(index, func(item *PlaylistItem) { item.State = ItemRunning })
+
+			result, err := c.downloadPlaylistItem(ctx, urls[index], options, func(progress Progress) {
+				update(index, func(item *PlaylistItem) { item.Written, item.Size = progress.Written, progress.Size })
+			})
+			update(index, func(item *PlaylistItem) {
+				switch {
+				case err == nil:
+					item.State, item.Path, item.Written = ItemDone, result.Path, result.Size
+				case errors.Is(err, ctx.Err()):
+					item.State, item.Err = ItemCanceled, err
+				default:
+					item.State, item.Err = ItemFailed, err
+				}
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	return items, ctx.Err()
+}
+
+func (c *Cobalt) downloadPlaylistItem(ctx context.Context, url string, options PlaylistOptions, progress func(Progress)) (*DownloadResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	settings := options.Settings
+	if reflect.DeepEqual(settings, Settings{}) {
+		settings = CreateDefaultSettings()
+	}
+	settings.Url = url
+	var media *CobaltResponse
+	var err error
+	if len(options.Instances) > 0 {
+		media, err = c.RunFailover(ctx, settings, options.Instances, options.Strategy)
+	} else {
+		media, err = c.RunContext(ctx, settings)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if media.IsPicker() {
+		return nil, errors.New("picker responses can't be downloaded as a playlist item")
+	}
+
+	download := options.Download
+	download.Filename = ""
+	download.Progress = progress
+	return c.DownloadContext(ctx, media, download)
+}
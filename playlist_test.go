@@ -0,0 +1,83 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadPlaylist(t *testing.T) {
+	var server *httptest.Server
+	var running, maxRunning atomic.Int32
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+		case r.Method == http.MethodPost:
+			var settings Settings
+			json.NewDecoder(r.Body).Decode(&settings)
+			id := settings.Url[strings.LastIndex(settings.Url, "=")+1:]
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(CobaltResponse{Status: "tunnel", URL: server.URL + "/file/" + id, Filename: id + ".mp4"})
+		case r.URL.Path == "/file/missing0000":
+			http.NotFound(w, r)
+		default:
+			if n := running.Add(1); n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			defer running.Add(-1)
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("media of " + r.URL.Path))
+		}
+	}))
+	defer server.Close()
+
+	urls := Playlist{
+		"https://www.youtube.com/watch?v=aaaaaaaaaaa",
+		"https://www.youtube.com/watch?v=missing0000",
+		"https://www.youtube.com/watch?v=bbbbbbbbbbb",
+	}
+	var last PlaylistProgress
+	var calls int
+	items, err := New(WithAPI(server.URL)).DownloadPlaylist(context.Background(), urls, PlaylistOptions{
+		Download:    DownloadOptions{Directory: t.TempDir()},
+		Concurrency: 2,
+		Progress: func(progress PlaylistProgress) {
+			calls++
+			last = progress
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []itemState{ItemDone, ItemFailed, ItemDone}
+	for i, item := range items {
+		if item.State != expected[i] || item.URL != urls[i] {
+			t.Errorf("item %v: expected %v, got %+v", i, expected[i], item)
+		}
+	}
+	if items[0].Written != int64(len("media of /file/aaaaaaaaaaa")) || !strings.HasSuffix(items[0].Path, "aaaaaaaaaaa.mp4") {
+		t.Errorf("unexpected first item %+v", items[0])
+	}
+	if last.Total != 3 || last.Done != 2 || last.Failed != 1 || last.Written != items[0].Written+items[2].Written || calls < 6 {
+		t.Errorf("unexpected final progress %+v after %v calls", last, calls)
+	}
+	if maxRunning.Load() > 2 {
+		t.Errorf("expected at most 2 concurrent downloads, got %v", maxRunning.Load())
+	}
+}
+
+func TestDownloadPlaylistCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	items, err := DownloadPlaylist(ctx, Playlist{"https://www.youtube.com/watch?v=aaaaaaaaaaa"}, PlaylistOptions{})
+	if err != context.Canceled || items[0].State != ItemCanceled {
+		t.Errorf("expected the item to be canceled, got %+v, %v", items, err)
+	}
+}
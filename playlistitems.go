@@ -0,0 +1,197 @@
+package gobalt
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reading playlists page by page from the YouTube web clients, so huge playlists don't have to be read whole before
+// the first item is used.
+
+// youtubeAPI is the browse endpoint of the YouTube web client.
+var youtubeAPI = "https://www.youtube.com/youtubei/v1/browse?prettyPrint=false"
+
+var youtubeClient = innertubeClient{api: &youtubeAPI, name: "WEB", version: "2.20241118.01.00", origin: "https://www.youtube.com"}
+
+const browseMaxPages = 200 //Pages of 100 items requested at most.
+
+// innertubeClient is a YouTube web client, identified by its name and version.
+type innertubeClient struct {
+	api     *string //Browse endpoint, a pointer so tests can change it.
+	name    string
+	version string
+	origin  string
+	region  string //Country code, like US, of the content to get. Empty for the default of YouTube.
+}
+
+// PlaylistEntry is an item of a playlist read by PlaylistItems.
+type PlaylistEntry struct {
+	URL       string        //Url of the video, or of the track for YouTube Music.
+	VideoID   string        //YouTube video id.
+	Title     string        //Title of the video or track.
+	Author    string        //Channel of the video, or artist of the track.
+	Index     int           //Position in the playlist, from 1. For albums, the track number.
+	Duration  time.Duration //Duration of the video, 0 if unknown.
+	Thumbnail string        //Url of the hqdefault thumbnail of the video, see BestThumbnail for bigger ones.
+}
+
+// PlaylistItems reads a YouTube or YouTube Music playlist page by page, see the method.
+func PlaylistItems(ctx context.Context, playlist string) iter.Seq2[PlaylistEntry, error] {
+	return defaultCobalt().PlaylistItems(ctx, playlist)
+}
+
+// PlaylistItems returns an iterator over the items of a YouTube or YouTube Music playlist (or album), requesting a page of
+// items only when the previous one was used, using the http client of this Cobalt. Stop the loop to stop requesting pages.
+// Errors are yielded once, ending the iteration.
+//
+//	for entry, err := range client.PlaylistItems(ctx, url) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Cobalt) PlaylistItems(ctx context.Context, playlist string) iter.Seq2[PlaylistEntry, error] {
+	return func(yield func(PlaylistEntry, error) bool) {
+		u, err := url.Parse(playlist)
+		if err != nil {
+			yield(PlaylistEntry{}, err)
+			return
+		}
+		client := youtubeClient
+		browseID := "VL" + u.Query().Get("list")
+		if u.Hostname() == "music.youtube.com" || strings.HasPrefix(u.Query().Get("list"), "OLAK5uy_") {
+			client = youtubeMusicClient
+			browseID, err = youtubeMusicBrowseID(playlist)
+		} else if !strings.HasSuffix(u.Hostname(), "youtube.com") || u.Path != "/playlist" || browseID == "VL" {
+			err = errors.New("non youtube playlist url provided")
+		}
+		if err != nil {
+			yield(PlaylistEntry{}, err)
+			return
+		}
+
+		index := 0
+		album := &MusicPlaylist{IsAlbum: isMusicAlbum(browseID)}
+		for page, err := range c.browsePages(ctx, client, browseID) {
+			if err != nil {
+				yield(PlaylistEntry{}, err)
+				return
+			}
+			if page.header != nil && album.Title == "" {
+				album.Title, album.Artist = musicHeader(page.header)
+			}
+
+			var entries []PlaylistEntry
+			for _, item := range page.items {
+				if track, ok := musicTrack(item, album); ok {
+					entries = append(entries, PlaylistEntry{URL: track.URL, VideoID: track.VideoID, Title: track.Title, Author: track.Artist, Index: track.Index, Thumbnail: videoThumbnail(track.VideoID)})
+				}
+			}
+			for _, video := range page.videos {
+				if entry, ok := playlistVideo(video); ok {
+					entries = append(entries, entry)
+				}
+			}
+			for _, entry := range entries {
+				index++
+				if entry.Index == 0 {
+					entry.Index = index
+				}
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// playlistVideo reads a playlistVideoRenderer of the YouTube web client, false for unavailable videos.
+func playlistVideo(video map[string]any) (PlaylistEntry, bool) {
+	videoID, _ := video["videoId"].(string)
+	if videoID == "" || video["isPlayable"] == false {
+		return PlaylistEntry{}, false
+	}
+	entry := PlaylistEntry{
+		URL:       "https://www.youtube.com/watch?v=" + videoID,
+		VideoID:   videoID,
+		Title:     musicText(video["title"]),
+		Author:    musicText(video["shortBylineText"]),
+		Thumbnail: videoThumbnail(videoID),
+	}
+	if seconds, err := strconv.Atoi(musicString(video, "lengthSeconds")); err == nil {
+		entry.Duration = time.Duration(seconds) * time.Second
+	}
+	return entry, true
+}
+
+// browsePages returns an iterator over the pages of a browse page, following the continuation tokens.
+func (c *Cobalt) browsePages(ctx context.Context, client innertubeClient, browseID string) iter.Seq2[*musicWalk, error] {
+	return c.innertubePages(ctx, client, *client.api, map[string]any{"browseId": browseID})
+}
+
+// innertubePages returns an iterator over the pages of the response to the request, following the continuation tokens.
+func (c *Cobalt) innertubePages(ctx context.Context, client innertubeClient, endpoint string, request map[string]any) iter.Seq2[*musicWalk, error] {
+	return func(yield func(*musicWalk, error) bool) {
+		for page := 0; page < browseMaxPages; page++ {
+			var response any
+			if err := c.innertubeRequest(ctx, client, endpoint, request, &response); err != nil {
+				yield(nil, err)
+				return
+			}
+			walked := &musicWalk{}
+			walked.walk(response)
+			if !yield(walked, nil) || walked.continuation == "" {
+				return
+			}
+			request = map[string]any{"continuation": walked.continuation}
+		}
+	}
+}
+
+// innertubeRequest posts the request to an endpoint of the api of a YouTube web client.
+func (c *Cobalt) innertubeRequest(ctx context.Context, client innertubeClient, endpoint string, request map[string]any, v any) error {
+	clientContext := map[string]any{"clientName": client.name, "clientVersion": client.version, "hl": cmp.Or(c.language, DefaultLanguage)}
+	if client.region != "" {
+		clientContext["gl"] = client.region
+	}
+	request["context"] = map[string]any{"client": clientContext}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create the request to %v: %w", endpoint, err)
+	}
+	req.Header.Add("User-Agent", c.userAgent)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Origin", client.origin)
+	if c.language != "" {
+		req.Header.Add("Accept-Language", c.language)
+	}
+	c.addScraperCookies(req, client.origin)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %v failed with %v", endpoint, res.Status)
+	}
+	jsonBody, err := c.readBody(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonBody, v)
+}
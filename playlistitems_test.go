@@ -0,0 +1,94 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func playlistVideoJSON(videoID, title string, seconds int) string {
+	return fmt.Sprintf(`{"playlistVideoRenderer":{"videoId":"%v","title":{"runs":[{"text":"%v"}]},"shortBylineText":{"runs":[{"text":"Channel"}]},"lengthSeconds":"%v","isPlayable":true}}`, videoID, title, seconds)
+}
+
+func TestPlaylistItems(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if request["continuation"] == "page2" {
+			fmt.Fprintf(w, `{"onResponseReceivedActions":[{"appendContinuationItemsAction":{"continuationItems":[%v]}}]}`, playlistVideoJSON("ccccccccccc", "Third", 30))
+			return
+		}
+		fmt.Fprintf(w, `{"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"content":{"sectionListRenderer":{"contents":[{"itemSectionRenderer":{"contents":[{"playlistVideoListRenderer":{"contents":[
+			%v,%v,{"playlistVideoRenderer":{"videoId":"ddddddddddd","isPlayable":false}},
+			{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page2"}}}}]}}]}}]}}}}]}}}`,
+			playlistVideoJSON("aaaaaaaaaaa", "First", 212), playlistVideoJSON("bbbbbbbbbbb", "Second", 61))
+	}))
+	defer server.Close()
+	oldAPI := youtubeAPI
+	youtubeAPI = server.URL
+	defer func() { youtubeAPI = oldAPI }()
+
+	var entries []PlaylistEntry
+	for entry, err := range PlaylistItems(context.Background(), "https://www.youtube.com/playlist?list=PLabc") {
+		if err != nil {
+			t.Fatalf("failed reading the playlist: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 3 || len(requests) != 2 || requests[0]["browseId"] != "VLPLabc" {
+		t.Fatalf("unexpected entries %+v after requests %v", entries, requests)
+	}
+	expected := PlaylistEntry{URL: "https://www.youtube.com/watch?v=aaaaaaaaaaa", VideoID: "aaaaaaaaaaa", Title: "First", Author: "Channel", Index: 1, Duration: 212 * time.Second, Thumbnail: "https://i.ytimg.com/vi/aaaaaaaaaaa/hqdefault.jpg"}
+	if entries[0] != expected || entries[2].Index != 3 || entries[2].VideoID != "ccccccccccc" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	requests = nil
+	for range PlaylistItems(context.Background(), "https://www.youtube.com/playlist?list=PLabc") {
+		break
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected stopping the loop to stop requesting pages, made %v requests", len(requests))
+	}
+
+	for _, err := range PlaylistItems(context.Background(), "https://example.com/playlist?list=PLabc") {
+		if err == nil {
+			t.Errorf("expected an error for a non youtube url")
+		}
+	}
+}
+
+func TestPlaylistItemsLanguage(t *testing.T) {
+	var hl, acceptLanguage []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Context struct {
+				Client struct {
+					HL string `json:"hl"`
+				} `json:"client"`
+			} `json:"context"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		hl = append(hl, request.Context.Client.HL)
+		acceptLanguage = append(acceptLanguage, r.Header.Get("Accept-Language"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	oldAPI := youtubeAPI
+	youtubeAPI = server.URL
+	defer func() { youtubeAPI = oldAPI }()
+
+	for _, client := range []*Cobalt{New(), New(WithLanguage("pt-BR")), New(WithLanguage(""))} {
+		for range client.PlaylistItems(context.Background(), "https://www.youtube.com/playlist?list=PLabc") {
+		}
+	}
+	if hl[0] != "en-US" || acceptLanguage[0] != "en-US" || hl[1] != "pt-BR" || acceptLanguage[1] != "pt-BR" || hl[2] != "en-US" || acceptLanguage[2] != "" {
+		t.Errorf("unexpected languages %v and Accept-Language %v", hl, acceptLanguage)
+	}
+}
@@ -0,0 +1,132 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Plugins: hooks and post-processors registered by other packages, usually from their init function, so tagging, transcoding
+// or uploading integrations can live outside gobalt. Importing the package of a plugin enables it, like database/sql drivers.
+
+// Plugin is a named set of hooks. Hooks are optional, and run in the order the plugins were registered.
+// An error returned by a hook fails the job or the download.
+type Plugin struct {
+	Name string
+
+	PreSubmit     func(ctx context.Context, settings *Settings) error                            //Called before a job is sent to cobalt, it can change the settings.
+	PostResponse  func(ctx context.Context, settings Settings, media *CobaltResponse) error      //Called after cobalt answered a job successfully.
+	PostDownload  func(ctx context.Context, media *CobaltResponse, result *DownloadResult) error //Called after a file was downloaded and post-processed.
+	PostProcessor PostProcessor                                                                  //Post-processor available as LookupPostProcessor(Name).
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   []Plugin
+)
+
+// ErrInvalidPlugin is returned by RegisterPlugin for plugins without a name, or with the name of a registered plugin.
+var ErrInvalidPlugin = errors.New("invalid plugin")
+
+// RegisterPlugin makes a plugin run for every Cobalt, unless disabled with WithoutPlugins. The plugin isn't registered if
+// its name is empty or already registered, an ErrInvalidPlugin error is returned instead.
+func RegisterPlugin(plugin Plugin) error {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if plugin.Name == "" {
+		return fmt.Errorf("%w: the name is empty", ErrInvalidPlugin)
+	}
+	if slices.ContainsFunc(plugins, func(p Plugin) bool { return p.Name == plugin.Name }) {
+		return fmt.Errorf("%w: %v is already registered", ErrInvalidPlugin, plugin.Name)
+	}
+	plugins = append(plugins, plugin)
+	return nil
+}
+
+// Plugins returns the names of the registered plugins, in the order they were registered.
+func Plugins() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	names := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		names[i] = plugin.Name
+	}
+	return names
+}
+
+// LookupPostProcessor returns the post-processor of the plugin with the name, false if there's none.
+func LookupPostProcessor(name string) (PostProcessor, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	for _, plugin := range plugins {
+		if plugin.Name == name && plugin.PostProcessor != nil {
+			return plugin.PostProcessor, true
+		}
+	}
+	return nil, false
+}
+
+// WithoutPlugins disables the hooks of the named plugins on this Cobalt, or of every plugin if no name is given.
+func WithoutPlugins(names ...string) Option {
+	return func(c *Cobalt) {
+		if len(names) == 0 {
+			c.pluginsOff = true
+			return
+		}
+		c.disabledPlugins = append(c.disabledPlugins, names...)
+	}
+}
+
+// enabledPlugins returns the plugins with hooks that run on c.
+func (c *Cobalt) enabledPlugins() []Plugin {
+	if c.pluginsOff {
+		return nil
+	}
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	var enabled []Plugin
+	for _, plugin := range plugins {
+		if !slices.Contains(c.disabledPlugins, plugin.Name) {
+			enabled = append(enabled, plugin)
+		}
+	}
+	return enabled
+}
+
+func (c *Cobalt) preSubmit(ctx context.Context, settings *Settings) error {
+	for _, plugin := range c.enabledPlugins() {
+		if plugin.PreSubmit == nil {
+			continue
+		}
+		if err := plugin.PreSubmit(ctx, settings); err != nil {
+			return fmt.Errorf("plugin %v: %w", plugin.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Cobalt) postResponse(ctx context.Context, settings Settings, media *CobaltResponse) error {
+	for _, plugin := range c.enabledPlugins() {
+		if plugin.PostResponse == nil {
+			continue
+		}
+		if err := plugin.PostResponse(ctx, settings, media); err != nil {
+			return fmt.Errorf("plugin %v: %w", plugin.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Cobalt) postDownload(ctx context.Context, media *CobaltResponse, result *DownloadResult) error {
+	for _, plugin := range c.enabledPlugins() {
+		if plugin.PostDownload == nil {
+			continue
+		}
+		if err := plugin.PostDownload(ctx, media, result); err != nil {
+			return fmt.Errorf("plugin %v: %w", plugin.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// registerTestPlugin registers a plugin for the duration of the test.
+func registerTestPlugin(t *testing.T, plugin Plugin) {
+	t.Helper()
+	if err := RegisterPlugin(plugin); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pluginsMu.Lock()
+		defer pluginsMu.Unlock()
+		plugins = slices.DeleteFunc(plugins, func(p Plugin) bool { return p.Name == plugin.Name })
+	})
+}
+
+func TestPluginHooks(t *testing.T) {
+	var submitted Settings
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/file":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write([]byte("media"))
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+		default:
+			json.NewDecoder(r.Body).Decode(&submitted)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(CobaltResponse{Status: "tunnel", URL: server.URL + "/file", Filename: "song.mp3"})
+		}
+	}))
+	defer server.Close()
+
+	var downloaded string
+	registerTestPlugin(t, Plugin{
+		Name: "test",
+		PreSubmit: func(ctx context.Context, settings *Settings) error {
+			settings.Mode = Audio
+			return nil
+		},
+		PostResponse: func(ctx context.Context, settings Settings, media *CobaltResponse) error {
+			media.Filename = "tagged " + media.Filename
+			return nil
+		},
+		PostDownload: func(ctx context.Context, media *CobaltResponse, result *DownloadResult) error {
+			downloaded = result.Path
+			return nil
+		},
+	})
+
+	client := New(WithAPI(server.URL))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	media, err := client.Run(settings)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if submitted.Mode != Audio {
+		t.Errorf("expected PreSubmit to change the settings sent, got mode %q", submitted.Mode)
+	}
+	if media.Filename != "tagged song.mp3" {
+		t.Errorf("expected PostResponse to change the response, got %q", media.Filename)
+	}
+
+	result, err := client.Download(media, DownloadOptions{Directory: t.TempDir()})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if downloaded == "" || downloaded != result.Path {
+		t.Errorf("expected PostDownload to get %q, got %q", result.Path, downloaded)
+	}
+
+	submitted = Settings{}
+	if media, err = New(WithAPI(server.URL), WithoutPlugins("test")).Run(settings); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if submitted.Mode == Audio || media.Filename != "song.mp3" {
+		t.Errorf("expected the disabled plugin not to run, got mode %q and filename %q", submitted.Mode, media.Filename)
+	}
+}
+
+func TestPluginErrorFailsJob(t *testing.T) {
+	failure := errors.New("rejected")
+	registerTestPlugin(t, Plugin{Name: "reject", PreSubmit: func(context.Context, *Settings) error { return failure }})
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	_, err := New(WithAPI("http://127.0.0.1:0")).Run(settings)
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "plugin reject") {
+		t.Errorf("expected the plugin error, got %v", err)
+	}
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	processor := PostProcessorFunc(func(ctx context.Context, path string) (string, error) { return path, nil })
+	registerTestPlugin(t, Plugin{Name: "processor", PostProcessor: processor})
+
+	if !slices.Contains(Plugins(), "processor") {
+		t.Errorf("expected the plugin to be listed, got %v", Plugins())
+	}
+	if _, ok := LookupPostProcessor("processor"); !ok {
+		t.Errorf("expected the post-processor to be found")
+	}
+	if _, ok := LookupPostProcessor("missing"); ok {
+		t.Errorf("expected no post-processor for an unknown name")
+	}
+
+	if err := RegisterPlugin(Plugin{Name: "processor"}); !errors.Is(err, ErrInvalidPlugin) {
+		t.Errorf("expected ErrInvalidPlugin registering a name twice, got %v", err)
+	}
+	if err := RegisterPlugin(Plugin{}); !errors.Is(err, ErrInvalidPlugin) {
+		t.Errorf("expected ErrInvalidPlugin registering an empty name, got %v", err)
+	}
+	if names := slices.Sorted(slices.Values(Plugins())); len(slices.Compact(names)) != len(names) || slices.Contains(names, "") {
+		t.Errorf("expected the invalid plugins to not be registered, got %v", Plugins())
+	}
+}
@@ -0,0 +1,135 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Post-processing of downloaded files, like remuxing or re-encoding with ffmpeg, as an ordered pipeline in DownloadOptions.PostProcessors.
+
+// ErrFFmpegNotFound is returned by the ffmpeg post-processors when FFmpegPath can't be found.
+var ErrFFmpegNotFound = errors.New("ffmpeg not found")
+
+// FFmpegPath is the ffmpeg executable used by the built-in post-processors. Default: ffmpeg from PATH.
+var FFmpegPath = "ffmpeg"
+
+// PostProcessor changes a downloaded file. Process returns the path of the result, or input if it was changed in place.
+// When the path changes, the pipeline removes input.
+type PostProcessor interface {
+	Process(ctx context.Context, input string) (string, error)
+}
+
+// PostProcessorFunc is a function used as a PostProcessor.
+type PostProcessorFunc func(ctx context.Context, input string) (string, error)
+
+func (f PostProcessorFunc) Process(ctx context.Context, input string) (string, error) {
+	return f(ctx, input)
+}
+
+// FFmpegAvailable reports if FFmpegPath can be run.
+func FFmpegAvailable() bool {
+	_, err := exec.LookPath(FFmpegPath)
+	return err == nil
+}
+
+// runPostProcessors runs the pipeline on path, returning the path of the final file.
+func runPostProcessors(ctx context.Context, path string, processors []PostProcessor) (string, error) {
+	for i, processor := range processors {
+		output, err := processor.Process(ctx, path)
+		if err != nil {
+			return path, fmt.Errorf("post-processor %v failed on %v: %w", i+1, path, err)
+		}
+		if output != path {
+			os.Remove(path)
+			path = output
+		}
+	}
+	return path, nil
+}
+
+// Remux copies the streams to another container, like mkv or mp4, without re-encoding.
+func Remux(container string) PostProcessor {
+	return PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		return ffmpeg(ctx, input, container, func(output string) []string {
+			return []string{"-i", input, "-map", "0", "-c", "copy", output}
+		})
+	})
+}
+
+// Reencode converts the file to ext with the ffmpeg output arguments, like Reencode("mp3", "-b:a", "192k").
+// Without arguments ffmpeg picks the codecs for ext.
+func Reencode(ext string, args ...string) PostProcessor {
+	return PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		return ffmpeg(ctx, input, ext, func(output string) []string {
+			return append(append([]string{"-i", input}, args...), output)
+		})
+	})
+}
+
+// EmbedThumbnail attaches an image, from a path or an url, as the cover art of the file.
+func EmbedThumbnail(thumbnail string) PostProcessor {
+	return PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		ext := strings.TrimPrefix(filepath.Ext(input), ".")
+		return ffmpeg(ctx, input, ext, func(output string) []string {
+			args := []string{"-i", input, "-i", thumbnail, "-map", "0", "-map", "1", "-c", "copy", "-disposition:v:1", "attached_pic"}
+			if ext == "mp3" {
+				args = append(args, "-id3v2_version", "3")
+			}
+			return append(args, output)
+		})
+	})
+}
+
+// ffmpeg runs ffmpeg with the arguments for the output path, which is input with the ext extension.
+// If the extension doesn't change, the output replaces input.
+func ffmpeg(ctx context.Context, input, ext string, args func(output string) []string) (string, error) {
+	output := strings.TrimSuffix(input, filepath.Ext(input)) + "." + ext
+	inPlace := output == input
+	if inPlace {
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + ".ffmpeg." + ext
+	}
+
+	if err := runFFmpeg(ctx, args(output)); err != nil {
+		os.Remove(output)
+		return "", err
+	}
+
+	if inPlace {
+		if err := os.Rename(output, input); err != nil {
+			return "", err
+		}
+		return input, nil
+	}
+	return output, nil
+}
+
+// runFFmpeg runs FFmpegPath with the arguments, returning its error output on failure.
+func runFFmpeg(ctx context.Context, args []string) error {
+	_, err := ffmpegOutput(ctx, "error", args)
+	return err
+}
+
+// ffmpegOutput runs FFmpegPath with the arguments and the log level, returning what it logged.
+func ffmpegOutput(ctx context.Context, logLevel string, args []string) (string, error) {
+	executable, err := exec.LookPath(FFmpegPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFFmpegNotFound, err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, executable, append([]string{"-y", "-hide_banner", "-loglevel", logLevel}, args...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("ffmpeg failed: %w: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	return stderr.String(), nil
+}
@@ -0,0 +1,188 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPostProcessors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	rename := PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		output := strings.TrimSuffix(input, filepath.Ext(input)) + ".txt"
+		data, _ := os.ReadFile(input)
+		return output, os.WriteFile(output, data, 0o644)
+	})
+	upper := PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		data, _ := os.ReadFile(input)
+		return input, os.WriteFile(input, bytes.ToUpper(data), 0o644)
+	})
+
+	dir := t.TempDir()
+	media := &CobaltResponse{Status: "tunnel", URL: server.URL, Filename: "file.mp4"}
+	result, err := Download(media, DownloadOptions{Directory: dir, PostProcessors: []PostProcessor{rename, upper}})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if result.Path != filepath.Join(dir, "file.txt") {
+		t.Errorf("unexpected path %v", result.Path)
+	}
+	if data, _ := os.ReadFile(result.Path); string(data) != "MEDIA" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file.mp4")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the replaced file to be removed")
+	}
+
+	failed := errors.New("failed")
+	_, err = Download(media, DownloadOptions{Directory: dir, PostProcessors: []PostProcessor{PostProcessorFunc(func(ctx context.Context, input string) (string, error) {
+		return "", failed
+	})}})
+	if !errors.Is(err, failed) {
+		t.Errorf("expected the post-processor error, got %v", err)
+	}
+}
+
+func TestFFmpegNotFound(t *testing.T) {
+	defer func(path string) { FFmpegPath = path }(FFmpegPath)
+	FFmpegPath = "gobalt-ffmpeg-that-does-not-exist"
+
+	path := filepath.Join(t.TempDir(), "file.webm")
+	os.WriteFile(path, []byte("media"), 0o644)
+	if _, err := Remux("mkv").Process(context.Background(), path); !errors.Is(err, ErrFFmpegNotFound) {
+		t.Errorf("expected ErrFFmpegNotFound, got %v", err)
+	}
+}
+
+func TestRemux(t *testing.T) {
+	if !FFmpegAvailable() {
+		t.Skip("ffmpeg isn't installed")
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "tone.mp4")
+	generate := PostProcessorFunc(func(ctx context.Context, _ string) (string, error) {
+		return ffmpeg(ctx, input, "mp4", func(output string) []string {
+			return []string{"-f", "lavfi", "-i", "sine=duration=1", output}
+		})
+	})
+	if _, err := generate.Process(context.Background(), input); err != nil {
+		t.Fatalf("failed generating a test file: %v", err)
+	}
+
+	output, err := Remux("mkv").Process(context.Background(), input)
+	if err != nil {
+		t.Fatalf("remux failed: %v", err)
+	}
+	if output != filepath.Join(dir, "tone.mkv") {
+		t.Errorf("unexpected output %v", output)
+	}
+}
+
+// fakeFFmpeg replaces FFmpegPath with a script that writes the output file (its last argument), prints stderr and exits with code.
+// It returns a function reading the arguments of every run, in order.
+func fakeFFmpeg(t *testing.T, stderr string, code int) func() [][]string {
+	t.Helper()
+	if runtime.GOOS == "windows" || runtime.GOOS == "js" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "stderr"), []byte(stderr), 0o644)
+	script := fmt.Sprintf(`#!/bin/sh
+printf '%%s\n' "$@" >> %[1]q/args
+echo >> %[1]q/args
+for last; do :; done
+if [ "$last" != "-" ]; then echo converted > "$last"; fi
+cat %[1]q/stderr >&2
+exit %[2]v
+`, dir, code)
+	path := filepath.Join(dir, "ffmpeg")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	previous := FFmpegPath
+	t.Cleanup(func() { FFmpegPath = previous })
+	FFmpegPath = path
+
+	return func() [][]string {
+		data, _ := os.ReadFile(filepath.Join(dir, "args"))
+		var runs [][]string
+		for _, run := range strings.Split(strings.TrimSuffix(string(data), "\n\n"), "\n\n") {
+			if run != "" {
+				runs = append(runs, strings.Split(run, "\n"))
+			}
+		}
+		return runs
+	}
+}
+
+func TestFFmpegSteps(t *testing.T) {
+	runs := fakeFFmpeg(t, "", 0)
+	dir := t.TempDir()
+	video, song := filepath.Join(dir, "video.webm"), filepath.Join(dir, "song.mp3")
+	os.WriteFile(video, []byte("media"), 0o644)
+	os.WriteFile(song, []byte("media"), 0o644)
+	tmp := filepath.Join(dir, "song.ffmpeg.mp3")
+
+	steps := []struct {
+		processor PostProcessor
+		input     string
+		output    string
+		args      []string
+	}{
+		{Remux("mkv"), video, filepath.Join(dir, "video.mkv"), []string{"-i", video, "-map", "0", "-c", "copy", filepath.Join(dir, "video.mkv")}},
+		{Reencode("mp3", "-b:a", "192k"), video, filepath.Join(dir, "video.mp3"), []string{"-i", video, "-b:a", "192k", filepath.Join(dir, "video.mp3")}},
+		{EmbedThumbnail("https://example.com/cover.jpg"), song, song, []string{"-i", song, "-i", "https://example.com/cover.jpg", "-map", "0", "-map", "1", "-c", "copy", "-disposition:v:1", "attached_pic", "-id3v2_version", "3", tmp}},
+	}
+	for i, step := range steps {
+		output, err := step.processor.Process(context.Background(), step.input)
+		if err != nil {
+			t.Fatalf("step %v failed: %v", i, err)
+		}
+		if output != step.output {
+			t.Errorf("step %v: expected the output %v, got %v", i, step.output, output)
+		}
+		if data, _ := os.ReadFile(output); string(data) != "converted\n" {
+			t.Errorf("step %v: expected the output of ffmpeg in %v, got %q", i, output, data)
+		}
+		expected := append([]string{"-y", "-hide_banner", "-loglevel", "error"}, step.args...)
+		if run := runs()[i]; !slices.Equal(run, expected) {
+			t.Errorf("step %v: unexpected arguments\n%q\nexpected\n%q", i, run, expected)
+		}
+	}
+	if _, err := os.Stat(tmp); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the in place output to replace the input")
+	}
+}
+
+func TestFFmpegFailure(t *testing.T) {
+	runs := fakeFFmpeg(t, "video.webm: Invalid data found when processing input\n", 1)
+	input := filepath.Join(t.TempDir(), "video.webm")
+	os.WriteFile(input, []byte("media"), 0o644)
+
+	_, err := Remux("mkv").Process(context.Background(), input)
+	if err == nil || !strings.Contains(err.Error(), "ffmpeg failed") || !strings.Contains(err.Error(), "Invalid data found") {
+		t.Errorf("expected the error output of ffmpeg, got %v", err)
+	}
+	if len(runs()) != 1 {
+		t.Errorf("expected ffmpeg to run once, got %v", runs())
+	}
+	if _, err := os.Stat(strings.TrimSuffix(input, ".webm") + ".mkv"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the partial output to be removed")
+	}
+	if data, _ := os.ReadFile(input); string(data) != "media" {
+		t.Errorf("expected the input to be kept, got %q", data)
+	}
+}
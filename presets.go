@@ -0,0 +1,195 @@
+package gobalt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Ready-made Settings for common downloads, the interplay of the mode, codecs and service flags isn't obvious.
+// They start from CreateDefaultSettings, set Settings.Url before calling Run.
+// Presets keeps them by name, along with the presets of your users, like "music" or "archive-4k".
+
+// TikTokOriginalAudio downloads the original sound used in a TikTok video, instead of the audio of the video itself.
+func TikTokOriginalAudio() Settings {
+	options := CreateDefaultSettings()
+	options.Mode = Audio
+	options.AudioFormat = Best
+	options.TikTokFullAudio = true
+	return options
+}
+
+// TikTokHD downloads TikTok videos in 1080p. They are H265, which older players and some phones can't play.
+func TikTokHD() Settings {
+	options := CreateDefaultSettings()
+	options.TikTokH265 = true
+	return options
+}
+
+// TwitterGIF downloads twitter gifs as real .gif files, instead of the looping .mp4 twitter serves.
+// Twitter videos with audio are downloaded as usual.
+func TwitterGIF() Settings {
+	options := CreateDefaultSettings()
+	options.Mode = Auto
+	options.TwitterConvertGif = true
+	return options
+}
+
+// YouTubeMusicBest downloads the audio of YouTube (Music) videos as YouTube serves it, without re-encoding, with metadata.
+// The bitrate is only used if the instance has to re-encode it.
+func YouTubeMusicBest() Settings {
+	options := CreateDefaultSettings()
+	options.Mode = Audio
+	options.AudioFormat = Best
+	options.AudioBitrate = Bitrate320
+	options.DisableMetadata = false
+	return options
+}
+
+// YouTubeBestVideo downloads YouTube videos in up to 4K with VP9, which keeps the most detail and supports HDR.
+// Use the default settings (H264, 1080p) for files shared on social media or played on phones.
+func YouTubeBestVideo() Settings {
+	options := CreateDefaultSettings()
+	options.YoutubeVideoFormat = VP9
+	options.VideoQuality = Q2160
+	return options
+}
+
+// ErrUnknownPreset is returned by Presets.Apply for names without a preset.
+var ErrUnknownPreset = errors.New("unknown preset")
+
+// BuiltinPresets returns the ready-made presets of this file by name. They are in every Presets, unless replaced.
+func BuiltinPresets() map[string]Settings {
+	return map[string]Settings{
+		"tiktok-original-audio": TikTokOriginalAudio(),
+		"tiktok-hd":             TikTokHD(),
+		"twitter-gif":           TwitterGIF(),
+		"youtube-music-best":    YouTubeMusicBest(),
+		"youtube-best-video":    YouTubeBestVideo(),
+	}
+}
+
+// Presets is a set of named Settings, saved in a json file. It's safe for concurrent use.
+type Presets struct {
+	path string
+
+	mu      sync.Mutex
+	presets map[string]Settings //Presets set by the user, the built-in ones aren't saved.
+}
+
+// LoadPresets reads the presets saved in the file at path. A missing file is an empty set, created by Save.
+func LoadPresets(path string) (*Presets, error) {
+	p := &Presets{path: path, presets: map[string]Settings{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved map[string]map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid presets file %v: %w", path, err)
+	}
+	for name, values := range saved {
+		settings, err := parseSettings(values, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset %q in %v: %w", name, path, err)
+		}
+		p.presets[name] = settings
+	}
+	return p, nil
+}
+
+// Save writes the presets set with Set to the file they were loaded from.
+func (p *Presets) Save() error {
+	p.mu.Lock()
+	data, err := json.MarshalIndent(p.presets, "", "\t")
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	//Written to a temporary file first, so a failed save doesn't lose the presets.
+	file, err := os.CreateTemp(filepath.Dir(p.path), ".presets-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), p.path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Set adds or replaces the preset name, without its url. Call Save to keep it.
+func (p *Presets) Set(name string, settings Settings) error {
+	if name == "" {
+		return errors.New("preset name is empty")
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	settings.Url = ""
+	settings.Extra = maps.Clone(settings.Extra)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.presets[name] = settings
+	return nil
+}
+
+// Delete removes the preset name set with Set, false if there was none. Built-in presets can't be removed.
+func (p *Presets) Delete(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, found := p.presets[name]
+	delete(p.presets, name)
+	return found
+}
+
+// Get returns the preset name, set with Set or built-in.
+func (p *Presets) Get(name string) (Settings, bool) {
+	p.mu.Lock()
+	settings, found := p.presets[name]
+	p.mu.Unlock()
+	if !found {
+		settings, found = BuiltinPresets()[name]
+	}
+	settings.Extra = maps.Clone(settings.Extra)
+	return settings, found
+}
+
+// Names returns the names of every preset, including the built-in ones, sorted.
+func (p *Presets) Names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := slices.Collect(maps.Keys(p.presets))
+	for name := range BuiltinPresets() {
+		if _, found := p.presets[name]; !found {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Apply returns the preset name with url set, ready for Run.
+func (p *Presets) Apply(name, url string) (Settings, error) {
+	settings, found := p.Get(name)
+	if !found {
+		return Settings{}, fmt.Errorf("%w: %v", ErrUnknownPreset, name)
+	}
+	settings.Url = url
+	return settings, nil
+}
@@ -0,0 +1,93 @@
+package gobalt
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name     string
+		preset   Settings
+		expected map[string]any
+	}{
+		{"TikTokOriginalAudio", TikTokOriginalAudio(), map[string]any{"downloadMode": "audio", "tiktokFullAudio": true, "audioFormat": "best"}},
+		{"TikTokHD", TikTokHD(), map[string]any{"downloadMode": "auto", "allowH265": true}},
+		{"TwitterGIF", TwitterGIF(), map[string]any{"downloadMode": "auto", "convertGif": true}},
+		{"YouTubeMusicBest", YouTubeMusicBest(), map[string]any{"downloadMode": "audio", "audioFormat": "best", "audioBitrate": "320"}},
+		{"YouTubeBestVideo", YouTubeBestVideo(), map[string]any{"youtubeVideoCodec": "vp9", "videoQuality": "2160"}},
+	}
+	for _, test := range tests {
+		test.preset.Url = "https://example.com/video"
+		body, err := shapeSettings(test.preset, "11.0")
+		if err != nil {
+			t.Fatalf("%v: marshal failed: %v", test.name, err)
+		}
+		var fields map[string]any
+		json.Unmarshal(body, &fields)
+		for field, value := range test.expected {
+			if fields[field] != value {
+				t.Errorf("%v: expected %v to be %v, got %v", test.name, field, value, fields[field])
+			}
+		}
+	}
+}
+
+func TestPresetsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	presets, err := LoadPresets(path)
+	if err != nil {
+		t.Fatalf("expected a missing file to be an empty set, got %v", err)
+	}
+
+	music := YouTubeMusicBest()
+	music.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	music.Extra = map[string]any{"localProcessing": "preferred"}
+	archive := CreateDefaultSettings()
+	archive.VideoQuality, archive.YoutubeVideoFormat = Q2160, VP9
+	if err := presets.Set("music", music); err != nil {
+		t.Fatal(err)
+	}
+	if err := presets.Set("archive-4k", archive); err != nil {
+		t.Fatal(err)
+	}
+	if err := presets.Set("broken", Settings{VideoQuality: 1000}); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("expected invalid settings to be rejected, got %v", err)
+	}
+	if err := presets.Save(); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := LoadPresets(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	names := loaded.Names()
+	if !slices.Contains(names, "music") || !slices.Contains(names, "archive-4k") || !slices.Contains(names, "tiktok-hd") || !slices.IsSorted(names) {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	settings, err := loaded.Apply("music", "https://www.youtube.com/watch?v=jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if settings.Url != "https://www.youtube.com/watch?v=jNQXAC9IVRw" || settings.Mode != Audio || settings.AudioBitrate != Bitrate320 || settings.Extra["localProcessing"] != "preferred" {
+		t.Errorf("unexpected music preset %+v", settings)
+	}
+	if settings, _ := loaded.Get("archive-4k"); settings.VideoQuality != Q2160 || settings.YoutubeVideoFormat != VP9 || settings.Url != "" {
+		t.Errorf("unexpected archive preset %+v", settings)
+	}
+
+	if _, err := loaded.Apply("missing", ""); !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("expected ErrUnknownPreset, got %v", err)
+	}
+	if loaded.Delete("tiktok-hd") {
+		t.Errorf("expected built-in presets not to be deleted")
+	}
+	if !loaded.Delete("music") || slices.Contains(loaded.Names(), "music") {
+		t.Errorf("expected the preset to be deleted")
+	}
+}
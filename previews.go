@@ -0,0 +1,112 @@
+package gobalt
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Thumbnails of picker items, to show a gallery before downloading, or a preview in a chat embed.
+
+// Thumbnail is the preview image of a picker item, fetched by FetchThumbnail.
+type Thumbnail struct {
+	URL         string //Url of the image, PickerItem.Thumb.
+	Data        []byte
+	ContentType string //Like image/jpeg, from the response or detected from Data.
+}
+
+// DataURI returns the image as a data uri, like data:image/jpeg;base64,/9j/..., to embed it in html or chat messages.
+func (t *Thumbnail) DataURI() string {
+	return "data:" + t.ContentType + ";base64," + base64.StdEncoding.EncodeToString(t.Data)
+}
+
+// FetchThumbnail downloads the thumbnail of a picker item, see the method.
+func FetchThumbnail(ctx context.Context, item PickerItem) (*Thumbnail, error) {
+	return defaultCobalt().FetchThumbnail(ctx, item)
+}
+
+// FetchThumbnail downloads the thumbnail of a picker item in memory, with the http client of this Cobalt.
+// It fails if the item has no thumbnail, or the url isn't an image. The size is limited by WithMaxResponseSize.
+func (c *Cobalt) FetchThumbnail(ctx context.Context, item PickerItem) (*Thumbnail, error) {
+	if item.Thumb == "" {
+		return nil, fmt.Errorf("the %v has no thumbnail", cmp.Or(string(item.Type), "item"))
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, item.Thumb, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %v: %w", item.Thumb, err)
+	}
+	request.Header.Add("User-Agent", c.userAgent)
+	res, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the thumbnail from %v: %v", item.Thumb, res.Status)
+	}
+	data, err := c.readBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		//File servers often send application/octet-stream.
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("the thumbnail at %v isn't an image, it's %v", item.Thumb, contentType)
+	}
+	return &Thumbnail{URL: item.Thumb, Data: data, ContentType: contentType}, nil
+}
+
+// FetchThumbnails downloads the thumbnails of a picker, see the method.
+func FetchThumbnails(ctx context.Context, media *CobaltResponse, workers int) ([]*Thumbnail, error) {
+	return defaultCobalt().FetchThumbnails(ctx, media, workers)
+}
+
+// FetchThumbnails downloads the thumbnails of every item of a picker response, up to workers at the same time,
+// or all of them at once if workers is 0 or less. The thumbnails are in the order of the items, nil for the items
+// without one or that failed. The errors of the items that failed are joined.
+func (c *Cobalt) FetchThumbnails(ctx context.Context, media *CobaltResponse, workers int) ([]*Thumbnail, error) {
+	if media == nil || !media.IsPicker() || media.Picker == nil {
+		return nil, errors.New("the response isn't a picker")
+	}
+	items := *media.Picker
+	if workers <= 0 || workers > len(items) {
+		workers = len(items)
+	}
+
+	thumbnails := make([]*Thumbnail, len(items))
+	errs := make([]error, len(items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				thumbnail, err := c.FetchThumbnail(ctx, items[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("item %v: %w", i+1, err)
+					continue
+				}
+				thumbnails[i] = thumbnail
+			}
+		}()
+	}
+	for i, item := range items {
+		if item.Thumb != "" {
+			indexes <- i
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return thumbnails, errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchThumbnails(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("\xff\xd8\xff\xe0jpeg"))
+		case "/video.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(png)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	media := &CobaltResponse{Status: "picker", Picker: &[]PickerItem{
+		{Type: Photo, URL: server.URL + "/1", Thumb: server.URL + "/photo.jpg"},
+		{Type: Video, URL: server.URL + "/2", Thumb: server.URL + "/video.png"},
+		{Type: Photo, URL: server.URL + "/3"},
+		{Type: Gif, URL: server.URL + "/4", Thumb: server.URL + "/page"},
+		{Type: Photo, URL: server.URL + "/5", Thumb: server.URL + "/missing.jpg"},
+	}}
+	for _, workers := range []int{0, 1, 2} {
+		thumbnails, err := FetchThumbnails(context.Background(), media, workers)
+		if len(thumbnails) != 5 {
+			t.Fatalf("expected a thumbnail per item, got %v", len(thumbnails))
+		}
+		if err == nil || !strings.Contains(err.Error(), "item 4:") || !strings.Contains(err.Error(), "item 5:") || strings.Contains(err.Error(), "item 3:") {
+			t.Errorf("expected the errors of items 4 and 5, got %v", err)
+		}
+		if thumbnails[0] == nil || thumbnails[0].ContentType != "image/jpeg" || string(thumbnails[0].Data) != "\xff\xd8\xff\xe0jpeg" {
+			t.Errorf("unexpected first thumbnail %+v", thumbnails[0])
+		}
+		if thumbnails[1] == nil || thumbnails[1].ContentType != "image/png" {
+			t.Errorf("expected the content type to be detected, got %+v", thumbnails[1])
+		}
+		if thumbnails[2] != nil || thumbnails[3] != nil || thumbnails[4] != nil {
+			t.Errorf("expected no thumbnails for items without one or that failed")
+		}
+	}
+
+	if _, err := FetchThumbnails(context.Background(), &CobaltResponse{Status: "tunnel"}, 0); err == nil {
+		t.Errorf("expected an error for a response that isn't a picker")
+	}
+}
+
+func TestThumbnailDataURI(t *testing.T) {
+	thumbnail := Thumbnail{Data: []byte("\xff\xd8\xff"), ContentType: "image/jpeg"}
+	if uri := thumbnail.DataURI(); uri != "data:image/jpeg;base64,/9j/" {
+		t.Errorf("unexpected data uri %q", uri)
+	}
+}
@@ -0,0 +1,431 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Deep media probing. Only the container headers (MP4 moov box or Matroska/WebM EBML elements) are read using ranged requests.
+
+const (
+	probeChunkSize  = 64 * 1024        //Bytes requested on every ranged read.
+	probeMaxBoxSize = 16 * 1024 * 1024 //moov boxes bigger than this are not fetched.
+	probeMaxReads   = 8                //Maximum ranged requests made while looking for the moov box.
+)
+
+// Matroska/WebM element ids used while probing.
+const (
+	ebmlHeaderID      = 0x1A45DFA3
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+	ebmlTracks        = 0x1654AE6B
+	ebmlTrackEntry    = 0xAE
+	ebmlTrackType     = 0x83
+	ebmlCodecID       = 0x86
+	ebmlVideo         = 0xE0
+	ebmlPixelWidth    = 0xB0
+	ebmlPixelHeight   = 0xBA
+	ebmlCluster       = 0x1F43B675
+)
+
+// Friendly names for the codec identifiers found in MP4 sample entries and Matroska CodecID elements.
+var containerCodecNames = map[string]string{
+	"avc1": "h264", "avc3": "h264", "hvc1": "h265", "hev1": "h265", "av01": "av1", "vp09": "vp9", "vp08": "vp8",
+	"mp4a": "aac", "Opus": "opus", "fLaC": "flac", ".mp3": "mp3",
+	"V_MPEG4/ISO/AVC": "h264", "V_MPEGH/ISO/HEVC": "h265", "V_AV1": "av1", "V_VP9": "vp9", "V_VP8": "vp8",
+	"A_OPUS": "opus", "A_VORBIS": "vorbis", "A_AAC": "aac", "A_FLAC": "flac", "A_MPEG/L3": "mp3",
+}
+
+var errUnknownContainer = errors.New("unknown media container")
+
+// rangeFetcher returns the bytes between start and end (inclusive). It may return less bytes than requested near the end of the file.
+type rangeFetcher func(start, end int64) ([]byte, error)
+
+// rangeFetcher reads ranges of url using rangedHttpRequest, sending header with every request.
+func (c *Cobalt) rangeFetcher(ctx context.Context, url string, header http.Header) rangeFetcher {
+	return func(start, end int64) ([]byte, error) {
+		res, err := c.rangedHttpRequest(ctx, url, header, start, end)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusOK && start != 0 {
+			return nil, errors.New("server does not support range requests")
+		}
+		return io.ReadAll(io.LimitReader(res.Body, end-start+1))
+	}
+}
+
+// probeContainer detects the container from the first bytes of the media and fills the duration, resolution, codecs and bitrate of info.
+func probeContainer(info *MediaInfo, fetch rangeFetcher) error {
+	head, err := fetch(0, probeChunkSize-1)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		err = probeMP4(info, head, fetch)
+	case len(head) >= 4 && binary.BigEndian.Uint32(head) == ebmlHeaderID:
+		err = probeEBML(info, head)
+	default:
+		err = errUnknownContainer
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Duration > 0 && info.Size > 0 {
+		info.Bitrate = int(float64(info.Size) * 8 / info.Duration.Seconds())
+	}
+	return nil
+}
+
+// codecName returns the friendly name of a container codec identifier, or the identifier itself if it's unknown.
+func codecName(id string) string {
+	if name, ok := containerCodecNames[id]; ok {
+		return name
+	}
+	return id
+}
+
+/* MP4 */
+
+// probeMP4 walks the top level boxes until moov is found, skipping over mdat with new ranged reads.
+func probeMP4(info *MediaInfo, head []byte, fetch rangeFetcher) error {
+	buf, bufStart, offset := head, int64(0), int64(0)
+
+	for reads := 0; reads < probeMaxReads; {
+		rel := offset - bufStart
+		if rel < 0 || rel+8 > int64(len(buf)) {
+			if info.Size > 0 && offset >= int64(info.Size) {
+				break
+			}
+			chunk, err := fetch(offset, offset+probeChunkSize-1)
+			reads++
+			if err != nil {
+				return err
+			}
+			if len(chunk) < 8 {
+				break
+			}
+			buf, bufStart, rel = chunk, offset, 0
+		}
+
+		size, headerLen, boxType, ok := mp4BoxHeader(buf[rel:])
+		if !ok {
+			break
+		}
+		if size == 0 {
+			//Box extends to the end of the file.
+			if info.Size == 0 {
+				size = int64(len(buf)) - rel
+			} else {
+				size = int64(info.Size) - offset
+			}
+		}
+		if size < int64(headerLen) || size > math.MaxInt64-offset {
+			break
+		}
+
+		if boxType == "moov" {
+			if size > int64(len(buf))-rel {
+				if size > probeMaxBoxSize {
+					return errors.New("moov box is too big to probe")
+				}
+				moov, err := fetch(offset, offset+size-1)
+				if err != nil {
+					return err
+				}
+				if int64(len(moov)) < size {
+					return errors.New("moov box is truncated")
+				}
+				buf, rel = moov, 0
+			}
+			parseMoov(info, buf[rel+int64(headerLen):rel+size])
+			return nil
+		}
+
+		offset += size
+	}
+
+	return errors.New("moov box not found")
+}
+
+// mp4BoxHeader parses the size and type of the box at the start of data. A size of 0 means the box extends to the end of the file.
+func mp4BoxHeader(data []byte) (size int64, headerLen int, boxType string, ok bool) {
+	if len(data) < 8 {
+		return 0, 0, "", false
+	}
+	size, headerLen, boxType = int64(binary.BigEndian.Uint32(data)), 8, string(data[4:8])
+	if size == 1 {
+		if len(data) < 16 {
+			return 0, 0, "", false
+		}
+		large := binary.BigEndian.Uint64(data[8:16])
+		if large > math.MaxInt64 {
+			return 0, 0, "", false
+		}
+		size, headerLen = int64(large), 16
+	}
+	return size, headerLen, boxType, true
+}
+
+// mp4Boxes calls fn for every complete box found in data.
+func mp4Boxes(data []byte, fn func(boxType string, payload []byte)) {
+	for len(data) >= 8 {
+		size, headerLen, boxType, ok := mp4BoxHeader(data)
+		if !ok {
+			return
+		}
+		if size == 0 {
+			size = int64(len(data))
+		}
+		if size < int64(headerLen) || size > int64(len(data)) {
+			return
+		}
+		fn(boxType, data[headerLen:size])
+		data = data[size:]
+	}
+}
+
+func parseMoov(info *MediaInfo, moov []byte) {
+	mp4Boxes(moov, func(boxType string, payload []byte) {
+		switch boxType {
+		case "mvhd":
+			info.Duration = mvhdDuration(payload)
+		case "trak":
+			parseTrak(info, payload)
+		}
+	})
+}
+
+// mvhdDuration reads the timescale and duration of the movie header, for both version 0 and 1.
+func mvhdDuration(mvhd []byte) time.Duration {
+	var timescale, duration uint64
+	switch {
+	case len(mvhd) >= 20 && mvhd[0] == 0:
+		timescale, duration = uint64(binary.BigEndian.Uint32(mvhd[12:16])), uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		timescale, duration = uint64(binary.BigEndian.Uint32(mvhd[20:24])), binary.BigEndian.Uint64(mvhd[24:32])
+	}
+	if timescale == 0 || duration == math.MaxUint32 || duration == math.MaxUint64 {
+		return 0
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+}
+
+// parseTrak reads the handler type (vide/soun), the codec from the first sample entry and the resolution from the track header.
+func parseTrak(info *MediaInfo, trak []byte) {
+	var handler, format string
+	var width, height int
+
+	mp4Boxes(trak, func(boxType string, payload []byte) {
+		switch boxType {
+		case "tkhd":
+			//Width and height are the last two 16.16 fixed point values of the track header.
+			if len(payload) >= 84 {
+				width = int(binary.BigEndian.Uint32(payload[len(payload)-8:]) >> 16)
+				height = int(binary.BigEndian.Uint32(payload[len(payload)-4:]) >> 16)
+			}
+		case "mdia":
+			mp4Boxes(payload, func(boxType string, payload []byte) {
+				switch boxType {
+				case "hdlr":
+					if len(payload) >= 12 {
+						handler = string(payload[8:12])
+					}
+				case "minf":
+					format = mp4SampleFormat(payload)
+				}
+			})
+		}
+	})
+
+	switch handler {
+	case "vide":
+		if info.VideoCodec == "" {
+			info.VideoCodec = codecName(format)
+			info.Width, info.Height = width, height
+		}
+	case "soun":
+		if info.AudioCodec == "" {
+			info.AudioCodec = codecName(format)
+		}
+	}
+}
+
+// mp4SampleFormat returns the format of the first sample entry in minf/stbl/stsd.
+func mp4SampleFormat(minf []byte) (format string) {
+	mp4Boxes(minf, func(boxType string, payload []byte) {
+		if boxType != "stbl" {
+			return
+		}
+		mp4Boxes(payload, func(boxType string, payload []byte) {
+			//stsd: version and flags, entry count, then the first entry size and format.
+			if boxType == "stsd" && len(payload) >= 16 {
+				format = string(payload[12:16])
+			}
+		})
+	})
+	return format
+}
+
+/* Matroska/WebM */
+
+// probeEBML reads the segment Info and Tracks elements, stopping at the first cluster.
+func probeEBML(info *MediaInfo, head []byte) error {
+	timecodeScale, duration := uint64(1000000), 0.0
+
+	ebmlElements(head, func(id uint64, segment []byte) bool {
+		if id != ebmlSegment {
+			return true
+		}
+		ebmlElements(segment, func(id uint64, payload []byte) bool {
+			switch id {
+			case ebmlInfo:
+				ebmlElements(payload, func(id uint64, payload []byte) bool {
+					switch id {
+					case ebmlTimecodeScale:
+						if scale := ebmlUint(payload); scale > 0 {
+							timecodeScale = scale
+						}
+					case ebmlDuration:
+						duration = ebmlFloat(payload)
+					}
+					return true
+				})
+			case ebmlTracks:
+				ebmlElements(payload, func(id uint64, payload []byte) bool {
+					if id == ebmlTrackEntry {
+						parseTrackEntry(info, payload)
+					}
+					return true
+				})
+			case ebmlCluster:
+				return false
+			}
+			return true
+		})
+		return false
+	})
+
+	if duration > 0 {
+		info.Duration = time.Duration(duration * float64(timecodeScale))
+	}
+	if info.Duration == 0 && info.VideoCodec == "" && info.AudioCodec == "" {
+		return errors.New("no segment information found")
+	}
+	return nil
+}
+
+func parseTrackEntry(info *MediaInfo, entry []byte) {
+	var trackType uint64
+	var codec string
+	var width, height int
+
+	ebmlElements(entry, func(id uint64, payload []byte) bool {
+		switch id {
+		case ebmlTrackType:
+			trackType = ebmlUint(payload)
+		case ebmlCodecID:
+			codec = strings.TrimRight(string(payload), "\x00")
+		case ebmlVideo:
+			ebmlElements(payload, func(id uint64, payload []byte) bool {
+				switch id {
+				case ebmlPixelWidth:
+					width = int(ebmlUint(payload))
+				case ebmlPixelHeight:
+					height = int(ebmlUint(payload))
+				}
+				return true
+			})
+		}
+		return true
+	})
+
+	switch trackType {
+	case 1:
+		if info.VideoCodec == "" {
+			info.VideoCodec = codecName(codec)
+			info.Width, info.Height = width, height
+		}
+	case 2:
+		if info.AudioCodec == "" {
+			info.AudioCodec = codecName(codec)
+		}
+	}
+}
+
+// ebmlVint reads a variable length integer. Element ids keep their length marker, sizes don't.
+func ebmlVint(data []byte, keepMarker bool) (value uint64, length int, ok bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length = bits.LeadingZeros8(data[0]) + 1
+	if len(data) < length {
+		return 0, 0, false
+	}
+	value = uint64(data[0])
+	if !keepMarker {
+		value &= 0xFF >> length
+	}
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, true
+}
+
+// ebmlElements calls fn for every element in data until fn returns false.
+// Elements with an unknown size, or bigger than the data available, are truncated to what's left.
+func ebmlElements(data []byte, fn func(id uint64, payload []byte) bool) {
+	for len(data) > 0 {
+		id, idLen, ok := ebmlVint(data, true)
+		if !ok {
+			return
+		}
+		size, sizeLen, ok := ebmlVint(data[idLen:], false)
+		if !ok {
+			return
+		}
+		start, end := idLen+sizeLen, len(data)
+		unknownSize := size == 1<<(7*sizeLen)-1
+		if !unknownSize && size <= uint64(end-start) {
+			end = start + int(size)
+		}
+		if !fn(id, data[start:end]) {
+			return
+		}
+		data = data[end:]
+	}
+}
+
+func ebmlUint(data []byte) uint64 {
+	if len(data) > 8 {
+		return 0
+	}
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func ebmlFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
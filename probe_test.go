@@ -0,0 +1,135 @@
+package gobalt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(box, boxType...), body...)
+}
+
+func ebmlElement(id []byte, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	element := append([]byte{}, id...)
+	element = binary.BigEndian.AppendUint64(element, uint64(len(body))|0x01<<56)
+	return append(element, body...)
+}
+
+func testMP4(t *testing.T) []byte {
+	t.Helper()
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)  //timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 90500) //duration, 90.5s
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 1920<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 1080<<16)
+	stsd := func(format string) []byte {
+		return mp4Box("stsd", []byte{0, 0, 0, 0, 0, 0, 0, 1}, mp4Box(format, make([]byte, 8)))
+	}
+	hdlr := func(handler string) []byte {
+		return mp4Box("hdlr", make([]byte, 8), []byte(handler), make([]byte, 12))
+	}
+	video := mp4Box("trak", mp4Box("tkhd", tkhd), mp4Box("mdia", hdlr("vide"), mp4Box("minf", mp4Box("stbl", stsd("avc1")))))
+	audio := mp4Box("trak", mp4Box("mdia", hdlr("soun"), mp4Box("minf", mp4Box("stbl", stsd("mp4a")))))
+
+	//moov after a big mdat, like most files that aren't "fast start".
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom")),
+		mp4Box("mdat", make([]byte, 200*1024)),
+		mp4Box("moov", mp4Box("mvhd", mvhd), video, audio),
+	}, nil)
+}
+
+func TestProbeMP4(t *testing.T) {
+	file := testMP4(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(file))
+	}))
+	defer server.Close()
+
+	info, err := ProcessMedia(server.URL + "/video.mp4")
+	if err != nil {
+		t.Fatalf("failed processing media because %v", err)
+	}
+	if info.Duration != 90500*time.Millisecond || info.Width != 1920 || info.Height != 1080 || info.VideoCodec != "h264" || info.AudioCodec != "aac" {
+		t.Fatalf("got unexpected media info: %+v", info)
+	}
+	if info.Bitrate == 0 {
+		t.Fatalf("expected bitrate to be calculated, got %+v", info)
+	}
+}
+
+func TestProbeWebM(t *testing.T) {
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(12000))
+	file := bytes.Join([][]byte{
+		ebmlElement([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebmlElement([]byte{0x42, 0x82}, []byte("webm"))),
+		ebmlElement([]byte{0x18, 0x53, 0x80, 0x67},
+			ebmlElement([]byte{0x15, 0x49, 0xA9, 0x66},
+				ebmlElement([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}),
+				ebmlElement([]byte{0x44, 0x89}, duration),
+			),
+			ebmlElement([]byte{0x16, 0x54, 0xAE, 0x6B},
+				ebmlElement([]byte{0xAE},
+					ebmlElement([]byte{0x83}, []byte{1}),
+					ebmlElement([]byte{0x86}, []byte("V_VP9")),
+					ebmlElement([]byte{0xE0}, ebmlElement([]byte{0xB0}, []byte{0x05, 0x00}), ebmlElement([]byte{0xBA}, []byte{0x02, 0xD0})),
+				),
+				ebmlElement([]byte{0xAE},
+					ebmlElement([]byte{0x83}, []byte{2}),
+					ebmlElement([]byte{0x86}, []byte("A_OPUS")),
+				),
+			),
+		),
+	}, nil)
+
+	info := &MediaInfo{}
+	err := probeContainer(info, func(start, end int64) ([]byte, error) {
+		return file[start:min(end+1, int64(len(file)))], nil
+	})
+	if err != nil {
+		t.Fatalf("failed probing webm because %v", err)
+	}
+	if info.Duration != 12*time.Second || info.Width != 1280 || info.Height != 720 || info.VideoCodec != "vp9" || info.AudioCodec != "opus" {
+		t.Fatalf("got unexpected media info: %+v", info)
+	}
+}
+
+func TestProbeGarbage(t *testing.T) {
+	for _, file := range [][]byte{nil, []byte("ftyp"), {0, 0, 0, 1, 'f', 't', 'y', 'p'}, {0x1A, 0x45, 0xDF, 0xA3, 0xFF}} {
+		info := &MediaInfo{}
+		if err := probeContainer(info, func(start, end int64) ([]byte, error) {
+			if start >= int64(len(file)) {
+				return nil, nil
+			}
+			return file[start:min(end+1, int64(len(file)))], nil
+		}); err == nil {
+			t.Fatalf("expected an error probing %v", file)
+		}
+	}
+}
+
+func FuzzProbeContainer(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 8, 'f', 't', 'y', 'p'})
+	f.Add([]byte{0, 0, 0, 1, 'm', 'o', 'o', 'v', 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	f.Add([]byte{0x1A, 0x45, 0xDF, 0xA3, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	f.Fuzz(func(t *testing.T, file []byte) {
+		probeContainer(&MediaInfo{Size: uint(len(file))}, func(start, end int64) ([]byte, error) {
+			if start < 0 || start >= int64(len(file)) {
+				return nil, nil
+			}
+			return file[start:min(max(end+1, start), int64(len(file)))], nil
+		})
+		probeContainer(&MediaInfo{}, func(start, end int64) ([]byte, error) {
+			return append([]byte{0, 0, 0, 16, 'f', 't', 'y', 'p', 0, 0, 0, 0, 0, 0, 0, 0}, file...), nil
+		})
+	})
+}
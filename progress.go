@@ -0,0 +1,99 @@
+package gobalt
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Measuring the progress and speed of a stream, for downloads and for callers reading an OpenStream themselves.
+
+// How often the speed is sampled, and the weight of the last sample in Progress.Speed.
+const (
+	speedSampleInterval = 250 * time.Millisecond
+	speedSmoothing      = 0.3
+)
+
+// ProgressReader counts the bytes read from an io.Reader and measures the speed, calling a function after every read.
+// Downloads with DownloadOptions.Progress use it, wrap a Stream with it to get the same progress:
+//
+//	stream, _ := gobalt.OpenStream(media.URL, gobalt.DownloadOptions{})
+//	reader := gobalt.NewProgressReader(stream, stream.Size, func(p gobalt.Progress) { fmt.Printf("\r%v/%v bytes", p.Written, p.Size) })
+//	defer reader.Close()
+type ProgressReader struct {
+	r        io.Reader
+	progress func(Progress)
+
+	mu          sync.Mutex
+	size        int64
+	read        int64
+	now         func() time.Time
+	start       time.Time
+	sampledAt   time.Time
+	sampled     int64 //Bytes read at sampledAt.
+	speed       float64
+	speedSample bool //If speed has a sample yet.
+	last        Progress
+}
+
+// NewProgressReader returns a ProgressReader reading from r, of size bytes (-1 if unknown, the progress is then Indeterminate).
+// progress is called after every read that returned bytes, it can be nil to only poll Progress.
+func NewProgressReader(r io.Reader, size int64, progress func(Progress)) *ProgressReader {
+	start := time.Now()
+	return &ProgressReader{r: r, progress: progress, size: size, now: time.Now, start: start, sampledAt: start, last: Progress{Size: size, ETA: -1, Indeterminate: size < 0}}
+}
+
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		progress := p.add(n)
+		if p.progress != nil {
+			p.progress(progress)
+		}
+	}
+	return n, err
+}
+
+// add counts n more bytes, sampling the speed every speedSampleInterval.
+func (p *ProgressReader) add(n int) Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.read += int64(n)
+
+	now := p.now()
+	if interval := now.Sub(p.sampledAt); interval >= speedSampleInterval {
+		sample := float64(p.read-p.sampled) / interval.Seconds()
+		if p.speedSample {
+			p.speed = speedSmoothing*sample + (1-speedSmoothing)*p.speed
+		} else {
+			p.speed, p.speedSample = sample, true
+		}
+		p.sampledAt, p.sampled = now, p.read
+	}
+
+	progress := Progress{Written: p.read, Size: p.size, Elapsed: now.Sub(p.start), Speed: p.speed, ETA: -1, Indeterminate: p.size < 0}
+	if !p.speedSample && progress.Elapsed > 0 {
+		//Until the first sample, the average since the start.
+		progress.Speed = float64(p.read) / progress.Elapsed.Seconds()
+	}
+	if p.size >= 0 && progress.Speed > 0 {
+		progress.ETA = time.Duration(float64(max(p.size-p.read, 0)) / progress.Speed * float64(time.Second))
+	}
+	p.last = progress
+	return progress
+}
+
+// Progress returns the progress after the last read. It can be called while another goroutine reads.
+func (p *ProgressReader) Progress() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+// Close closes the underlying reader if it's an io.Closer.
+func (p *ProgressReader) Close() error {
+	if closer, ok := p.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
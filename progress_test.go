@@ -0,0 +1,73 @@
+package gobalt
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProgressSpeed(t *testing.T) {
+	var events []Progress
+	source := bytes.NewReader(make([]byte, 4000))
+	reader := NewProgressReader(source, 10000, func(p Progress) { events = append(events, p) })
+	clock := reader.start
+	reader.now = func() time.Time { return clock }
+
+	//1000 bytes per second for a second, then 3000 bytes per second.
+	for _, step := range []struct {
+		after time.Duration
+		bytes int
+	}{{500 * time.Millisecond, 500}, {500 * time.Millisecond, 500}, {time.Second, 3000}} {
+		clock = clock.Add(step.after)
+		io.ReadFull(reader, make([]byte, step.bytes))
+	}
+
+	if events[0].Speed != 1000 || events[0].ETA != 9500*time.Millisecond || events[0].Elapsed != 500*time.Millisecond {
+		t.Errorf("unexpected first event %+v", events[0])
+	}
+	if last := events[2]; last.Speed != 0.3*3000+0.7*1000 || last.Written != 4000 || last.ETA != time.Duration(6000/last.Speed*float64(time.Second)) {
+		t.Errorf("unexpected smoothed event %+v", last)
+	}
+	if reader.Progress() != events[2] {
+		t.Errorf("expected Progress to return the last event, got %+v", reader.Progress())
+	}
+
+	unknown := NewProgressReader(bytes.NewReader([]byte("media")), -1, nil)
+	io.ReadAll(unknown)
+	if progress := unknown.Progress(); progress.ETA != -1 || progress.Written != 5 || !progress.Indeterminate || progress.Percent() != -1 {
+		t.Errorf("expected an indeterminate progress without size, got %+v", progress)
+	}
+	if events[2].Indeterminate || events[2].Percent() != 40 {
+		t.Errorf("expected 40%% of a known size, got %+v", events[2])
+	}
+}
+
+func TestProgressChunkedDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	var events []Progress
+	_, err := DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, io.Discard, DownloadOptions{Progress: func(p Progress) { events = append(events, p) }})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatalf("expected progress events")
+	}
+	for _, event := range events {
+		if !event.Indeterminate || event.Size != -1 || event.Percent() != -1 {
+			t.Errorf("expected indeterminate progress for a chunked response, got %+v", event)
+		}
+	}
+	if last := events[len(events)-1]; last.Written != 15 {
+		t.Errorf("expected the bytes transferred, got %v", last.Written)
+	}
+}
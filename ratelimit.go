@@ -0,0 +1,66 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Waiting for rate limits to reset. cobalt answers rate limited jobs with 429 and error.api.rate_exceeded,
+// with the seconds until the limit resets in the RateLimit-Reset header, or Retry-After from proxies in front of it.
+
+// WithRateLimitRetry makes rate limited jobs wait for the rate limit to reset and try again, waiting up to maxWait in total.
+// Jobs aren't retried if the wait would pass the deadline of the context given to RunContext. 0 or less disables it. Default: disabled.
+func WithRateLimitRetry(maxWait time.Duration) Option {
+	return func(c *Cobalt) {
+		c.maxRateLimitWait = maxWait
+	}
+}
+
+// isRateLimited reports if the instance rejected the job for going over its rate limit.
+// error.api.fetch.rate isn't included, it's the service limiting the instance.
+func isRateLimited(media *CobaltResponse) bool {
+	if media.Status != "error" {
+		return false
+	}
+	return media.httpStatus == http.StatusTooManyRequests || (media.Error != nil && media.Error.Code == "error.api.rate_exceeded")
+}
+
+// rateLimitWait returns how long to wait before retrying a rate limited job, and false if it shouldn't be retried.
+func (c *Cobalt) rateLimitWait(ctx context.Context, media *CobaltResponse, waited time.Duration) (time.Duration, bool) {
+	if c.maxRateLimitWait <= 0 {
+		return 0, false
+	}
+	wait := media.retryAfter
+	if wait < 0 && media.Error != nil && media.Error.Context.Limit > 0 {
+		//The rate limit window, the limit resets before it ends.
+		wait = time.Duration(media.Error.Context.Limit) * time.Second
+	}
+	if wait < 0 {
+		wait = time.Second
+	}
+	if waited+wait > c.maxRateLimitWait {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// retryAfter reads the wait from the Retry-After (seconds or a date) or RateLimit-Reset (seconds) headers, -1 if there's none.
+func retryAfter(header http.Header) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(time.Until(date), 0)
+		}
+	}
+	if seconds, err := strconv.Atoi(header.Get("RateLimit-Reset")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return -1
+}
@@ -0,0 +1,79 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rateLimitedServer(limited int32, headers map[string]string, body string) (*httptest.Server, *atomic.Int32) {
+	var jobs atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		if jobs.Add(1) <= limited {
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(body))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+	})), &jobs
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	rateExceeded := `{"status":"error","error":{"code":"error.api.rate_exceeded","context":{"limit":60}}}`
+
+	server, jobs := rateLimitedServer(2, map[string]string{"Retry-After": "0", "Content-Type": "application/json"}, rateExceeded)
+	defer server.Close()
+	if _, err := New(WithAPI(server.URL)).Run(settings); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited without retries, got %v", err)
+	}
+	jobs.Store(0)
+	media, err := New(WithAPI(server.URL), WithRateLimitRetry(time.Second)).Run(settings)
+	if err != nil || media.Status != "tunnel" || jobs.Load() != 3 {
+		t.Errorf("expected the job to succeed on the third try, got %+v, %v after %v jobs", media, err, jobs.Load())
+	}
+
+	//Proxies answer with their own body, and the wait doesn't fit the deadline.
+	proxy, jobs := rateLimitedServer(1, map[string]string{"RateLimit-Reset": "60", "Content-Type": "text/html"}, "<html>429 Too Many Requests</html>")
+	defer proxy.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err = New(WithAPI(proxy.URL), WithRateLimitRetry(time.Hour)).RunContext(ctx, settings)
+	var cobaltErr *CobaltError
+	if !errors.As(err, &cobaltErr) || cobaltErr.Status != http.StatusTooManyRequests || time.Since(start) > time.Second || jobs.Load() != 1 {
+		t.Errorf("expected to fail right away with a 429, got %v after %v", err, time.Since(start))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		expected time.Duration
+	}{
+		{http.Header{"Retry-After": {"120"}}, 2 * time.Minute},
+		{http.Header{"Ratelimit-Reset": {"30"}}, 30 * time.Second},
+		{http.Header{"Retry-After": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}}, 0},
+		{http.Header{"Retry-After": {"soon"}}, -1},
+		{http.Header{}, -1},
+	}
+	for _, test := range tests {
+		if wait := retryAfter(test.header); wait != test.expected {
+			t.Errorf("retryAfter(%v) = %v, expected %v", test.header, wait, test.expected)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package gobalt
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Instance list kept up to date in the background, so long running services always have instances to fail over to
+// without waiting for the trackers when a job comes in.
+
+// instancePool is the instance list cached by a Cobalt, see WithInstanceRefresh.
+type instancePool struct {
+	interval time.Duration
+	trackers []string
+
+	mu        sync.Mutex
+	instances []CobaltInstance
+	refreshed time.Time
+	err       error //Error of the last refresh, the instances of the previous one are kept.
+
+	cancel context.CancelFunc //Stops the refreshes, see Close.
+}
+
+// WithInstanceRefresh gets the instance list from trackers (DefaultTrackers if none) when the Cobalt is created and every
+// interval after that, in the background. Instances returns the last list got. Call Close to stop refreshing.
+func WithInstanceRefresh(interval time.Duration, trackers ...string) Option {
+	return func(c *Cobalt) {
+		c.pool = &instancePool{interval: interval, trackers: trackers}
+	}
+}
+
+// start refreshes the pool every interval until it's closed.
+func (p *instancePool) start(c *Cobalt) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			c.RefreshInstances(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// RefreshInstances gets the instance list from the trackers of WithInstanceRefresh (DefaultTrackers if it wasn't used)
+// now, and caches it for Instances. On errors the list cached before is kept.
+func (c *Cobalt) RefreshInstances(ctx context.Context) ([]CobaltInstance, error) {
+	var trackers []string
+	if c.pool != nil {
+		trackers = c.pool.trackers
+	}
+	instances, err := c.MergeInstanceTrackers(ctx, trackers...)
+	if c.pool == nil {
+		return instances, err
+	}
+
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	c.pool.err = err
+	if err != nil {
+		return slices.Clone(c.pool.instances), err
+	}
+	c.pool.instances, c.pool.refreshed = instances, time.Now()
+	return slices.Clone(instances), nil
+}
+
+// Instances returns the instance list cached by WithInstanceRefresh without waiting for the trackers, when it was got
+// and the error of the last refresh. The list is empty until the first refresh finishes.
+func (c *Cobalt) Instances() ([]CobaltInstance, time.Time, error) {
+	if c.pool == nil {
+		return nil, time.Time{}, nil
+	}
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	return slices.Clone(c.pool.instances), c.pool.refreshed, c.pool.err
+}
+
+// Close stops the background work of the Cobalt, like the refreshes of WithInstanceRefresh. It can be called more than once.
+func (c *Cobalt) Close() error {
+	if c.pool != nil && c.pool.cancel != nil {
+		c.pool.cancel()
+	}
+	return nil
+}
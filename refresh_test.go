@@ -0,0 +1,63 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInstanceRefresh(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if requests.Add(1) == 1 {
+			w.Write([]byte(`[{"api":"first.example.com","version":"10.5.4"}]`))
+			return
+		}
+		w.Write([]byte(`[{"api":"first.example.com","version":"10.5.4"},{"api":"second.example.com","version":"11.0.0"}]`))
+	}))
+	defer tracker.Close()
+
+	c := New(WithInstanceRefresh(20*time.Millisecond, tracker.URL))
+	defer c.Close()
+	waitFor := func(condition func([]CobaltInstance, error) bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if instances, _, err := c.Instances(); condition(instances, err) {
+				return
+			}
+		}
+		instances, _, err := c.Instances()
+		t.Fatalf("unexpected instances %+v, %v", instances, err)
+	}
+	waitFor(func(instances []CobaltInstance, err error) bool { return len(instances) == 2 && err == nil })
+
+	failing.Store(true)
+	waitFor(func(instances []CobaltInstance, err error) bool { return len(instances) == 2 && err != nil })
+
+	c.Close()
+	time.Sleep(20 * time.Millisecond)
+	failing.Store(false)
+	stopped := requests.Load()
+	time.Sleep(60 * time.Millisecond)
+	if requests.Load() != stopped {
+		t.Errorf("expected no refreshes after Close")
+	}
+
+	idle := New()
+	if instances, _, _ := idle.Instances(); instances != nil {
+		t.Errorf("expected no instances without WithInstanceRefresh")
+	}
+	defer func(trackers []string) { DefaultTrackers = trackers }(DefaultTrackers)
+	DefaultTrackers = []string{tracker.URL}
+	if instances, err := idle.RefreshInstances(context.Background()); err != nil || len(instances) != 2 {
+		t.Errorf("unexpected refresh without WithInstanceRefresh %+v, %v", instances, err)
+	}
+}
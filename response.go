@@ -0,0 +1,81 @@
+package gobalt
+
+import "slices"
+
+// Helpers to handle the different kinds of CobaltResponse without switching on Status.
+
+// IsTunnel reports if the media is streamed through the instance.
+func (media *CobaltResponse) IsTunnel() bool {
+	return media.Status == "tunnel"
+}
+
+// IsRedirect reports if the url points directly to the service.
+func (media *CobaltResponse) IsRedirect() bool {
+	return media.Status == "redirect"
+}
+
+// IsLocalProcessing reports if the client has to make the file from the Tunnel urls, see CobaltResponse.Output.
+func (media *CobaltResponse) IsLocalProcessing() bool {
+	return media.Status == "local-processing"
+}
+
+// SubtitlesURL returns the url of the subtitles file of a local-processing response, empty if it has none.
+// Subtitles of tunnel responses are embedded in the media.
+func (media *CobaltResponse) SubtitlesURL() string {
+	if !media.IsLocalProcessing() || media.Output == nil || !media.Output.Subtitles || len(media.Tunnel) < 2 {
+		return ""
+	}
+	return media.Tunnel[len(media.Tunnel)-1]
+}
+
+// IsPicker reports if the response has multiple media to pick from, see CobaltResponse.Picker.
+func (media *CobaltResponse) IsPicker() bool {
+	return media.Status == "picker"
+}
+
+// AllURLs returns every url to download: the url of a tunnel or redirect response, the Tunnel urls of a local-processing response,
+// or the url of each picker item followed by the background audio, if the picker has one.
+func (media *CobaltResponse) AllURLs() []string {
+	if media.IsLocalProcessing() {
+		return slices.Clone(media.Tunnel)
+	}
+	if !media.IsPicker() {
+		if media.URL == "" {
+			return nil
+		}
+		return []string{media.URL}
+	}
+	if media.Picker == nil {
+		return nil
+	}
+	urls := make([]string, 0, len(*media.Picker)+1)
+	for _, item := range *media.Picker {
+		urls = append(urls, item.URL)
+	}
+	if media.Audio != "" {
+		urls = append(urls, media.Audio)
+	}
+	return urls
+}
+
+// BestURL returns the url of a tunnel or redirect response or, for pickers, the url of the first video, gif or photo,
+// in this order of preference. Empty if there's no url.
+func (media *CobaltResponse) BestURL() string {
+	if !media.IsPicker() {
+		return media.URL
+	}
+	if media.Picker == nil {
+		return ""
+	}
+	for _, kind := range []PickerMediaType{Video, Gif, Photo} {
+		for _, item := range *media.Picker {
+			if item.Type == kind {
+				return item.URL
+			}
+		}
+	}
+	if len(*media.Picker) > 0 {
+		return (*media.Picker)[0].URL
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package gobalt
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestCobaltResponseHelpers(t *testing.T) {
+	var picker CobaltResponse
+	json.Unmarshal([]byte(`{"status":"picker","picker":[{"type":"photo","url":"https://example.com/1.jpg"},{"type":"video","url":"https://example.com/2.mp4"}]}`), &picker)
+	if !picker.IsPicker() || picker.IsTunnel() || picker.IsRedirect() {
+		t.Errorf("expected only IsPicker")
+	}
+	if urls := picker.AllURLs(); !slices.Equal(urls, []string{"https://example.com/1.jpg", "https://example.com/2.mp4"}) {
+		t.Errorf("unexpected picker urls %v", urls)
+	}
+	if best := picker.BestURL(); best != "https://example.com/2.mp4" {
+		t.Errorf("expected the video to be the best url, got %v", best)
+	}
+
+	tunnel := CobaltResponse{Status: "tunnel", URL: "https://instance.example/tunnel?id=1"}
+	if !tunnel.IsTunnel() || tunnel.BestURL() != tunnel.URL || !slices.Equal(tunnel.AllURLs(), []string{tunnel.URL}) {
+		t.Errorf("unexpected tunnel helpers")
+	}
+
+	empty := CobaltResponse{Status: "picker"}
+	if empty.BestURL() != "" || len(empty.AllURLs()) != 0 {
+		t.Errorf("expected no urls for an empty picker")
+	}
+}
+
+func TestPickerItemJSON(t *testing.T) {
+	var media CobaltResponse
+	json.Unmarshal([]byte(`{"status":"picker","picker":[{"type":"gif","url":"https://example.com/1.mp4","thumb":"https://example.com/1.jpg"}]}`), &media)
+	item := (*media.Picker)[0]
+	if item.Type != Gif || item.Thumb != "https://example.com/1.jpg" {
+		t.Fatalf("unexpected picker item %+v", item)
+	}
+	encoded, _ := json.Marshal(item)
+	if string(encoded) != `{"type":"gif","url":"https://example.com/1.mp4","thumb":"https://example.com/1.jpg"}` {
+		t.Errorf("unexpected encoding %s", encoded)
+	}
+}
+
+func TestPickerAudio(t *testing.T) {
+	var media CobaltResponse
+	json.Unmarshal([]byte(`{"status":"picker","audio":"https://instance.example/tunnel?id=audio","audioFilename":"tiktok_audio.mp3","picker":[{"type":"photo","url":"https://example.com/1.jpg"}]}`), &media)
+	if media.Audio != "https://instance.example/tunnel?id=audio" || media.AudioFilename != "tiktok_audio.mp3" {
+		t.Errorf("unexpected background audio %q, %q", media.Audio, media.AudioFilename)
+	}
+	if urls := media.AllURLs(); !slices.Equal(urls, []string{"https://example.com/1.jpg", "https://instance.example/tunnel?id=audio"}) {
+		t.Errorf("expected the background audio after the picker items, got %v", urls)
+	}
+	if best := media.BestURL(); best != "https://example.com/1.jpg" {
+		t.Errorf("expected a picker item to be the best url, got %v", best)
+	}
+}
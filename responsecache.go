@@ -0,0 +1,144 @@
+package gobalt
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Cache of the responses of successful jobs, so the same link requested again while its urls are still valid doesn't reach the instance.
+
+// WithResponseCache caches up to size responses for ttl, or until their urls expire if that's sooner (see CobaltResponse.ExpiresAt).
+// Responses are cached by instance, url (after NormalizeURL) and Settings. The least recently used response is dropped
+// when the cache is full. 0 or less for either disables it. Default: disabled.
+func WithResponseCache(size int, ttl time.Duration) Option {
+	return func(c *Cobalt) {
+		c.responses = newResponseCache(size, ttl)
+	}
+}
+
+type responseCache struct {
+	size  int
+	ttl   time.Duration
+	store Store //Also keeps the responses, if set. See WithStore.
+
+	mu      sync.Mutex
+	order   *list.List //Keys, the most recently used first.
+	entries map[string]*list.Element
+}
+
+type cachedResponse struct {
+	key     string
+	media   *CobaltResponse
+	expires time.Time
+}
+
+// storedResponse is cachedResponse in a Store, with the fields of the response that aren't marshaled.
+type storedResponse struct {
+	Media      *CobaltResponse `json:"media"`
+	ObtainedAt time.Time       `json:"obtainedAt"`
+	ExpiresAt  time.Time       `json:"expiresAt"`
+	Expires    time.Time       `json:"expires"`
+}
+
+func responseKey(key string) string {
+	return "gobalt/response/" + key
+}
+
+// newResponseCache returns nil, a disabled cache, if size or ttl is 0 or less.
+func newResponseCache(size int, ttl time.Duration) *responseCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &responseCache{size: size, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns a copy of the cached response for key, nil if there's none or it expired.
+func (rc *responseCache) get(key string) *CobaltResponse {
+	if rc == nil || key == "" {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	element, ok := rc.entries[key]
+	if !ok {
+		return rc.load(key)
+	}
+	cached := element.Value.(*cachedResponse)
+	if time.Now().After(cached.expires) {
+		rc.order.Remove(element)
+		delete(rc.entries, key)
+		return nil
+	}
+	rc.order.MoveToFront(element)
+	return cached.media.clone()
+}
+
+// load gets the response for key from the store, keeping it in memory. Called with the lock held.
+func (rc *responseCache) load(key string) *CobaltResponse {
+	var stored storedResponse
+	if !storeGet(rc.store, responseKey(key), &stored) || stored.Media == nil || time.Now().After(stored.Expires) {
+		return nil
+	}
+	stored.Media.ObtainedAt, stored.Media.ExpiresAt = stored.ObtainedAt, stored.ExpiresAt
+	rc.add(&cachedResponse{key: key, media: stored.Media, expires: stored.Expires})
+	return stored.Media.clone()
+}
+
+func (rc *responseCache) set(key string, media *CobaltResponse) {
+	if rc == nil || key == "" {
+		return
+	}
+	expires := time.Now().Add(rc.ttl)
+	if !media.ExpiresAt.IsZero() && media.ExpiresAt.Before(expires) {
+		expires = media.ExpiresAt
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.add(&cachedResponse{key: key, media: media.clone(), expires: expires})
+	storeSet(rc.store, responseKey(key), storedResponse{Media: media, ObtainedAt: media.ObtainedAt, ExpiresAt: media.ExpiresAt, Expires: expires}, time.Until(expires))
+}
+
+// add keeps the response in memory, dropping the least recently used one if the cache is full. Called with the lock held.
+func (rc *responseCache) add(cached *cachedResponse) {
+	if element, ok := rc.entries[cached.key]; ok {
+		rc.order.Remove(element)
+	}
+	rc.entries[cached.key] = rc.order.PushFront(cached)
+	for rc.order.Len() > rc.size {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+func (rc *responseCache) forget(key string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if element, ok := rc.entries[key]; ok {
+		rc.order.Remove(element)
+		delete(rc.entries, key)
+	}
+	if rc.store != nil {
+		rc.store.Delete(responseKey(key))
+	}
+}
+
+// clone copies the response, with its own picker.
+func (media *CobaltResponse) clone() *CobaltResponse {
+	copied := *media
+	if media.Picker != nil {
+		picker := append([]PickerItem(nil), *media.Picker...)
+		copied.Picker = &picker
+	}
+	copied.Tunnel = slices.Clone(media.Tunnel)
+	if media.Output != nil {
+		output := *media.Output
+		copied.Output = &output
+	}
+	return &copied
+}
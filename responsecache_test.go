@@ -0,0 +1,66 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	var jobs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		jobs.Add(1)
+		fmt.Fprintf(w, `{"status":"tunnel","url":"https://cobalt.example/tunnel?id=%v&exp=%v","filename":"file.mp4"}`, jobs.Load(), time.Now().Add(time.Minute).UnixMilli())
+	}))
+	defer server.Close()
+	client := New(WithAPI(server.URL), WithResponseCache(1, time.Hour))
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	other := settings
+	other.Url = "https://www.youtube.com/watch?v=jNQXAC9IVRw"
+
+	first, err := client.Run(settings)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	first.URL = "changed by the caller"
+	cached, err := client.Run(settings)
+	if err != nil || jobs.Load() != 1 || cached.URL == first.URL {
+		t.Errorf("expected a copy of the cached response, got %+v, %v after %v jobs", cached, err, jobs.Load())
+	}
+
+	//The cache holds one response, the first one is dropped.
+	client.Run(other)
+	client.Run(settings)
+	if jobs.Load() != 3 {
+		t.Errorf("expected the least recently used response to be dropped, got %v jobs", jobs.Load())
+	}
+
+	if err := cached.Refresh(context.Background()); err != nil || jobs.Load() != 4 {
+		t.Errorf("expected Refresh to skip the cache, got %v after %v jobs", err, jobs.Load())
+	}
+}
+
+func TestResponseCacheExpiry(t *testing.T) {
+	cache := newResponseCache(10, time.Hour)
+	cache.set("fresh", &CobaltResponse{Status: "tunnel", ExpiresAt: time.Now().Add(time.Minute)})
+	cache.set("expired", &CobaltResponse{Status: "tunnel", ExpiresAt: time.Now().Add(-time.Second)})
+	if cache.get("fresh") == nil {
+		t.Error("expected the fresh response to be cached")
+	}
+	if cache.get("expired") != nil {
+		t.Error("expected responses with expired urls not to be returned")
+	}
+	if newResponseCache(0, time.Hour) != nil || newResponseCache(10, 0) != nil {
+		t.Error("expected the cache to be disabled")
+	}
+}
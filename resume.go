@@ -0,0 +1,175 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Resuming downloads interrupted by network errors or stalled with Range requests, from the last byte received.
+
+// Downloads are resumed up to this many times when DownloadOptions.Retries is 0.
+const defaultStreamRetries = 3
+
+// ErrStreamStalled is wrapped by the read error of a stream that received nothing for DownloadOptions.StallTimeout and couldn't be resumed.
+var ErrStreamStalled = errors.New("stream stalled")
+
+// Wait before resuming, multiplied by the attempt number.
+var resumeBackoff = 500 * time.Millisecond
+
+// resumingReader reads the body of a download. When reading fails, or stalls for longer than stall, it requests the rest of the file
+// with a Range request, up to retries times, and continues from there.
+type resumingReader struct {
+	ctx       context.Context
+	cancel    context.CancelFunc //Cancels the request of body, dropping its connection.
+	client    *http.Client
+	url       string
+	userAgent string
+	validator string //ETag or Last-Modified of the first response, so a resumed request fails if the file changed.
+	body      io.ReadCloser
+	offset    int64 //Bytes read so far.
+	retries   int
+
+	stall   time.Duration //Reads waiting longer than this cancel the request, 0 waits forever.
+	stalled atomic.Bool   //The request was canceled by a stalled read.
+}
+
+// resumable wraps the body of response in a resumingReader. cancel cancels the request of response, it's called on Close.
+// The reader doesn't resume if retries is negative or the server doesn't support ranges, but it still detects stalls.
+func resumable(ctx context.Context, cancel context.CancelFunc, client *http.Client, userAgent string, response *http.Response, options DownloadOptions) io.ReadCloser {
+	retries := options.Retries
+	if retries == 0 {
+		retries = defaultStreamRetries
+	}
+	if retries < 0 || response.Header.Get("Accept-Ranges") == "none" {
+		retries = 0
+	}
+	validator := response.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		//Weak ETags can't be used in If-Range.
+		validator = response.Header.Get("Last-Modified")
+	}
+	return &resumingReader{
+		ctx:       ctx,
+		cancel:    cancel,
+		client:    client,
+		url:       response.Request.URL.String(),
+		userAgent: userAgent,
+		validator: validator,
+		body:      response.Body,
+		retries:   retries,
+		stall:     options.StallTimeout,
+	}
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	var watchdog *time.Timer
+	if r.stall > 0 {
+		cancel := r.cancel
+		watchdog = time.AfterFunc(r.stall, func() {
+			r.stalled.Store(true)
+			cancel()
+		})
+	}
+	n, err := r.body.Read(p)
+	if watchdog != nil {
+		watchdog.Stop()
+	}
+	r.offset += int64(n)
+	if err == nil || err == io.EOF || r.ctx.Err() != nil {
+		return n, err
+	}
+	if r.stalled.Swap(false) {
+		err = fmt.Errorf("%w: nothing received for %v", ErrStreamStalled, r.stall)
+	}
+	if resumeErr := r.resume(); resumeErr != nil {
+		return n, fmt.Errorf("%w, resuming failed: %v", err, resumeErr)
+	}
+	return n, nil
+}
+
+// resume replaces the body with the rest of the file, trying until a request works or the retries run out.
+func (r *resumingReader) resume() error {
+	r.body.Close()
+	r.cancel()
+	r.body = http.NoBody
+	if r.retries == 0 {
+		return errors.New("no retries left, or the server doesn't support ranges")
+	}
+	var err error
+	for attempt := 1; r.retries > 0; attempt++ {
+		r.retries--
+		select {
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		case <-time.After(time.Duration(attempt) * resumeBackoff):
+		}
+		var body io.ReadCloser
+		if body, err = r.request(); err == nil {
+			r.body = body
+			return nil
+		}
+	}
+	return err
+}
+
+// request fetches the file from offset, failing unless the server returns exactly that range of the same file.
+func (r *resumingReader) request() (body io.ReadCloser, err error) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer func() {
+		if err != nil {
+			cancel()
+		} else {
+			r.cancel = cancel
+		}
+	}()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", r.userAgent)
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	if r.validator != "" {
+		request.Header.Set("If-Range", r.validator)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusPartialContent {
+		response.Body.Close()
+		return nil, fmt.Errorf("range request returned %v", response.Status)
+	}
+	if start, ok := contentRangeStart(response.Header.Get("Content-Range")); !ok || start != r.offset {
+		response.Body.Close()
+		return nil, errors.New("range request returned another range")
+	}
+	return response.Body, nil
+}
+
+func (r *resumingReader) Close() error {
+	err := r.body.Close()
+	r.cancel()
+	return err
+}
+
+// contentRangeStart returns the first byte of a Content-Range header, like 100 for "bytes 100-199/200".
+func contentRangeStart(contentRange string) (int64, bool) {
+	bytesRange, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(bytesRange, "-")
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(start, 10, 64)
+	return offset, err == nil
+}
@@ -0,0 +1,130 @@
+package gobalt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer serves content, cutting the connection after half of it the first failures times it's requested without a range.
+func flakyServer(t *testing.T, content []byte, failures int32, ranges bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := requests.Add(1)
+		if r.Header.Get("Range") == "" && count <= failures {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Header().Set("ETag", `"v1"`)
+			w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if !ranges {
+			w.Write(content)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestStreamResume(t *testing.T) {
+	defer func(backoff time.Duration) { resumeBackoff = backoff }(resumeBackoff)
+	resumeBackoff = time.Millisecond
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	server, requests := flakyServer(t, content, 1, true)
+	var buffer bytes.Buffer
+	result, err := DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, &buffer, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("expected the download to resume, got %v", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), content) || result.Size != int64(len(content)) || requests.Load() != 2 {
+		t.Errorf("unexpected download of %v bytes after %v requests", buffer.Len(), requests.Load())
+	}
+
+	server, _ = flakyServer(t, content, 1, true)
+	if _, err := DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, io.Discard, DownloadOptions{Retries: -1}); err == nil {
+		t.Error("expected an error with resuming disabled")
+	}
+
+	server, requests = flakyServer(t, content, 1, false)
+	if _, err := DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, io.Discard, DownloadOptions{Retries: 2}); err == nil {
+		t.Error("expected an error when the server ignores ranges")
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 2 retries, got %v requests", requests.Load())
+	}
+}
+
+func TestStreamResumeCanceled(t *testing.T) {
+	defer func(backoff time.Duration) { resumeBackoff = backoff }(resumeBackoff)
+	resumeBackoff = time.Hour
+	server, _ := flakyServer(t, bytes.Repeat([]byte("x"), 10000), 1, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := DownloadToContext(ctx, &CobaltResponse{Status: "tunnel", URL: server.URL}, io.Discard, DownloadOptions{}); err != context.DeadlineExceeded {
+		t.Errorf("expected the context error while waiting to resume, got %v", err)
+	}
+}
+
+func TestStreamStallReconnects(t *testing.T) {
+	defer func(backoff time.Duration) { resumeBackoff = backoff }(resumeBackoff)
+	resumeBackoff = time.Millisecond
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	stallingServer := func(ranges bool) (*httptest.Server, *atomic.Int32) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.Header.Get("Range") == "" {
+				if !ranges {
+					w.Header().Set("Accept-Ranges", "none")
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.Write(content[:len(content)/2])
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+				return
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	server, requests := stallingServer(true)
+	var buffer bytes.Buffer
+	_, err := DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, &buffer, DownloadOptions{StallTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected the stalled stream to reconnect, got %v", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), content) || requests.Load() != 2 {
+		t.Errorf("unexpected download of %v bytes after %v requests", buffer.Len(), requests.Load())
+	}
+
+	server, _ = stallingServer(false)
+	_, err = DownloadTo(&CobaltResponse{Status: "tunnel", URL: server.URL}, io.Discard, DownloadOptions{StallTimeout: 50 * time.Millisecond})
+	if !errors.Is(err, ErrStreamStalled) {
+		t.Errorf("expected ErrStreamStalled without range support, got %v", err)
+	}
+}
+
+func TestContentRangeStart(t *testing.T) {
+	if start, ok := contentRangeStart("bytes 100-199/200"); !ok || start != 100 {
+		t.Errorf("unexpected start %v, %v", start, ok)
+	}
+	if _, ok := contentRangeStart("bytes */200"); ok {
+		t.Error("expected an unsatisfied range to be invalid")
+	}
+}
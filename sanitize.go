@@ -0,0 +1,117 @@
+package gobalt
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Filename sanitization, so filenames returned by cobalt can be safely used on any OS.
+
+type replaceStrategy string
+
+const (
+	ReplaceUnderscore replaceStrategy = "underscore" //Invalid characters are replaced by "_". Default.
+	ReplaceRemove     replaceStrategy = "remove"     //Invalid characters are removed.
+	ReplaceLookalike  replaceStrategy = "lookalike"  //Invalid characters are replaced by their full width unicode lookalikes (like ： and ？), control characters are removed.
+)
+
+// SanitizeOptions changes how SanitizeFilename cleans filenames. The zero value uses the rules of the current OS.
+type SanitizeOptions struct {
+	GOOS      string          //Rules to follow, like "windows", "darwin" or "linux". Default: runtime.GOOS.
+	Strategy  replaceStrategy //What to do with invalid characters. Default: ReplaceUnderscore.
+	MaxLength int             //Maximum filename length, in bytes (UTF-16 units on windows). The extension is kept when truncating. Default: 255.
+}
+
+// Characters that can't be used in filenames, per OS. Control characters are always invalid.
+var invalidFilenameChars = map[string]string{
+	"windows": `<>:"/\|?*`,
+	"darwin":  `/:`,
+	"ios":     `/:`,
+}
+
+var filenameLookalikes = map[rune]rune{
+	'<': '＜', '>': '＞', ':': '：', '"': '＂', '/': '／', '\\': '＼', '|': '｜', '?': '？', '*': '＊',
+}
+
+// Names windows reserves for devices, even with an extension (like "con.mp4").
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename makes name safe to use as a filename on the OS in options: invalid and control characters are replaced,
+// windows reserved names and trailing dots/spaces are fixed, and long names are truncated keeping the extension.
+func SanitizeFilename(name string, options SanitizeOptions) string {
+	goos := options.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	maxLength := options.MaxLength
+	if maxLength <= 0 {
+		maxLength = 255
+	}
+	invalid, ok := invalidFilenameChars[goos]
+	if !ok {
+		invalid = "/"
+	}
+
+	var clean strings.Builder
+	for _, r := range strings.ToValidUTF8(name, "_") {
+		control := r < 0x20 || r == 0x7F
+		if !control && !strings.ContainsRune(invalid, r) {
+			clean.WriteRune(r)
+			continue
+		}
+		switch options.Strategy {
+		case ReplaceRemove:
+		case ReplaceLookalike:
+			if lookalike, ok := filenameLookalikes[r]; ok && !control {
+				clean.WriteRune(lookalike)
+			}
+		default:
+			clean.WriteByte('_')
+		}
+	}
+	name = strings.TrimSpace(clean.String())
+
+	if goos == "windows" {
+		name = strings.TrimRight(name, ". ")
+		base, _, _ := strings.Cut(name, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimSpace(base))] {
+			name = "_" + name
+		}
+	}
+	if name == "" || name == "." || name == ".." {
+		name = "download"
+	}
+
+	return truncateFilename(name, maxLength, goos == "windows")
+}
+
+// truncateFilename shortens the name (not the extension) until it fits maxLength, without breaking UTF-8 characters.
+func truncateFilename(name string, maxLength int, utf16Units bool) string {
+	length := func(s string) int {
+		if utf16Units {
+			return len(utf16.Encode([]rune(s)))
+		}
+		return len(s)
+	}
+	if length(name) <= maxLength {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	if length(ext) >= maxLength || strings.ContainsAny(ext, " ") {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+	for base != "" && length(base)+length(ext) > maxLength {
+		_, size := utf8.DecodeLastRuneInString(base)
+		base = base[:len(base)-size]
+	}
+	return strings.TrimRight(base, ". ") + ext
+}
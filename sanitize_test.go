@@ -0,0 +1,38 @@
+package gobalt
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  SanitizeOptions
+		expected string
+	}{
+		{`What? A "video": part 1/2 <live>.mp4`, SanitizeOptions{GOOS: "windows"}, `What_ A _video__ part 1_2 _live_.mp4`},
+		{`What? A "video": part 1/2 <live>.mp4`, SanitizeOptions{GOOS: "linux"}, `What? A "video": part 1_2 <live>.mp4`},
+		{`What? A "video": part 1/2.mp4`, SanitizeOptions{GOOS: "darwin", Strategy: ReplaceRemove}, `What? A "video" part 12.mp4`},
+		{`a|b*c?.mp3`, SanitizeOptions{GOOS: "windows", Strategy: ReplaceLookalike}, `a｜b＊c？.mp3`},
+		{"tab\there\x00.mp3", SanitizeOptions{GOOS: "linux"}, "tab_here_.mp3"},
+		{"con.mp4", SanitizeOptions{GOOS: "windows"}, "_con.mp4"},
+		{"Title... ", SanitizeOptions{GOOS: "windows"}, "Title"},
+		{"..", SanitizeOptions{GOOS: "linux"}, "download"},
+		{"///", SanitizeOptions{GOOS: "linux", Strategy: ReplaceRemove}, "download"},
+	}
+	for _, test := range tests {
+		if got := SanitizeFilename(test.name, test.options); got != test.expected {
+			t.Errorf("SanitizeFilename(%q, %+v) = %q, expected %q", test.name, test.options, got, test.expected)
+		}
+	}
+}
+
+func TestSanitizeFilenameLength(t *testing.T) {
+	long := strings.Repeat("ação ", 100) + ".opus"
+	got := SanitizeFilename(long, SanitizeOptions{GOOS: "linux", MaxLength: 100})
+	if len(got) > 100 || !strings.HasSuffix(got, ".opus") || !utf8.ValidString(got) {
+		t.Fatalf("got badly truncated filename %q (%v bytes)", got, len(got))
+	}
+}
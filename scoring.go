@@ -0,0 +1,191 @@
+package gobalt
+
+import (
+	"cmp"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/go-version"
+)
+
+// Ranking instances by the criteria of the application, instead of only filtering them by version.
+
+// Candidate is an instance being ranked, with what's known about it.
+type Candidate struct {
+	CobaltInstance
+	Latency time.Duration //Time the instance took to answer the server info request, 0 if not measured, -1 if it didn't answer.
+}
+
+// Uptime returns for how long the instance has been running, from its start time. 0 if unknown.
+func (c Candidate) Uptime() time.Duration {
+	if c.StartTime <= 0 {
+		return 0
+	}
+	return time.Since(time.UnixMilli(c.StartTime))
+}
+
+// apiURL returns the url of the api of the instance. Trackers list the api without its scheme.
+func (c Candidate) apiURL() string {
+	if strings.Contains(c.API, "://") {
+		return c.API
+	}
+	return cmp.Or(c.Protocol, "https") + "://" + c.API
+}
+
+// ServiceCount returns how many services the tracker reported as working on the instance.
+func (c Candidate) ServiceCount() int {
+	services := reflect.ValueOf(c.Services)
+	count := 0
+	for i := 0; i < services.NumField(); i++ {
+		if services.Field(i).Bool() {
+			count++
+		}
+	}
+	return count
+}
+
+// Scorer scores instances for RankInstances, higher is better. A negative score excludes the instance.
+type Scorer interface {
+	Score(candidate Candidate) float64
+}
+
+// ScorerFunc is a function implementing Scorer.
+type ScorerFunc func(candidate Candidate) float64
+
+func (f ScorerFunc) Score(candidate Candidate) float64 {
+	return f(candidate)
+}
+
+// DefaultScorer excludes instances older than 10.0.0, offline or unreachable. The others score from 0 to 1, mostly by the
+// services working, then latency, trust and uptime.
+var DefaultScorer Scorer = ScorerFunc(defaultScore)
+
+func defaultScore(candidate Candidate) float64 {
+	if !version.Compare(candidate.Version, "10.0.0", ">=") || !candidate.APIOnline || candidate.Latency < 0 {
+		return -1
+	}
+
+	total := reflect.TypeOf(Services{}).NumField()
+	score := 0.5 * float64(candidate.ServiceCount()) / float64(total)
+	if candidate.Latency > 0 {
+		//Half of the latency points at 500ms.
+		score += 0.25 / (1 + candidate.Latency.Seconds()/0.5)
+	} else {
+		score += 0.125
+	}
+	if candidate.TrustLevel() == Trusted {
+		score += 0.15
+	}
+	score += 0.1 * min(candidate.Uptime().Hours()/(7*24), 1)
+	return score
+}
+
+type trustLevel int
+
+// Trust levels of instances, from the Trust field reported by the trackers. Ordered, so they can be used as a minimum.
+const (
+	Untrusted    trustLevel = iota //Marked untrusted. As a minimum level, every instance is allowed.
+	UnknownTrust                   //No trust reported, or "unknown".
+	Trusted                        //Marked with any other trust, like "trusted".
+)
+
+// TrustLevel returns the trust level of the instance from its Trust field.
+func (i CobaltInstance) TrustLevel() trustLevel {
+	switch strings.ToLower(i.Trust) {
+	case "untrusted":
+		return Untrusted
+	case "", "unknown":
+		return UnknownTrust
+	}
+	return Trusted
+}
+
+// FilterByTrust returns the instances with at least the minimum trust level, like FilterByTrust(instances, Trusted).
+func FilterByTrust(instances []CobaltInstance, minimum trustLevel) []CobaltInstance {
+	var filtered []CobaltInstance
+	for _, instance := range instances {
+		if instance.TrustLevel() >= minimum {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered
+}
+
+// MinimumTrust wraps a scorer so RankInstances excludes the instances below the minimum trust level.
+// DefaultScorer is wrapped if scorer is nil.
+func MinimumTrust(scorer Scorer, minimum trustLevel) Scorer {
+	if scorer == nil {
+		scorer = DefaultScorer
+	}
+	return ScorerFunc(func(candidate Candidate) float64 {
+		if candidate.TrustLevel() < minimum {
+			return -1
+		}
+		return scorer.Score(candidate)
+	})
+}
+
+// RankInstances returns the candidates sorted by score, best first, without the ones scored negative. DefaultScorer if scorer is nil.
+func RankInstances(candidates []Candidate, scorer Scorer) []Candidate {
+	if scorer == nil {
+		scorer = DefaultScorer
+	}
+	type scored struct {
+		candidate Candidate
+		score     float64
+	}
+	var ranked []scored
+	for _, candidate := range candidates {
+		if score := scorer.Score(candidate); score >= 0 {
+			ranked = append(ranked, scored{candidate, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	result := make([]Candidate, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.candidate
+	}
+	return result
+}
+
+// MeasureLatency measures the latency of every instance concurrently, see the method.
+func MeasureLatency(ctx context.Context, instances []CobaltInstance) []Candidate {
+	return defaultCobalt().MeasureLatency(ctx, instances)
+}
+
+// MeasureLatency makes a server info request to every instance concurrently and returns them as candidates with their latency,
+// -1 for the instances that didn't answer before the health timeout or ctx is done.
+func (c *Cobalt) MeasureLatency(ctx context.Context, instances []CobaltInstance) []Candidate {
+	candidates := make([]Candidate, len(instances))
+	var wg sync.WaitGroup
+	for i, instance := range instances {
+		candidates[i] = Candidate{CobaltInstance: instance, Latency: -1}
+		wg.Add(1)
+		go func(candidate *Candidate) {
+			defer wg.Done()
+			done := make(chan error, 1)
+			start := time.Now()
+			api := candidate.apiURL()
+			go func() {
+				_, err := c.serverInfo(api)
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err == nil {
+					candidate.Latency = max(time.Since(start), time.Nanosecond)
+				}
+			case <-ctx.Done():
+			}
+		}(&candidates[i])
+	}
+	wg.Wait()
+	return candidates
+}
@@ -0,0 +1,82 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRankInstances(t *testing.T) {
+	candidates := []Candidate{
+		{CobaltInstance: CobaltInstance{API: "old", Version: "7.15.0", APIOnline: true, Services: Services{Youtube: true}}},
+		{CobaltInstance: CobaltInstance{API: "few-services", Version: "10.5.4", APIOnline: true, Services: Services{Youtube: true}}, Latency: 100 * time.Millisecond},
+		{CobaltInstance: CobaltInstance{API: "offline", Version: "10.5.4", Services: Services{Youtube: true, Tiktok: true}}},
+		{CobaltInstance: CobaltInstance{API: "best", Version: "11.0.0", APIOnline: true, Trust: "trusted", Services: Services{Youtube: true, Tiktok: true, Twitter: true}}, Latency: 100 * time.Millisecond},
+		{CobaltInstance: CobaltInstance{API: "unreachable", Version: "11.0.0", APIOnline: true, Services: Services{Youtube: true}}, Latency: -1},
+	}
+	ranked := RankInstances(candidates, nil)
+	if len(ranked) != 2 || ranked[0].API != "best" || ranked[1].API != "few-services" {
+		t.Errorf("unexpected default ranking %+v", ranked)
+	}
+
+	onlyTikTok := ScorerFunc(func(candidate Candidate) float64 {
+		if !candidate.Services.Tiktok {
+			return -1
+		}
+		return float64(candidate.ServiceCount())
+	})
+	ranked = RankInstances(candidates, onlyTikTok)
+	if len(ranked) != 2 || ranked[0].API != "best" || ranked[1].API != "offline" {
+		t.Errorf("unexpected custom ranking %+v", ranked)
+	}
+}
+
+func TestMeasureLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+	}))
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	candidates := MeasureLatency(context.Background(), []CobaltInstance{
+		{API: strings.TrimPrefix(server.URL, "http://"), Protocol: "http"},
+		{API: down.URL},
+	})
+	if candidates[0].Latency <= 0 || candidates[1].Latency != -1 {
+		t.Errorf("unexpected latencies %v, %v", candidates[0].Latency, candidates[1].Latency)
+	}
+}
+
+func TestTrustFilter(t *testing.T) {
+	instances := []CobaltInstance{
+		{API: "trusted", Version: "10.5.4", APIOnline: true, Trust: "Trusted"},
+		{API: "unknown", Version: "10.5.4", APIOnline: true, Trust: "unknown"},
+		{API: "empty", Version: "10.5.4", APIOnline: true},
+		{API: "untrusted", Version: "10.5.4", APIOnline: true, Trust: "untrusted"},
+	}
+	if filtered := FilterByTrust(instances, Trusted); len(filtered) != 1 || filtered[0].API != "trusted" {
+		t.Errorf("expected only the trusted instance, got %+v", filtered)
+	}
+	if filtered := FilterByTrust(instances, UnknownTrust); len(filtered) != 3 {
+		t.Errorf("expected every instance but the untrusted one, got %+v", filtered)
+	}
+	if filtered := FilterByTrust(instances, Untrusted); len(filtered) != 4 {
+		t.Errorf("expected every instance, got %+v", filtered)
+	}
+
+	candidates := make([]Candidate, len(instances))
+	for i, instance := range instances {
+		candidates[i] = Candidate{CobaltInstance: instance}
+	}
+	if ranked := RankInstances(candidates, MinimumTrust(nil, Trusted)); len(ranked) != 1 || ranked[0].API != "trusted" {
+		t.Errorf("expected only the trusted instance to be ranked, got %+v", ranked)
+	}
+	if ranked := RankInstances(candidates, MinimumTrust(nil, UnknownTrust)); len(ranked) != 3 || ranked[0].API != "trusted" {
+		t.Errorf("unexpected ranking %+v", ranked)
+	}
+}
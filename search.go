@@ -0,0 +1,214 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YouTube search, with the filters of the YouTube web app, to find media to download.
+
+// youtubeSearchAPI is the search endpoint of the YouTube web client.
+var youtubeSearchAPI = "https://www.youtube.com/youtubei/v1/search?prettyPrint=false"
+
+type searchType int
+
+const (
+	AnyType         searchType = iota //Videos, playlists and channels.
+	VideoResults                      //Only videos.
+	ChannelResults                    //Only channels.
+	PlaylistResults                   //Only playlists.
+)
+
+type searchDuration int
+
+const (
+	AnyDuration    searchDuration = iota
+	ShortDuration                 //Under 4 minutes.
+	LongDuration                  //Over 20 minutes.
+	MediumDuration                //From 4 to 20 minutes.
+)
+
+type uploadDate int
+
+const (
+	AnyTime   uploadDate = iota
+	LastHour             //Uploaded in the last hour.
+	Today                //Uploaded today.
+	ThisWeek             //Uploaded this week.
+	ThisMonth            //Uploaded this month.
+	ThisYear             //Uploaded this year.
+)
+
+type searchSort int
+
+const (
+	Relevance  searchSort = iota //Default.
+	Rating                       //Highest rated first.
+	UploadDate                   //Newest first.
+	ViewCount                    //Most viewed first.
+)
+
+// SearchFilters are the filters of a YouTube search. The zero value doesn't filter anything.
+// The numbers of the constants are the ones YouTube uses, don't change them.
+type SearchFilters struct {
+	Type     searchType     //Kind of results, like VideoResults. Default: AnyType.
+	Duration searchDuration //Duration of the videos, like ShortDuration. Default: AnyDuration.
+	Uploaded uploadDate     //When the videos were uploaded, like ThisWeek. Default: AnyTime.
+	Sort     searchSort     //Order of the results, like ViewCount. Default: Relevance.
+}
+
+// Encode returns the filters as the "sp" parameter of YouTube search urls, a base64 encoded protobuf message.
+// Empty if there are no filters.
+func (f SearchFilters) Encode() string {
+	var filters []byte
+	for _, field := range []struct {
+		number byte
+		value  int
+	}{{1, int(f.Uploaded)}, {2, int(f.Type)}, {3, int(f.Duration)}} {
+		if field.value != 0 {
+			filters = append(filters, field.number<<3, byte(field.value))
+		}
+	}
+
+	var message []byte
+	if f.Sort != Relevance {
+		message = append(message, 1<<3, byte(f.Sort))
+	}
+	if len(filters) > 0 {
+		message = append(message, 2<<3|2, byte(len(filters)))
+		message = append(message, filters...)
+	}
+	if len(message) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(message)
+}
+
+// SearchResult is a video, playlist or channel found by Search.
+type SearchResult struct {
+	Type      searchType    //VideoResults, PlaylistResults or ChannelResults.
+	ID        string        //Video, playlist or channel id.
+	URL       string        //Url of the video, playlist or channel.
+	Title     string        //Title of the video or playlist, or name of the channel.
+	Author    string        //Channel of the video or playlist.
+	Duration  time.Duration //Duration of the video, 0 for playlists, channels and live streams.
+	Thumbnail string        //Url of the thumbnail. For videos the hqdefault one, see BestThumbnail for bigger ones.
+}
+
+// searchItem is a search result as the web client sends it, see musicWalk.
+type searchItem struct {
+	kind string
+	data map[string]any
+}
+
+// Search searches YouTube, see the method.
+func Search(ctx context.Context, query string, filters SearchFilters) ([]SearchResult, error) {
+	return defaultCobalt().Search(ctx, query, filters)
+}
+
+// Search returns the first page of results of a YouTube search using the http client of this Cobalt.
+// Use SearchResults to get more than the first page.
+func (c *Cobalt) Search(ctx context.Context, query string, filters SearchFilters) ([]SearchResult, error) {
+	for page, err := range c.searchPages(ctx, query, filters) {
+		if err != nil {
+			return nil, err
+		}
+		return page, nil
+	}
+	return nil, nil
+}
+
+// SearchResults returns an iterator over the results of a YouTube search, see the method.
+func SearchResults(ctx context.Context, query string, filters SearchFilters) iter.Seq2[SearchResult, error] {
+	return defaultCobalt().SearchResults(ctx, query, filters)
+}
+
+// SearchResults returns an iterator over the results of a YouTube search, requesting the next page with its continuation token
+// only when the results of the previous one are used up. Stop the loop to stop requesting pages. Iteration ends after the first error.
+func (c *Cobalt) SearchResults(ctx context.Context, query string, filters SearchFilters) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		for page, err := range c.searchPages(ctx, query, filters) {
+			if err != nil {
+				yield(SearchResult{}, err)
+				return
+			}
+			for _, result := range page {
+				if !yield(result, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// searchPages returns an iterator over the pages of results of a YouTube search.
+func (c *Cobalt) searchPages(ctx context.Context, query string, filters SearchFilters) iter.Seq2[[]SearchResult, error] {
+	return func(yield func([]SearchResult, error) bool) {
+		if strings.TrimSpace(query) == "" {
+			yield(nil, errors.New("empty search query"))
+			return
+		}
+		request := map[string]any{"query": query}
+		if params := filters.Encode(); params != "" {
+			request["params"] = params
+		}
+		for walked, err := range c.innertubePages(ctx, youtubeClient, youtubeSearchAPI, request) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			results := make([]SearchResult, 0, len(walked.results))
+			for _, item := range walked.results {
+				if result, ok := searchResult(item); ok {
+					results = append(results, result)
+				}
+			}
+			if !yield(results, nil) {
+				return
+			}
+		}
+	}
+}
+
+// searchResult reads a videoRenderer, playlistRenderer or channelRenderer.
+func searchResult(item searchItem) (SearchResult, bool) {
+	var result SearchResult
+	switch item.kind {
+	case "videoRenderer":
+		result = SearchResult{Type: VideoResults, ID: musicString(item.data, "videoId"), Author: musicText(item.data["ownerText"])}
+		result.URL = "https://www.youtube.com/watch?v=" + result.ID
+		result.Duration = parseClockDuration(musicString(item.data["lengthText"], "simpleText"))
+		result.Thumbnail = videoThumbnail(result.ID)
+	case "playlistRenderer":
+		result = SearchResult{Type: PlaylistResults, ID: musicString(item.data, "playlistId"), Author: musicText(item.data["shortBylineText"])}
+		result.URL = "https://www.youtube.com/playlist?list=" + result.ID
+	case "channelRenderer":
+		result = SearchResult{Type: ChannelResults, ID: musicString(item.data, "channelId")}
+		result.URL = "https://www.youtube.com/channel/" + result.ID
+	}
+	if result.Title = musicString(item.data["title"], "simpleText"); result.Title == "" {
+		result.Title = musicText(item.data["title"])
+	}
+	if result.Thumbnail == "" {
+		result.Thumbnail = rendererThumbnail(item.data)
+	}
+	return result, result.ID != ""
+}
+
+// parseClockDuration parses durations like 3:32 or 1:02:03, 0 if it can't.
+func parseClockDuration(clock string) time.Duration {
+	var seconds int
+	for _, part := range strings.Split(clock, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds) * time.Second
+}
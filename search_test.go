@@ -0,0 +1,118 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSearchFiltersEncode(t *testing.T) {
+	tests := []struct {
+		filters  SearchFilters
+		expected string
+	}{
+		{SearchFilters{}, ""},
+		{SearchFilters{Type: VideoResults}, "EgIQAQ=="},
+		{SearchFilters{Type: PlaylistResults}, "EgIQAw=="},
+		{SearchFilters{Sort: UploadDate}, "CAI="},
+		{SearchFilters{Duration: LongDuration}, "EgIYAg=="},
+		{SearchFilters{Uploaded: Today, Type: VideoResults}, "EgQIAhAB"},
+		{SearchFilters{Sort: ViewCount, Type: VideoResults, Duration: ShortDuration}, "CAMSBBABGAE="},
+	}
+	for _, test := range tests {
+		if encoded := test.filters.Encode(); encoded != test.expected {
+			t.Errorf("%+v encoded to %q, expected %q", test.filters, encoded, test.expected)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		fmt.Fprint(w, `{"contents":{"twoColumnSearchResultsRenderer":{"primaryContents":{"sectionListRenderer":{"contents":[{"itemSectionRenderer":{"contents":[
+			{"videoRenderer":{"videoId":"aaaaaaaaaaa","title":{"runs":[{"text":"A song"}]},"ownerText":{"runs":[{"text":"Artist"}]},"lengthText":{"simpleText":"1:02:03"}}},
+			{"playlistRenderer":{"playlistId":"PLabc","title":{"simpleText":"A playlist"},"thumbnail":{"thumbnails":[{"url":"https://i.ytimg.com/vi/small.jpg"},{"url":"https://i.ytimg.com/vi/big.jpg"}]},"shortBylineText":{"runs":[{"text":"Curator"}]}}},
+			{"channelRenderer":{"channelId":"UCabc","title":{"simpleText":"A channel"},"thumbnail":{"thumbnails":[{"url":"//yt3.ggpht.com/avatar"}]}}},
+			{"shelfRenderer":{"title":{"simpleText":"Not a result"}}}]}}]}}}}}`)
+	}))
+	defer server.Close()
+	oldAPI := youtubeSearchAPI
+	youtubeSearchAPI = server.URL
+	defer func() { youtubeSearchAPI = oldAPI }()
+
+	results, err := Search(context.Background(), "a song", SearchFilters{Type: VideoResults})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if request["query"] != "a song" || request["params"] != "EgIQAQ==" {
+		t.Errorf("unexpected request %v", request)
+	}
+	expected := []SearchResult{
+		{Type: VideoResults, ID: "aaaaaaaaaaa", URL: "https://www.youtube.com/watch?v=aaaaaaaaaaa", Title: "A song", Author: "Artist", Duration: time.Hour + 2*time.Minute + 3*time.Second, Thumbnail: "https://i.ytimg.com/vi/aaaaaaaaaaa/hqdefault.jpg"},
+		{Type: PlaylistResults, ID: "PLabc", URL: "https://www.youtube.com/playlist?list=PLabc", Title: "A playlist", Author: "Curator", Thumbnail: "https://i.ytimg.com/vi/big.jpg"},
+		{Type: ChannelResults, ID: "UCabc", URL: "https://www.youtube.com/channel/UCabc", Title: "A channel", Thumbnail: "https://yt3.ggpht.com/avatar"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("result %v is %+v, expected %+v", i, results[i], expected[i])
+		}
+	}
+
+	if _, err := Search(context.Background(), " ", SearchFilters{}); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
+
+func TestSearchResults(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if request["continuation"] == "page2" {
+			fmt.Fprint(w, `{"onResponseReceivedCommands":[{"appendContinuationItemsAction":{"continuationItems":[{"itemSectionRenderer":{"contents":[
+				{"videoRenderer":{"videoId":"ccccccccccc","title":{"runs":[{"text":"Third"}]}}}]}}]}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"contents":{"twoColumnSearchResultsRenderer":{"primaryContents":{"sectionListRenderer":{"contents":[{"itemSectionRenderer":{"contents":[
+			{"videoRenderer":{"videoId":"aaaaaaaaaaa","title":{"runs":[{"text":"First"}]}}},
+			{"videoRenderer":{"videoId":"bbbbbbbbbbb","title":{"runs":[{"text":"Second"}]}}}]}},
+			{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page2"}}}}]}}}}}`)
+	}))
+	defer server.Close()
+	oldAPI := youtubeSearchAPI
+	youtubeSearchAPI = server.URL
+	defer func() { youtubeSearchAPI = oldAPI }()
+
+	var ids []string
+	for result, err := range SearchResults(context.Background(), "songs", SearchFilters{}) {
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		ids = append(ids, result.ID)
+	}
+	if len(ids) != 3 || ids[2] != "ccccccccccc" || len(requests) != 2 || requests[1]["query"] != nil {
+		t.Fatalf("unexpected results %v after requests %v", ids, requests)
+	}
+
+	requests = nil
+	for range SearchResults(context.Background(), "songs", SearchFilters{}) {
+		break
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected stopping the loop to stop requesting pages, made %v requests", len(requests))
+	}
+
+	requests = nil
+	if results, err := Search(context.Background(), "songs", SearchFilters{}); err != nil || len(results) != 2 || len(requests) != 1 {
+		t.Errorf("expected Search to only get the first page, got %v, %v after %v requests", results, err, len(requests))
+	}
+}
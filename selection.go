@@ -0,0 +1,118 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+)
+
+// Choosing the instance of each job among healthy mirrors, to spread the load, and failing over to the others.
+
+// SelectionStrategy picks the instance to send a job to. Select is only called with at least one candidate,
+// and it must be safe for concurrent use.
+type SelectionStrategy interface {
+	Select(candidates []Candidate) Candidate
+}
+
+// SelectionFunc is a function used as a SelectionStrategy.
+type SelectionFunc func(candidates []Candidate) Candidate
+
+func (f SelectionFunc) Select(candidates []Candidate) Candidate {
+	return f(candidates)
+}
+
+// RandomSelection picks any candidate.
+var RandomSelection SelectionStrategy = SelectionFunc(func(candidates []Candidate) Candidate {
+	return candidates[rand.IntN(len(candidates))]
+})
+
+// LowestLatency picks the candidate with the lowest measured latency, see MeasureLatency. The first one if none was measured.
+var LowestLatency SelectionStrategy = SelectionFunc(func(candidates []Candidate) Candidate {
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.Latency > 0 && (best.Latency <= 0 || candidate.Latency < best.Latency) {
+			best = candidate
+		}
+	}
+	return best
+})
+
+// RoundRobin returns a strategy picking the candidates in turns.
+func RoundRobin() SelectionStrategy {
+	var next atomic.Uint64
+	return SelectionFunc(func(candidates []Candidate) Candidate {
+		return candidates[(next.Add(1)-1)%uint64(len(candidates))]
+	})
+}
+
+// WeightedByScore returns a strategy picking candidates at random, the ones with a higher score more often.
+// Candidates scored negative are only picked if every one is. DefaultScorer if scorer is nil.
+func WeightedByScore(scorer Scorer) SelectionStrategy {
+	if scorer == nil {
+		scorer = DefaultScorer
+	}
+	return SelectionFunc(func(candidates []Candidate) Candidate {
+		scores := make([]float64, len(candidates))
+		var total float64
+		for i, candidate := range candidates {
+			scores[i] = max(scorer.Score(candidate), 0)
+			total += scores[i]
+		}
+		if total == 0 {
+			return candidates[rand.IntN(len(candidates))]
+		}
+		pick := rand.Float64() * total
+		for i, score := range scores {
+			if pick < score {
+				return candidates[i]
+			}
+			pick -= score
+		}
+		return candidates[len(candidates)-1]
+	})
+}
+
+// RunFailover runs the job on an instance picked by the strategy, see the method.
+func RunFailover(ctx context.Context, options Settings, candidates []Candidate, strategy SelectionStrategy) (*CobaltResponse, error) {
+	return defaultCobalt().RunFailover(ctx, options, candidates, strategy)
+}
+
+// RunFailover runs the job on the instance picked by the strategy among candidates, like the ones ranked by RankInstances.
+// If the instance fails, the job is sent to another one picked among the rest, until one works. Errors about the link or
+// the content, which other instances would return too, aren't retried. RandomSelection if strategy is nil.
+func (c *Cobalt) RunFailover(ctx context.Context, options Settings, candidates []Candidate, strategy SelectionStrategy) (*CobaltResponse, error) {
+	if len(candidates) == 0 {
+		return nil, errors.New("no instances to run the job on")
+	}
+	if strategy == nil {
+		strategy = RandomSelection
+	}
+
+	remaining := slices.Clone(candidates)
+	var errs []error
+	for len(remaining) > 0 {
+		picked := strategy.Select(remaining)
+		media, err := c.run(ctx, picked.apiURL(), options)
+		if feedback, ok := strategy.(SelectionFeedback); ok && ctx.Err() == nil && (err == nil || failsOver(err)) {
+			feedback.Report(picked, err)
+		}
+		if err == nil {
+			return media, nil
+		}
+		if ctx.Err() != nil || !failsOver(err) {
+			return media, err
+		}
+		errs = append(errs, fmt.Errorf("%v: %w", picked.apiURL(), err))
+		remaining = slices.DeleteFunc(remaining, func(candidate Candidate) bool { return candidate.API == picked.API })
+	}
+	return nil, fmt.Errorf("every instance failed: %w", errors.Join(errs...))
+}
+
+// failsOver reports if another instance could succeed where one failed with err.
+func failsOver(err error) bool {
+	var urlErr *URLError
+	return !errors.As(err, &urlErr) && !errors.Is(err, ErrInvalidSettings) && !errors.Is(err, ErrContentUnavailable)
+}
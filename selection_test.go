@@ -0,0 +1,144 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSelectionStrategies(t *testing.T) {
+	candidates := []Candidate{
+		{CobaltInstance: CobaltInstance{API: "a"}, Latency: 300 * time.Millisecond},
+		{CobaltInstance: CobaltInstance{API: "b"}, Latency: 100 * time.Millisecond},
+		{CobaltInstance: CobaltInstance{API: "c"}},
+	}
+	if picked := LowestLatency.Select(candidates); picked.API != "b" {
+		t.Errorf("expected the fastest instance, got %v", picked.API)
+	}
+
+	roundRobin := RoundRobin()
+	var picked []string
+	for range 4 {
+		picked = append(picked, roundRobin.Select(candidates).API)
+	}
+	if strings.Join(picked, "") != "abca" {
+		t.Errorf("expected the instances in turns, got %v", picked)
+	}
+
+	onlyC := WeightedByScore(ScorerFunc(func(candidate Candidate) float64 {
+		if candidate.API == "c" {
+			return 1
+		}
+		return -1
+	}))
+	for range 20 {
+		if picked := onlyC.Select(candidates); picked.API != "c" {
+			t.Fatalf("expected only the scored instance to be picked, got %v", picked.API)
+		}
+	}
+	if picked := RandomSelection.Select(candidates[:1]); picked.API != "a" {
+		t.Errorf("unexpected pick %v", picked.API)
+	}
+}
+
+// failoverServer answers jobs with the error code, or with a tunnel if it's empty.
+func failoverServer(t *testing.T, code string, jobs *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		jobs.Add(1)
+		if code != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","error":{"code":"` + code + `"}}`))
+			return
+		}
+		w.Write([]byte(`{"status":"tunnel","url":"https://example.com/file","filename":"file.mp4"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunFailover(t *testing.T) {
+	var failedJobs, workingJobs atomic.Int32
+	failing := failoverServer(t, "error.api.capacity", &failedJobs)
+	working := failoverServer(t, "", &workingJobs)
+	candidates := []Candidate{{CobaltInstance: CobaltInstance{API: failing.URL}}, {CobaltInstance: CobaltInstance{API: working.URL}}}
+
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	media, err := RunFailover(context.Background(), settings, candidates, RoundRobin())
+	if err != nil || media.URL != "https://example.com/file" {
+		t.Fatalf("expected the job to fail over, got %+v, %v", media, err)
+	}
+	if failedJobs.Load() != 1 || workingJobs.Load() != 1 {
+		t.Errorf("unexpected jobs %v and %v", failedJobs.Load(), workingJobs.Load())
+	}
+
+	var unavailableJobs atomic.Int32
+	unavailable := failoverServer(t, "error.api.content.video.unavailable", &unavailableJobs)
+	candidates[0].API = unavailable.URL
+	if _, err := RunFailover(context.Background(), settings, candidates, RoundRobin()); !errors.Is(err, ErrContentUnavailable) || workingJobs.Load() != 1 {
+		t.Errorf("expected unavailable content not to fail over, got %v", err)
+	}
+
+	if _, err := RunFailover(context.Background(), settings, candidates[:1], nil); err == nil {
+		t.Error("expected an error when every instance fails")
+	}
+}
+
+func TestDownloadPlaylistInstances(t *testing.T) {
+	var server *httptest.Server
+	var jobs [2]atomic.Int32
+	mirror := func(index int) *httptest.Server {
+		mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+				return
+			}
+			jobs[index].Add(1)
+			var settings Settings
+			json.NewDecoder(r.Body).Decode(&settings)
+			json.NewEncoder(w).Encode(CobaltResponse{Status: "tunnel", URL: server.URL, Filename: settings.Url[len(settings.Url)-11:] + ".mp4"})
+		}))
+		t.Cleanup(mirror.Close)
+		return mirror
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	instances := []Candidate{{CobaltInstance: CobaltInstance{API: mirror(0).URL}}, {CobaltInstance: CobaltInstance{API: mirror(1).URL}}}
+	urls := Playlist{
+		"https://www.youtube.com/watch?v=aaaaaaaaaaa",
+		"https://www.youtube.com/watch?v=bbbbbbbbbbb",
+		"https://www.youtube.com/watch?v=ccccccccccc",
+		"https://www.youtube.com/watch?v=ddddddddddd",
+	}
+	items, err := DownloadPlaylist(context.Background(), urls, PlaylistOptions{
+		Download:  DownloadOptions{Directory: t.TempDir()},
+		Instances: instances,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if item.State != ItemDone {
+			t.Errorf("unexpected item %+v", item)
+		}
+	}
+	if jobs[0].Load() != 2 || jobs[1].Load() != 2 {
+		t.Errorf("expected the jobs spread across the mirrors, got %v and %v", jobs[0].Load(), jobs[1].Load())
+	}
+}
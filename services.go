@@ -0,0 +1,390 @@
+package gobalt
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Service detection and per-service url validation, done before sending a request to cobalt.
+
+// URLError is returned by ValidateURL (and Run) when the url can't point to downloadable media.
+type URLError struct {
+	Service ServiceName //Service detected from the url, empty if it's unknown.
+	URL     string      //The url that was rejected.
+	Reason  string      //Why the url was rejected, like "this is a channel url, not a video".
+}
+
+func (e *URLError) Error() string {
+	if e.Service == "" {
+		return fmt.Sprintf("invalid url %v: %v", e.URL, e.Reason)
+	}
+	return fmt.Sprintf("invalid %v url %v: %v", e.Service, e.URL, e.Reason)
+}
+
+// ServiceName is the name of a service supported by cobalt, as listed in ServerInfo.Cobalt.Services.
+// Names of services added to cobalt after this gobalt version are kept as they are, see Known.
+type ServiceName string
+
+const (
+	Bilibili    ServiceName = "bilibili"
+	Bluesky     ServiceName = "bluesky"
+	Dailymotion ServiceName = "dailymotion"
+	Facebook    ServiceName = "facebook"
+	Instagram   ServiceName = "instagram"
+	Loom        ServiceName = "loom"
+	Newgrounds  ServiceName = "newgrounds"
+	OK          ServiceName = "ok" //Odnoklassniki.
+	Pinterest   ServiceName = "pinterest"
+	Reddit      ServiceName = "reddit"
+	Rutube      ServiceName = "rutube"
+	Snapchat    ServiceName = "snapchat"
+	Soundcloud  ServiceName = "soundcloud"
+	Streamable  ServiceName = "streamable"
+	TikTok      ServiceName = "tiktok"
+	Tumblr      ServiceName = "tumblr"
+	Twitch      ServiceName = "twitch" //Twitch clips.
+	Twitter     ServiceName = "twitter"
+	Vimeo       ServiceName = "vimeo"
+	VK          ServiceName = "vk"
+	Xiaohongshu ServiceName = "xiaohongshu"
+	YouTube     ServiceName = "youtube" //Including YouTube Music and Shorts.
+)
+
+var knownServices = []ServiceName{Bilibili, Bluesky, Dailymotion, Facebook, Instagram, Loom, Newgrounds, OK, Pinterest, Reddit,
+	Rutube, Snapchat, Soundcloud, Streamable, TikTok, Tumblr, Twitch, Twitter, Vimeo, VK, Xiaohongshu, YouTube}
+
+// Known reports if the service is one of the ServiceName constants.
+func (s ServiceName) Known() bool {
+	for _, known := range knownServices {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceNames returns the services enabled on the instance, including the ones gobalt doesn't know.
+func (i *ServerInfo) ServiceNames() []ServiceName {
+	services := make([]ServiceName, len(i.Cobalt.Services))
+	for n, service := range i.Cobalt.Services {
+		services[n] = ServiceName(strings.ToLower(strings.TrimSpace(service)))
+	}
+	return services
+}
+
+// HasService reports if the service is enabled on the instance.
+func (i *ServerInfo) HasService(service ServiceName) bool {
+	for _, enabled := range i.ServiceNames() {
+		if enabled == service {
+			return true
+		}
+	}
+	return false
+}
+
+// Hostnames (and their subdomains) of each service, using the same names cobalt uses in ServerInfo.Cobalt.Services.
+var serviceHosts = map[string]ServiceName{
+	"youtube.com":          YouTube,
+	"youtu.be":             YouTube,
+	"youtube-nocookie.com": YouTube,
+	"tiktok.com":           TikTok,
+	"twitter.com":          Twitter,
+	"x.com":                Twitter,
+	"vxtwitter.com":        Twitter,
+	"fxtwitter.com":        Twitter,
+	"fixvx.com":            Twitter,
+	"instagram.com":        Instagram,
+	"ddinstagram.com":      Instagram,
+	"reddit.com":           Reddit,
+	"redd.it":              Reddit,
+	"soundcloud.com":       Soundcloud,
+	"vimeo.com":            Vimeo,
+	"twitch.tv":            Twitch,
+	"bilibili.com":         Bilibili,
+	"bilibili.tv":          Bilibili,
+	"b23.tv":               Bilibili,
+	"pinterest.com":        Pinterest,
+	"pin.it":               Pinterest,
+	"tumblr.com":           Tumblr,
+	"vk.com":               VK,
+	"vk.ru":                VK,
+	"vkvideo.ru":           VK,
+	"ok.ru":                OK,
+	"rutube.ru":            Rutube,
+	"dailymotion.com":      Dailymotion,
+	"dai.ly":               Dailymotion,
+	"streamable.com":       Streamable,
+	"facebook.com":         Facebook,
+	"fb.watch":             Facebook,
+	"loom.com":             Loom,
+	"snapchat.com":         Snapchat,
+	"bsky.app":             Bluesky,
+	"xiaohongshu.com":      Xiaohongshu,
+	"xhslink.com":          Xiaohongshu,
+}
+
+// Validators for services with well known url patterns. Services without one are sent to cobalt as is.
+var serviceValidators = map[ServiceName]func(u *url.URL) string{
+	YouTube:    validateYoutubeURL,
+	TikTok:     validateTiktokURL,
+	Twitter:    validateTwitterURL,
+	Instagram:  validateInstagramURL,
+	Reddit:     validateRedditURL,
+	Soundcloud: validateSoundcloudURL,
+	Twitch:     validateTwitchURL,
+	Vimeo:      validateVimeoURL,
+}
+
+var (
+	youtubeVideoID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	numericID      = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// parseMediaURL parses the url, adding https:// if no scheme is present.
+func parseMediaURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("no host found in %v", rawURL)
+	}
+	return u, nil
+}
+
+// DetectService returns the service the url belongs to (like YouTube or TikTok), or an empty ServiceName if it's unknown.
+// The names are the same ones cobalt uses in ServerInfo.Cobalt.Services.
+func DetectService(rawURL string) ServiceName {
+	u, err := parseMediaURL(rawURL)
+	if err != nil {
+		return ""
+	}
+	return serviceFromHost(u.Hostname())
+}
+
+func serviceFromHost(host string) ServiceName {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for host != "" {
+		if service, ok := serviceHosts[host]; ok {
+			return service
+		}
+		//pinterest uses country domains, like pinterest.co.uk or br.pinterest.com.
+		if strings.HasPrefix(host, "pinterest.") {
+			return Pinterest
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return ""
+}
+
+// ValidateURL checks if the url matches the known patterns of the service it belongs to, returning a *URLError explaining why
+// it was rejected (for example, "this is a channel url, not a video"). Urls from unknown services are not rejected, cobalt decides.
+func ValidateURL(rawURL string) error {
+	u, err := parseMediaURL(rawURL)
+	if err != nil {
+		return &URLError{URL: rawURL, Reason: "not a valid url"}
+	}
+
+	service := serviceFromHost(u.Hostname())
+	validate, ok := serviceValidators[service]
+	if !ok {
+		return nil
+	}
+	if reason := validate(u); reason != "" {
+		return &URLError{Service: service, URL: rawURL, Reason: reason}
+	}
+	return nil
+}
+
+// pathParts splits the url path, ignoring empty parts.
+func pathParts(u *url.URL) []string {
+	return strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+}
+
+func validateYoutubeURL(u *url.URL) string {
+	parts := pathParts(u)
+	id := ""
+	switch {
+	case strings.HasSuffix(strings.ToLower(u.Hostname()), "youtu.be"):
+		if len(parts) == 0 {
+			return "missing the video id"
+		}
+		id = parts[0]
+	case len(parts) == 0:
+		return "this is the youtube home page, not a video"
+	case parts[0] == "watch":
+		id = u.Query().Get("v")
+		if id == "" {
+			return "missing the video id (v= parameter)"
+		}
+	case parts[0] == "shorts" || parts[0] == "embed" || parts[0] == "live" || parts[0] == "v":
+		if len(parts) < 2 {
+			return "missing the video id"
+		}
+		id = parts[1]
+	case parts[0] == "playlist":
+		return "this is a playlist url, not a video. Use GetYoutubePlaylist() to get the videos"
+	case strings.HasPrefix(parts[0], "@") || parts[0] == "channel" || parts[0] == "c" || parts[0] == "user":
+		return "this is a channel url, not a video"
+	case parts[0] == "results" || parts[0] == "feed":
+		return "this is a youtube page, not a video"
+	default:
+		return ""
+	}
+	if !youtubeVideoID.MatchString(id) {
+		return fmt.Sprintf("%q is not a valid video id", id)
+	}
+	return ""
+}
+
+func validateTiktokURL(u *url.URL) string {
+	host, parts := strings.ToLower(u.Hostname()), pathParts(u)
+	if host == "vm.tiktok.com" || host == "vt.tiktok.com" {
+		if len(parts) == 0 {
+			return "missing the short link id"
+		}
+		return ""
+	}
+	switch {
+	case len(parts) == 0:
+		return "this is the tiktok home page, not a video"
+	case strings.HasPrefix(parts[0], "@") && len(parts) == 1:
+		return "this is a profile url, not a video"
+	case strings.HasPrefix(parts[0], "@") && len(parts) >= 3 && (parts[1] == "video" || parts[1] == "photo"):
+		if !numericID.MatchString(parts[2]) {
+			return fmt.Sprintf("%q is not a valid post id", parts[2])
+		}
+	case parts[0] == "tag" || parts[0] == "music" || parts[0] == "discover":
+		return "this is a tiktok page, not a video"
+	}
+	return ""
+}
+
+func validateTwitterURL(u *url.URL) string {
+	parts := pathParts(u)
+	switch {
+	case len(parts) == 0:
+		return "this is the home page, not a post"
+	case len(parts) >= 3 && parts[1] == "status":
+		if !numericID.MatchString(parts[2]) {
+			return fmt.Sprintf("%q is not a valid post id", parts[2])
+		}
+	case len(parts) >= 4 && parts[0] == "i" && parts[1] == "web" && parts[2] == "status":
+		if !numericID.MatchString(parts[3]) {
+			return fmt.Sprintf("%q is not a valid post id", parts[3])
+		}
+	case len(parts) == 1 && parts[0] != "i":
+		return "this is a profile url, not a post"
+	default:
+		return "missing the post id (/status/<id>)"
+	}
+	return ""
+}
+
+func validateInstagramURL(u *url.URL) string {
+	parts := pathParts(u)
+	if len(parts) == 0 {
+		return "this is the instagram home page, not a post"
+	}
+	switch parts[0] {
+	case "p", "reel", "reels", "tv", "share":
+		if len(parts) < 2 {
+			return "missing the post id"
+		}
+	case "stories":
+		if len(parts) < 3 {
+			return "missing the story id"
+		}
+	case "explore":
+		return "this is an instagram page, not a post"
+	default:
+		//Urls like instagram.com/user/p/id are valid too.
+		if len(parts) == 1 {
+			return "this is a profile url, not a post"
+		}
+	}
+	return ""
+}
+
+func validateRedditURL(u *url.URL) string {
+	host, parts := strings.ToLower(u.Hostname()), pathParts(u)
+	if host == "redd.it" || host == "v.redd.it" {
+		if len(parts) == 0 {
+			return "missing the post id"
+		}
+		return ""
+	}
+	switch {
+	case len(parts) == 0:
+		return "this is the reddit home page, not a post"
+	case len(parts) >= 4 && (parts[0] == "r" || parts[0] == "user" || parts[0] == "u") && (parts[2] == "comments" || parts[2] == "s"):
+		return ""
+	case len(parts) >= 2 && parts[0] == "comments":
+		return ""
+	case parts[0] == "r" && len(parts) <= 2:
+		return "this is a subreddit url, not a post"
+	case parts[0] == "user" || parts[0] == "u":
+		return "this is a profile url, not a post"
+	}
+	return "missing the post id (/comments/<id>)"
+}
+
+func validateSoundcloudURL(u *url.URL) string {
+	host, parts := strings.ToLower(u.Hostname()), pathParts(u)
+	if host == "on.soundcloud.com" {
+		if len(parts) == 0 {
+			return "missing the short link id"
+		}
+		return ""
+	}
+	switch {
+	case len(parts) == 0:
+		return "this is the soundcloud home page, not a track"
+	case len(parts) == 1:
+		return "this is a profile url, not a track"
+	case parts[1] == "sets":
+		return "this is a playlist (set) url, only single tracks can be downloaded"
+	case len(parts) == 2 && (parts[1] == "tracks" || parts[1] == "albums" || parts[1] == "reposts" || parts[1] == "likes" || parts[1] == "followers" || parts[1] == "following"):
+		return "this is a profile page, not a track"
+	}
+	return ""
+}
+
+func validateTwitchURL(u *url.URL) string {
+	host, parts := strings.ToLower(u.Hostname()), pathParts(u)
+	if host == "clips.twitch.tv" {
+		if len(parts) == 0 {
+			return "missing the clip id"
+		}
+		return ""
+	}
+	if len(parts) >= 3 && parts[1] == "clip" {
+		return ""
+	}
+	if len(parts) >= 1 && parts[0] == "videos" {
+		return "twitch vods are not supported, only clips"
+	}
+	return "only twitch clips can be downloaded"
+}
+
+func validateVimeoURL(u *url.URL) string {
+	parts := pathParts(u)
+	if len(parts) == 0 {
+		return "this is the vimeo home page, not a video"
+	}
+	for _, part := range parts {
+		if numericID.MatchString(part) {
+			return ""
+		}
+	}
+	return "missing the numeric video id"
+}
@@ -0,0 +1,87 @@
+package gobalt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectService(t *testing.T) {
+	tests := map[string]ServiceName{
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ":    YouTube,
+		"music.youtube.com/watch?v=JCd4KENZyj4":          YouTube,
+		"https://vm.tiktok.com/ZMabcdef/":                TikTok,
+		"https://x.com/user/status/1234567890":           Twitter,
+		"https://br.pinterest.com/pin/123/":              Pinterest,
+		"https://pinterest.co.uk/pin/123/":               Pinterest,
+		"https://clips.twitch.tv/SomeClipSlug":           Twitch,
+		"https://example.com/video.mp4":                  "",
+		"https://notyoutube.com/watch?v=dQw4w9WgXcQ":     "",
+		"https://www.reddit.com/r/golang/comments/abc/x": Reddit,
+	}
+	for url, expected := range tests {
+		if got := DetectService(url); got != expected {
+			t.Errorf("DetectService(%v) = %q, expected %q", url, got, expected)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	valid := []string{
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=RD-Sr668sSEIA",
+		"https://youtu.be/dQw4w9WgXcQ",
+		"https://www.youtube.com/shorts/dQw4w9WgXcQ",
+		"https://www.tiktok.com/@user/video/7123456789012345678",
+		"https://twitter.com/user/status/1234567890",
+		"https://www.instagram.com/reel/C1a2b3c4d5/",
+		"https://soundcloud.com/artist/track-name",
+		"https://vimeo.com/123456789",
+		"https://example.com/anything",
+	}
+	for _, url := range valid {
+		if err := ValidateURL(url); err != nil {
+			t.Errorf("ValidateURL(%v) returned %v, expected no error", url, err)
+		}
+	}
+
+	invalid := []string{
+		"https://www.youtube.com/@channel",
+		"https://www.youtube.com/playlist?list=PLDKxz_KUEUfMDTqDgv4eHuZq1u_SQtRiu",
+		"https://www.youtube.com/watch?v=short",
+		"https://www.tiktok.com/@user",
+		"https://x.com/user",
+		"https://www.instagram.com/user/",
+		"https://www.reddit.com/r/golang",
+		"https://soundcloud.com/artist/sets/playlist",
+		"https://www.twitch.tv/streamer",
+	}
+	for _, url := range invalid {
+		var urlErr *URLError
+		if err := ValidateURL(url); !errors.As(err, &urlErr) {
+			t.Errorf("ValidateURL(%v) returned %v, expected an *URLError", url, err)
+		}
+	}
+}
+
+func FuzzValidateURL(f *testing.F) {
+	for _, seed := range []string{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "x.com/i/web/status/", "%", "://", "https://youtu.be/", "https://vm.tiktok.com"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, url string) {
+		DetectService(url)
+		ValidateURL(url)
+	})
+}
+
+func TestServerInfoServices(t *testing.T) {
+	info := &ServerInfo{Cobalt: CobaltServerInformation{Services: []string{"youtube", "TikTok", "newservice"}}}
+	services := info.ServiceNames()
+	if len(services) != 3 || services[1] != TikTok || services[2] != "newservice" {
+		t.Errorf("unexpected services %v", services)
+	}
+	if !info.HasService(YouTube) || !info.HasService(TikTok) || info.HasService(Twitter) || !info.HasService("newservice") {
+		t.Errorf("HasService doesn't match %v", services)
+	}
+	if !YouTube.Known() || services[2].Known() {
+		t.Errorf("unexpected Known results")
+	}
+}
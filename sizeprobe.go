@@ -0,0 +1,88 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Checking how big the media of a response is before downloading it, for bots that can only upload files up to a size.
+
+// Upload limits of common platforms, to compare with ProbeResult.Size.
+const (
+	DiscordUploadLimit  = 8 * 1024 * 1024  //Discord, without boosts.
+	TelegramUploadLimit = 50 * 1024 * 1024 //Telegram bots.
+)
+
+// ProbeResult is returned by Probe.
+type ProbeResult struct {
+	Size        int64  //Size in bytes, for pickers and local-processing responses the sum of every file. -1 if unknown.
+	Estimated   bool   //Size comes from the Estimated-Content-Length of cobalt tunnels that remux or convert the media, so the file may end up a bit bigger or smaller.
+	ContentType string //Mime type of the first file, empty if the server didn't send one.
+	Files       int    //Number of files probed.
+}
+
+// Fits reports if the size is known and at most limit bytes, like DiscordUploadLimit.
+func (p *ProbeResult) Fits(limit int64) bool {
+	return p.Size >= 0 && p.Size <= limit
+}
+
+// Probe gets the size and content type of the media of a response without downloading it, see ProbeContext.
+func Probe(media *CobaltResponse) (*ProbeResult, error) {
+	return defaultCobalt().ProbeContext(context.Background(), media)
+}
+
+// Probe gets the size and content type of the media of a response without downloading it, see ProbeContext.
+func (c *Cobalt) Probe(media *CobaltResponse) (*ProbeResult, error) {
+	return c.ProbeContext(context.Background(), media)
+}
+
+// ProbeContext makes a request for the first byte of every url of the response (see AllURLs) and reads the size from
+// Content-Range, Content-Length or, for tunnels that can't know it yet, Estimated-Content-Length. Nothing else is downloaded.
+func (c *Cobalt) ProbeContext(ctx context.Context, media *CobaltResponse) (*ProbeResult, error) {
+	urls := media.AllURLs()
+	if len(urls) == 0 {
+		return nil, errors.New("the response has no media to probe")
+	}
+
+	result := &ProbeResult{Files: len(urls)}
+	for i, mediaURL := range urls {
+		size, estimated, contentType, err := c.probeFile(ctx, mediaURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to probe %v: %w", mediaURL, err)
+		}
+		if i == 0 {
+			result.ContentType = contentType
+		}
+		result.Estimated = result.Estimated || estimated
+		if size < 0 || result.Size < 0 {
+			result.Size = -1
+			continue
+		}
+		result.Size += size
+	}
+	return result, nil
+}
+
+// probeFile returns the size of the file at url, -1 if unknown, if it's an estimate and its content type.
+func (c *Cobalt) probeFile(ctx context.Context, url string) (size int64, estimated bool, contentType string, err error) {
+	res, err := c.rangedHttpRequest(ctx, url, nil, 0, 0)
+	if err != nil {
+		return 0, false, "", err
+	}
+	res.Body.Close()
+
+	contentType = res.Header.Get("Content-Type")
+	if total, err := strconv.ParseInt(sizeFromContentRange(res.Header.Get("Content-Range")), 10, 64); err == nil && res.StatusCode == http.StatusPartialContent {
+		return total, false, contentType, nil
+	}
+	if res.StatusCode == http.StatusOK && res.ContentLength >= 0 {
+		return res.ContentLength, false, contentType, nil
+	}
+	if estimate, err := strconv.ParseInt(res.Header.Get("Estimated-Content-Length"), 10, 64); err == nil && estimate >= 0 {
+		return estimate, true, contentType, nil
+	}
+	return -1, false, contentType, nil
+}
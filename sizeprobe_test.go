@@ -0,0 +1,58 @@
+package gobalt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("expected a request for the first byte, got %q", r.Header.Get("Range"))
+		}
+		switch r.URL.Path {
+		case "/ranged":
+			w.Header().Set("Content-Type", "video/mp4")
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(strings.Repeat("a", 9*1024*1024)))
+		case "/tunnel":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Header().Set("Estimated-Content-Length", "2048")
+			w.(http.Flusher).Flush()
+			w.Write([]byte("streamed"))
+		case "/unknown":
+			w.(http.Flusher).Flush()
+			w.Write([]byte("streamed"))
+		}
+	}))
+	defer server.Close()
+
+	probed, err := Probe(&CobaltResponse{Status: "tunnel", URL: server.URL + "/ranged"})
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if probed.Size != 9*1024*1024 || probed.Estimated || probed.ContentType != "video/mp4" || probed.Fits(DiscordUploadLimit) || !probed.Fits(TelegramUploadLimit) {
+		t.Errorf("unexpected ranged probe %+v", probed)
+	}
+
+	probed, err = Probe(&CobaltResponse{Status: "local-processing", Tunnel: []string{server.URL + "/tunnel", server.URL + "/ranged"}})
+	if err != nil || probed.Size != 2048+9*1024*1024 || !probed.Estimated || probed.ContentType != "audio/mpeg" || probed.Files != 2 {
+		t.Errorf("unexpected tunnel probe %+v, %v", probed, err)
+	}
+
+	probed, err = Probe(&CobaltResponse{Status: "picker", Picker: &[]PickerItem{{URL: server.URL + "/ranged"}, {URL: server.URL + "/unknown"}}})
+	if err != nil || probed.Size != -1 || probed.Fits(DiscordUploadLimit) {
+		t.Errorf("expected an unknown size, got %+v, %v", probed, err)
+	}
+
+	if _, err := Probe(&CobaltResponse{Status: "tunnel"}); err == nil {
+		t.Errorf("expected an error without urls")
+	}
+	if requests != 5 {
+		t.Errorf("expected a request per url, got %v", requests)
+	}
+}
@@ -0,0 +1,152 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conversion of picker slideshows, photos with background audio, to a single video with ffmpeg,
+// for the platforms that only accept videos.
+
+// SlideshowOptions configures how RenderSlideshow and DownloadSlideshow make the video.
+type SlideshowOptions struct {
+	ImageDuration time.Duration //How long each image is shown. Default: 3 seconds.
+	Width         int           //Width of the video, images are scaled to fit and padded with black bars. Default: 1080.
+	Height        int           //Height of the video. Default: 1920, the portrait resolution of TikTok slideshows.
+	FPS           int           //Frame rate of the video. Default: 30.
+}
+
+func (options SlideshowOptions) withDefaults() SlideshowOptions {
+	if options.ImageDuration <= 0 {
+		options.ImageDuration = 3 * time.Second
+	}
+	if options.Width <= 0 {
+		options.Width = 1080
+	}
+	if options.Height <= 0 {
+		options.Height = 1920
+	}
+	if options.FPS <= 0 {
+		options.FPS = 30
+	}
+	return options
+}
+
+// RenderSlideshow renders the images, in order, to an H.264 video at output, with audio as the soundtrack if it isn't empty.
+// The video lasts ImageDuration for each image, the audio is cut to that length. Requires ffmpeg, see FFmpegPath.
+func RenderSlideshow(ctx context.Context, images []string, audio, output string, options SlideshowOptions) error {
+	if len(images) == 0 {
+		return errors.New("a slideshow needs at least one image")
+	}
+	return runFFmpeg(ctx, slideshowArgs(images, audio, output, options.withDefaults()))
+}
+
+// slideshowArgs returns the ffmpeg arguments to render the slideshow: every image is an input looped for the image duration,
+// scaled and padded to the resolution, and all of them concatenated.
+func slideshowArgs(images []string, audio, output string, options SlideshowOptions) []string {
+	seconds := strconv.FormatFloat(options.ImageDuration.Seconds(), 'f', -1, 64)
+	total := strconv.FormatFloat(options.ImageDuration.Seconds()*float64(len(images)), 'f', -1, 64)
+
+	var args []string
+	var filter, concat strings.Builder
+	for i, image := range images {
+		args = append(args, "-loop", "1", "-t", seconds, "-i", image)
+		fmt.Fprintf(&filter, "[%v:v]scale=%v:%v:force_original_aspect_ratio=decrease,pad=%v:%v:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%v,format=yuv420p[v%v];",
+			i, options.Width, options.Height, options.Width, options.Height, options.FPS, i)
+		fmt.Fprintf(&concat, "[v%v]", i)
+	}
+	fmt.Fprintf(&filter, "%vconcat=n=%v:v=1:a=0[video]", concat.String(), len(images))
+
+	if audio != "" {
+		args = append(args, "-i", audio)
+	}
+	args = append(args, "-filter_complex", filter.String(), "-map", "[video]")
+	if audio != "" {
+		args = append(args, "-map", fmt.Sprintf("%v:a", len(images)), "-c:a", "aac")
+	}
+	return append(args, "-c:v", "libx264", "-t", total, "-movflags", "+faststart", output)
+}
+
+// DownloadSlideshow downloads the photos and background audio of a picker and renders them to a video, see the method.
+func DownloadSlideshow(ctx context.Context, media *CobaltResponse, options DownloadOptions, slideshow SlideshowOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadSlideshow(ctx, media, options, slideshow)
+}
+
+// DownloadSlideshow downloads the photos and background audio of a picker and renders them to an mp4 video saved in options.Directory,
+// named after options.Filename, or the title field, or slideshow.mp4. Videos and gifs of the picker are left out.
+// The downloaded files are removed once the video is rendered, and options.PostProcessors run on the video.
+func (c *Cobalt) DownloadSlideshow(ctx context.Context, media *CobaltResponse, options DownloadOptions, slideshow SlideshowOptions) (*DownloadResult, error) {
+	if media == nil || !media.IsPicker() || media.Picker == nil {
+		return nil, errors.New("the response isn't a picker")
+	}
+	if !FFmpegAvailable() {
+		return nil, fmt.Errorf("%w: %v", ErrFFmpegNotFound, FFmpegPath)
+	}
+	var photos []PickerItem
+	for _, item := range *media.Picker {
+		if item.Type == Photo {
+			photos = append(photos, item)
+		}
+	}
+	if len(photos) == 0 {
+		return nil, errors.New("the picker has no photos")
+	}
+	path, collision := collisionPath(pickerOutputPath(options, "slideshow", ".mp4"), options.Collision)
+	if collision == CollisionSkip {
+		return skippedResult(path), nil
+	}
+
+	//Kept next to the output, so the video is moved instead of copied.
+	temp, err := os.MkdirTemp(options.Directory, ".gobalt-slideshow-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(temp)
+
+	fileOptions := options
+	fileOptions.Directory, fileOptions.Template, fileOptions.Collision, fileOptions.PostProcessors = temp, "", CollisionOverwrite, nil
+	download := func(url, name string) (string, error) {
+		fileOptions.Filename = name
+		result, err := c.DownloadContext(ctx, &CobaltResponse{Status: "tunnel", URL: url}, fileOptions)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %v of the slideshow: %w", name, err)
+		}
+		return result.Path, nil
+	}
+
+	images := make([]string, len(photos))
+	for i, photo := range photos {
+		if images[i], err = download(photo.URL, fmt.Sprintf("photo%03d", i+1)); err != nil {
+			return nil, err
+		}
+	}
+	var audio string
+	if media.Audio != "" {
+		if audio, err = download(media.Audio, "audio"); err != nil {
+			return nil, err
+		}
+	}
+
+	rendered := filepath.Join(temp, "slideshow.mp4")
+	if err := RenderSlideshow(ctx, images, audio, rendered, slideshow); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(rendered, path); err != nil {
+		return nil, err
+	}
+	if path, err = runPostProcessors(ctx, path, options.PostProcessors); err != nil {
+		return nil, err
+	}
+
+	result := &DownloadResult{Path: path, ContentType: "video/mp4", Collision: collision}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlideshowArgs(t *testing.T) {
+	args := slideshowArgs([]string{"a.jpg", "b.jpg"}, "audio.mp3", "out.mp4", SlideshowOptions{ImageDuration: 2500 * time.Millisecond, Width: 720, Height: 1280}.withDefaults())
+	command := strings.Join(args, " ")
+	for _, expected := range []string{
+		"-loop 1 -t 2.5 -i a.jpg -loop 1 -t 2.5 -i b.jpg -i audio.mp3",
+		"[1:v]scale=720:1280:force_original_aspect_ratio=decrease,pad=720:1280:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=30,format=yuv420p[v1];",
+		"[v0][v1]concat=n=2:v=1:a=0[video]",
+		"-map [video] -map 2:a",
+		"-t 5 -movflags +faststart out.mp4",
+	} {
+		if !strings.Contains(command, expected) {
+			t.Errorf("expected %q in the arguments: %v", expected, command)
+		}
+	}
+
+	silent := slideshowArgs([]string{"a.jpg"}, "", "out.mp4", SlideshowOptions{}.withDefaults())
+	if slices.Contains(silent, "-c:a") || !slices.Contains(silent, "3") {
+		t.Errorf("unexpected arguments without audio: %v", silent)
+	}
+}
+
+func TestDownloadSlideshow(t *testing.T) {
+	if _, err := DownloadSlideshow(context.Background(), &CobaltResponse{Status: "tunnel"}, DownloadOptions{}, SlideshowOptions{}); err == nil {
+		t.Error("expected an error for a response that isn't a picker")
+	}
+
+	defer func(path string) { FFmpegPath = path }(FFmpegPath)
+	FFmpegPath = "gobalt-ffmpeg-that-does-not-exist"
+	picker := []PickerItem{{Type: Photo, URL: "http://127.0.0.1/photo"}}
+	if _, err := DownloadSlideshow(context.Background(), &CobaltResponse{Status: "picker", Picker: &picker}, DownloadOptions{}, SlideshowOptions{}); !errors.Is(err, ErrFFmpegNotFound) {
+		t.Errorf("expected ErrFFmpegNotFound, got %v", err)
+	}
+}
+
+func TestRenderSlideshow(t *testing.T) {
+	if !FFmpegAvailable() {
+		t.Skip("ffmpeg isn't installed")
+	}
+	dir := t.TempDir()
+	image, audio := filepath.Join(dir, "image.png"), filepath.Join(dir, "audio.m4a")
+	if err := runFFmpeg(context.Background(), []string{"-f", "lavfi", "-i", "color=red:size=320x240", "-frames:v", "1", image}); err != nil {
+		t.Fatalf("failed generating an image: %v", err)
+	}
+	if err := runFFmpeg(context.Background(), []string{"-f", "lavfi", "-i", "sine=duration=5", audio}); err != nil {
+		t.Fatalf("failed generating audio: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(dir, filepath.Base(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	picker := []PickerItem{{Type: Photo, URL: server.URL + "/image.png"}, {Type: Video, URL: server.URL + "/video.mp4"}, {Type: Photo, URL: server.URL + "/image.png"}}
+	output := t.TempDir()
+	result, err := DownloadSlideshow(context.Background(), &CobaltResponse{Status: "picker", Picker: &picker, Audio: server.URL + "/audio.m4a"},
+		DownloadOptions{Directory: output, Fields: map[string]string{"title": "Slides"}}, SlideshowOptions{ImageDuration: time.Second, Width: 320, Height: 320})
+	if err != nil {
+		t.Fatalf("failed rendering the slideshow: %v", err)
+	}
+	if result.Path != filepath.Join(output, "Slides.mp4") || result.Size == 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if entries, _ := os.ReadDir(output); len(entries) != 1 {
+		t.Errorf("expected only the video in the directory, got %v", entries)
+	}
+}
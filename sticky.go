@@ -0,0 +1,135 @@
+package gobalt
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Session affinity: keeping the jobs on the same instance, for its caches and cookies, and only picking another one
+// from time to time or when it stops working.
+
+// SelectionFeedback is implemented by strategies learning from the jobs, RunFailover reports the result of every job to them.
+type SelectionFeedback interface {
+	Report(candidate Candidate, err error) //err is nil if the job worked.
+}
+
+type switchReason string
+
+const (
+	SwitchInterval    switchReason = "interval"    //The instance was used for StickyOptions.Interval and the strategy picked another one.
+	SwitchFailure     switchReason = "failure"     //The instance failed StickyOptions.MaxFailures jobs in a row.
+	SwitchUnavailable switchReason = "unavailable" //The instance isn't among the candidates anymore, like after it failed over.
+)
+
+// StickyOptions configures a Sticky strategy.
+type StickyOptions struct {
+	Interval    time.Duration        //Time after which the strategy picks the instance again, even if it works. Never if 0.
+	MaxFailures int                  //Consecutive failed jobs after which another instance is picked. 1 if 0 or less.
+	OnSwitch    func(InstanceSwitch) //Called when another instance is picked, not for the first pick.
+}
+
+// InstanceSwitch is the event sent to StickyOptions.OnSwitch.
+type InstanceSwitch struct {
+	From, To Candidate
+	Reason   switchReason
+	Err      error //Last error of From, if it switched after failures.
+}
+
+// StickySelection keeps picking the same instance, see Sticky.
+type StickySelection struct {
+	strategy SelectionStrategy
+	options  StickyOptions
+	now      func() time.Time
+
+	mu       sync.Mutex
+	current  *Candidate
+	pickedAt time.Time
+	failures int
+	lastErr  error
+}
+
+// Sticky returns a strategy picking an instance with strategy and keeping it for the next jobs. The instance is
+// picked again after options.Interval, and another one after options.MaxFailures consecutive failures reported by
+// RunFailover. RandomSelection if strategy is nil.
+func Sticky(strategy SelectionStrategy, options StickyOptions) *StickySelection {
+	if strategy == nil {
+		strategy = RandomSelection
+	}
+	if options.MaxFailures <= 0 {
+		options.MaxFailures = 1
+	}
+	return &StickySelection{strategy: strategy, options: options, now: time.Now}
+}
+
+func (s *StickySelection) Select(candidates []Candidate) Candidate {
+	s.mu.Lock()
+	if s.current == nil {
+		picked := s.pick(candidates)
+		s.mu.Unlock()
+		return picked
+	}
+
+	index := slices.IndexFunc(candidates, func(candidate Candidate) bool { return candidate.API == s.current.API })
+	var reason switchReason
+	switch {
+	case s.failures >= s.options.MaxFailures:
+		reason = SwitchFailure
+		if index >= 0 && len(candidates) > 1 {
+			candidates = slices.Delete(slices.Clone(candidates), index, index+1)
+		}
+	case index < 0:
+		reason = SwitchUnavailable
+	case s.options.Interval > 0 && s.now().Sub(s.pickedAt) >= s.options.Interval:
+		reason = SwitchInterval
+	default:
+		picked := candidates[index]
+		s.mu.Unlock()
+		return picked
+	}
+
+	event := InstanceSwitch{From: *s.current, Reason: reason}
+	if reason == SwitchFailure {
+		event.Err = s.lastErr
+	}
+	picked := s.pick(candidates)
+	s.mu.Unlock()
+
+	if picked.API != event.From.API && s.options.OnSwitch != nil {
+		event.To = picked
+		s.options.OnSwitch(event)
+	}
+	return picked
+}
+
+// pick makes the strategy pick among candidates and resets the failures, s.mu must be held.
+func (s *StickySelection) pick(candidates []Candidate) Candidate {
+	picked := s.strategy.Select(candidates)
+	s.current, s.pickedAt, s.failures, s.lastErr = &picked, s.now(), 0, nil
+	return picked
+}
+
+// Report counts the consecutive failures of the current instance.
+func (s *StickySelection) Report(candidate Candidate, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil || s.current.API != candidate.API {
+		return
+	}
+	if err == nil {
+		s.failures, s.lastErr = 0, nil
+		return
+	}
+	s.failures++
+	s.lastErr = err
+}
+
+// Current returns the instance being used, false if none was picked yet.
+func (s *StickySelection) Current() (Candidate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return Candidate{}, false
+	}
+	return *s.current, true
+}
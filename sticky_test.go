@@ -0,0 +1,70 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStickySelection(t *testing.T) {
+	candidates := []Candidate{{CobaltInstance: CobaltInstance{API: "a"}}, {CobaltInstance: CobaltInstance{API: "b"}}, {CobaltInstance: CobaltInstance{API: "c"}}}
+	var events []InstanceSwitch
+	first := SelectionFunc(func(candidates []Candidate) Candidate { return candidates[0] })
+	sticky := Sticky(first, StickyOptions{Interval: time.Minute, MaxFailures: 2, OnSwitch: func(event InstanceSwitch) { events = append(events, event) }})
+	clock := time.Now()
+	sticky.now = func() time.Time { return clock }
+
+	for range 3 {
+		if picked := sticky.Select(candidates); picked.API != "a" {
+			t.Fatalf("expected the first instance to stick, got %v", picked.API)
+		}
+	}
+
+	failure := errors.New("capacity")
+	sticky.Report(candidates[0], failure)
+	if picked := sticky.Select(candidates); picked.API != "a" {
+		t.Fatalf("expected the instance to stick after a single failure, got %v", picked.API)
+	}
+	sticky.Report(candidates[0], failure)
+	if picked := sticky.Select(candidates); picked.API != "b" {
+		t.Fatalf("expected another instance after two failures, got %v", picked.API)
+	}
+
+	clock = clock.Add(time.Minute)
+	if picked := sticky.Select(candidates); picked.API != "a" {
+		t.Fatalf("expected the instance to be picked again after the interval, got %v", picked.API)
+	}
+	if picked := sticky.Select(candidates[1:]); picked.API != "b" {
+		t.Fatalf("expected another instance when it's not a candidate, got %v", picked.API)
+	}
+
+	if len(events) != 3 || events[0].Reason != SwitchFailure || !errors.Is(events[0].Err, failure) || events[0].From.API != "a" || events[0].To.API != "b" ||
+		events[1].Reason != SwitchInterval || events[2].Reason != SwitchUnavailable || events[2].To.API != "b" {
+		t.Errorf("unexpected switch events %+v", events)
+	}
+	if current, ok := sticky.Current(); !ok || current.API != "b" {
+		t.Errorf("unexpected current instance %v", current.API)
+	}
+}
+
+func TestStickyFailover(t *testing.T) {
+	var failedJobs, workingJobs atomic.Int32
+	failing := failoverServer(t, "error.api.capacity", &failedJobs)
+	working := failoverServer(t, "", &workingJobs)
+	candidates := []Candidate{{CobaltInstance: CobaltInstance{API: failing.URL}}, {CobaltInstance: CobaltInstance{API: working.URL}}}
+
+	var switches atomic.Int32
+	sticky := Sticky(RoundRobin(), StickyOptions{OnSwitch: func(InstanceSwitch) { switches.Add(1) }})
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	for range 3 {
+		if _, err := RunFailover(context.Background(), settings, candidates, sticky); err != nil {
+			t.Fatalf("job failed: %v", err)
+		}
+	}
+	if failedJobs.Load() != 1 || workingJobs.Load() != 3 || switches.Load() != 1 {
+		t.Errorf("expected the working instance to stick after failing over, got %v failed and %v working jobs, %v switches", failedJobs.Load(), workingJobs.Load(), switches.Load())
+	}
+}
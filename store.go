@@ -0,0 +1,120 @@
+package gobalt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Persistent storage for the caches, so long running programs keep them warm across restarts.
+
+// Store keeps the values of the caches, see WithStore. Implementations must be safe for concurrent use.
+// Errors from the store are ignored by the caches, it only makes them colder.
+type Store interface {
+	Get(key string) (value []byte, found bool)             //Returns the value of key, not found if it expired.
+	Set(key string, value []byte, ttl time.Duration) error //Sets the value of key until ttl passes.
+	Delete(key string) error                               //Deletes key, no error if it doesn't exist.
+}
+
+// WithStore makes the server info cache (see WithServerInfoTTL) and the response cache (see WithResponseCache) keep their values
+// in store too, and look there for the values they don't have in memory.
+func WithStore(store Store) Option {
+	return func(c *Cobalt) {
+		c.store = store
+	}
+}
+
+// FileStore is a Store saving every value in a file of a directory. Expired files are deleted when read.
+type FileStore struct {
+	dir string
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore returns a FileStore saving the values in dir, creating it if it doesn't exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// storedFile is the content of a file of a FileStore.
+type storedFile struct {
+	Expires time.Time `json:"expires"`
+	Value   []byte    `json:"value"`
+}
+
+// path returns the file of key, named after its hash since keys can have any character.
+func (s *FileStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+func (s *FileStore) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var stored storedFile
+	if err := json.Unmarshal(data, &stored); err != nil || time.Now().After(stored.Expires) {
+		os.Remove(s.path(key))
+		return nil, false
+	}
+	return stored.Value, true
+}
+
+func (s *FileStore) Set(key string, value []byte, ttl time.Duration) error {
+	data, err := json.Marshal(storedFile{Expires: time.Now().Add(ttl), Value: value})
+	if err != nil {
+		return err
+	}
+	//Written to a temporary file first, so readers never see half of a file.
+	file, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+func (s *FileStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// storeGet unmarshals the value of key into v, false if the store is nil, it doesn't have key or the value is invalid.
+func storeGet(store Store, key string, v any) bool {
+	if store == nil {
+		return false
+	}
+	value, found := store.Get(key)
+	return found && json.Unmarshal(value, v) == nil
+}
+
+// storeSet marshals v as the value of key, if the store isn't nil.
+func storeSet(store Store, key string, v any, ttl time.Duration) {
+	if store == nil || ttl <= 0 {
+		return
+	}
+	if value, err := json.Marshal(v); err == nil {
+		store.Set(key, value, ttl)
+	}
+}
@@ -0,0 +1,73 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	if err := store.Set("a key/with any characters", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("failed to set the value: %v", err)
+	}
+	if value, found := store.Get("a key/with any characters"); !found || string(value) != "value" {
+		t.Errorf("unexpected value %q, %v", value, found)
+	}
+	store.Set("expired", []byte("value"), -time.Second)
+	if _, found := store.Get("expired"); found {
+		t.Error("expected expired values not to be found")
+	}
+	if err := store.Delete("a key/with any characters"); err != nil {
+		t.Errorf("failed to delete the value: %v", err)
+	}
+	if _, found := store.Get("a key/with any characters"); found {
+		t.Error("expected the deleted value not to be found")
+	}
+	if err := store.Delete("missing"); err != nil {
+		t.Errorf("expected no error deleting a missing key, got %v", err)
+	}
+}
+
+func TestStoreKeepsCachesWarm(t *testing.T) {
+	var infos, jobs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			infos.Add(1)
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+			return
+		}
+		jobs.Add(1)
+		w.Write([]byte(`{"status":"picker","picker":[{"type":"photo","url":"https://example.com/1.jpg"}]}`))
+	}))
+	defer server.Close()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	//A second Cobalt with the same store, like the program after a restart.
+	for range 2 {
+		media, err := New(WithAPI(server.URL), WithStore(store), WithResponseCache(10, time.Hour)).Run(settings)
+		if err != nil || !media.IsPicker() || len(*media.Picker) != 1 || media.ObtainedAt.IsZero() {
+			t.Fatalf("unexpected response %+v, %v", media, err)
+		}
+	}
+	if infos.Load() != 1 || jobs.Load() != 1 {
+		t.Errorf("expected the second Cobalt to use the stored values, got %v server info requests and %v jobs", infos.Load(), jobs.Load())
+	}
+
+	media, _ := New(WithAPI(server.URL), WithStore(store), WithResponseCache(10, time.Hour)).Run(settings)
+	if err := media.Refresh(context.Background()); err != nil || jobs.Load() != 2 {
+		t.Errorf("expected a stored response to be refreshed, got %v after %v jobs", err, jobs.Load())
+	}
+}
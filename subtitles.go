@@ -0,0 +1,117 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Subtitles returned by cobalt as a separate file, in local-processing responses, saved next to the media.
+
+// DownloadSubtitles saves the subtitles of a local-processing response in options.Directory, see the method.
+func DownloadSubtitles(ctx context.Context, media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadSubtitles(ctx, media, options)
+}
+
+// DownloadSubtitles saves only the subtitles of a local-processing response (see CobaltResponse.SubtitlesURL) in options.Directory,
+// named after options.Filename or the output filename, with the extension of the subtitles format, .vtt or .srt.
+// Useful when the media needs merging, Download saves the subtitles with the media otherwise. The result has the path in Path and Subtitles.
+func (c *Cobalt) DownloadSubtitles(ctx context.Context, media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	if media == nil || media.SubtitlesURL() == "" {
+		return nil, errors.New("the response has no subtitles, request them with Settings.SubtitleLanguage")
+	}
+	name := options.Filename
+	if name == "" {
+		name = media.Output.Filename
+	}
+	if name == "" {
+		name = "subtitles"
+	}
+	path, err := c.saveSubtitles(ctx, media.SubtitlesURL(), filepath.Join(options.Directory, SanitizeFilename(name, options.Sanitize)), options)
+	if err != nil {
+		return nil, err
+	}
+	result := &DownloadResult{Path: path, Subtitles: path}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	return result, nil
+}
+
+// downloadLocal downloads a local-processing response that is a single file (proxied or remuxed by the client, like a proxy type),
+// then its subtitles. Responses with video and audio to merge can't be downloaded as a file.
+func (c *Cobalt) downloadLocal(ctx context.Context, media *CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	files := media.Tunnel
+	if media.SubtitlesURL() != "" {
+		files = files[:len(files)-1]
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("the local-processing response has %v files to merge, download its Tunnel urls", len(files))
+	}
+	single := &CobaltResponse{Status: "tunnel", URL: files[0]}
+	if media.Output != nil {
+		single.Filename = media.Output.Filename
+	}
+	result, err := c.DownloadContext(ctx, single, options)
+	if err != nil || media.SubtitlesURL() == "" {
+		return result, err
+	}
+
+	result.Subtitles, err = c.saveSubtitles(ctx, media.SubtitlesURL(), result.Path, options)
+	if err != nil {
+		return result, fmt.Errorf("the media was saved, but its subtitles failed: %w", err)
+	}
+	return result, nil
+}
+
+// saveSubtitles saves the subtitles at subtitlesURL next to the media at mediaPath, with the same name and the subtitles extension.
+func (c *Cobalt) saveSubtitles(ctx context.Context, subtitlesURL, mediaPath string, options DownloadOptions) (string, error) {
+	stream, err := c.OpenStreamContext(ctx, subtitlesURL, options)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	//Renamed media already has a free name, its subtitles follow it.
+	strategy := options.Collision
+	if strategy == CollisionRename {
+		strategy = CollisionOverwrite
+	}
+	path, collision := collisionPath(strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath))+subtitlesExt(stream), strategy)
+	if collision == CollisionSkip {
+		return path, nil
+	}
+
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return "", err
+	}
+	_, err = writeStream(ctx, file, stream, nil)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".part")
+		return "", err
+	}
+	return path, os.Rename(path+".part", path)
+}
+
+// subtitlesExt returns the extension of the subtitles format, from the content type or the filename. Default: .vtt, used by YouTube.
+func subtitlesExt(stream *Stream) string {
+	mediaType, _, _ := mime.ParseMediaType(stream.ContentType)
+	switch mediaType {
+	case "text/vtt":
+		return ".vtt"
+	case "application/x-subrip", "application/srt", "text/srt":
+		return ".srt"
+	}
+	if ext := strings.ToLower(filepath.Ext(stream.Filename)); ext == ".srt" || ext == ".vtt" {
+		return ext
+	}
+	return ".vtt"
+}
@@ -0,0 +1,71 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadSubtitles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subs":
+			w.Header().Set("Content-Type", "text/vtt")
+			w.Write([]byte("WEBVTT"))
+		case "/srt":
+			w.Header().Set("Content-Type", "application/x-subrip")
+			w.Write([]byte("1"))
+		default:
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("media"))
+		}
+	}))
+	defer server.Close()
+
+	media := &CobaltResponse{
+		Status: "local-processing",
+		Tunnel: []string{server.URL + "/video", server.URL + "/subs"},
+		Output: &LocalOutput{Type: "video/mp4", Filename: "video.mp4", Subtitles: true},
+	}
+	if media.SubtitlesURL() != server.URL+"/subs" || len(media.AllURLs()) != 2 {
+		t.Errorf("unexpected urls %v and %v", media.SubtitlesURL(), media.AllURLs())
+	}
+
+	dir := t.TempDir()
+	result, err := Download(media, DownloadOptions{Directory: dir})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if result.Path != filepath.Join(dir, "video.mp4") || result.Subtitles != filepath.Join(dir, "video.vtt") {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if data, _ := os.ReadFile(result.Subtitles); string(data) != "WEBVTT" {
+		t.Errorf("unexpected subtitles %q", data)
+	}
+
+	merge := &CobaltResponse{Status: "local-processing", Tunnel: []string{server.URL + "/video", server.URL + "/audio", server.URL + "/srt"}, Output: &LocalOutput{Filename: "merged.mp4", Subtitles: true}}
+	if _, err := Download(merge, DownloadOptions{Directory: dir}); err == nil || !strings.Contains(err.Error(), "2 files to merge") {
+		t.Errorf("expected an error for a response to merge, got %v", err)
+	}
+	result, err = DownloadSubtitles(context.Background(), merge, DownloadOptions{Directory: dir})
+	if err != nil || result.Path != filepath.Join(dir, "merged.srt") || result.Size != 1 {
+		t.Errorf("unexpected subtitles download %+v, %v", result, err)
+	}
+
+	if _, err := DownloadSubtitles(context.Background(), &CobaltResponse{Status: "tunnel", URL: server.URL}, DownloadOptions{}); err == nil {
+		t.Error("expected an error for a response without subtitles")
+	}
+}
+
+func TestSubtitleLanguageVersions(t *testing.T) {
+	settings := Settings{Url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", SubtitleLanguage: "en"}
+	old, _ := shapeSettings(settings, "10.5.4")
+	current, _ := shapeSettings(settings, "11.0")
+	if strings.Contains(string(old), "subtitleLang") || !strings.Contains(string(current), `"subtitleLang":"en"`) {
+		t.Errorf("unexpected requests %s and %s", old, current)
+	}
+}
@@ -0,0 +1,721 @@
+package gobalt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Metadata tagging for downloaded audio. ID3v2.4 is written to mp3 files, Vorbis comments to ogg/opus files and iTunes style
+// ilst atoms to mp4/m4a files. Files are rewritten to a temporary file next to them, then renamed over the original.
+
+// Tags are the metadata written by WriteTags. Empty fields are not written, existing tags for them are kept.
+type Tags struct {
+	Title     string //Song or video title.
+	Artist    string //Artist, or the uploader of the media.
+	Album     string //Album name.
+	Track     int    //Track number, 0 to not set it.
+	SourceURL string //Where the media was downloaded from, like Settings.Url.
+}
+
+// tagSet is what the tag writers write: the tags, and the cover art of WriteCover.
+type tagSet struct {
+	Tags
+	cover []byte //Front cover, a jpeg or png image. Replaces the existing one.
+}
+
+// ErrUnsupportedTagFormat is returned by WriteTags when the file isn't mp3, ogg/opus or mp4.
+var ErrUnsupportedTagFormat = errors.New("unsupported file format for tagging")
+
+// ErrUnsupportedCover is returned by WriteCover when the image isn't jpeg or png.
+var ErrUnsupportedCover = errors.New("cover art must be a jpeg or png image")
+
+// Trailing "(youtube)" or "(soundcloud, 1242868615)" added by the pretty and nerdy filename styles.
+var filenameServiceSuffix = regexp.MustCompile(`\s\((?:[a-z0-9 ]+)(?:, [^)]+)?\)$`)
+
+// TagsFromFilename gets the title and artist from a filename returned by cobalt. Audio files are named "Title - Author.ext"
+// with the basic, pretty and nerdy filename styles. Classic style filenames (youtube_dQw4w9WgXcQ_audio.mp3) have no metadata.
+func TagsFromFilename(filename string) Tags {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if !strings.Contains(name, " ") {
+		return Tags{}
+	}
+	name = filenameServiceSuffix.ReplaceAllString(name, "")
+
+	//Titles can contain " - ", authors rarely do, so split on the last one.
+	if i := strings.LastIndex(name, " - "); i > 0 {
+		return Tags{Title: strings.TrimSpace(name[:i]), Artist: strings.TrimSpace(name[i+3:])}
+	}
+	return Tags{Title: strings.TrimSpace(name)}
+}
+
+// WriteTags writes the tags to the audio file at path. The format is detected from the file contents.
+func WriteTags(path string, tags Tags) error {
+	return writeTags(path, tagSet{Tags: tags})
+}
+
+// WriteCover embeds a jpeg or png image as the front cover of the audio file at path, replacing the existing one:
+// an ID3 APIC frame for mp3, METADATA_BLOCK_PICTURE for ogg/opus and covr for mp4. See CoverArt.
+func WriteCover(path string, cover []byte) error {
+	if _, err := coverType(cover); err != nil {
+		return err
+	}
+	return writeTags(path, tagSet{cover: cover})
+}
+
+func writeTags(path string, tags tagSet) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 12)
+	n, err := io.ReadFull(file, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	magic = magic[:n]
+
+	var write func(in *os.File, out io.Writer, tags tagSet) error
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")) || (len(magic) >= 2 && magic[0] == 0xFF && magic[1]&0xE0 == 0xE0):
+		write = writeID3Tags
+	case bytes.HasPrefix(magic, []byte("OggS")):
+		write = writeOggTags
+	case len(magic) >= 8 && string(magic[4:8]) == "ftyp":
+		write = writeMP4Tags
+	default:
+		return ErrUnsupportedTagFormat
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return err
+	}
+	out := bufio.NewWriter(tmp)
+	if err := write(file, out, tags); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to tag %v: %w", path, err)
+	}
+	if err := out.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(stat.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	file.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+/* ID3v2 (mp3) */
+
+func syncsafe(data []byte) uint32 {
+	return uint32(data[0]&0x7F)<<21 | uint32(data[1]&0x7F)<<14 | uint32(data[2]&0x7F)<<7 | uint32(data[3]&0x7F)
+}
+
+func appendSyncsafe(data []byte, value uint32) []byte {
+	return append(data, byte(value>>21&0x7F), byte(value>>14&0x7F), byte(value>>7&0x7F), byte(value&0x7F))
+}
+
+func appendID3Frame(tag []byte, id string, payload []byte) []byte {
+	tag = append(tag, id...)
+	tag = appendSyncsafe(tag, uint32(len(payload)))
+	return append(append(tag, 0, 0), payload...)
+}
+
+// writeID3Tags replaces the ID3v2 tag at the start of the file with a v2.4 one. Frames from the old tag that aren't being set are kept.
+func writeID3Tags(in *os.File, out io.Writer, tags tagSet) error {
+	frames := map[string][]byte{}
+	if tags.Title != "" {
+		frames["TIT2"] = append([]byte{3}, tags.Title...)
+	}
+	if tags.Artist != "" {
+		frames["TPE1"] = append([]byte{3}, tags.Artist...)
+	}
+	if tags.Album != "" {
+		frames["TALB"] = append([]byte{3}, tags.Album...)
+	}
+	if tags.Track > 0 {
+		frames["TRCK"] = append([]byte{3}, strconv.Itoa(tags.Track)...)
+	}
+	if tags.SourceURL != "" {
+		frames["WOAS"] = []byte(tags.SourceURL)
+	}
+	if len(tags.cover) > 0 {
+		//Latin-1 text encoding, the mime type, picture type 3 (front cover) and an empty description.
+		mimeType, _ := coverType(tags.cover)
+		frames["APIC"] = append(append(append([]byte{0}, mimeType...), 0, 3, 0), tags.cover...)
+	}
+
+	var tag []byte
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(in, header); err == nil && string(header[:3]) == "ID3" {
+		size := int64(syncsafe(header[6:10]))
+		if header[5]&0x10 != 0 {
+			size += 10 //footer
+		}
+		old := make([]byte, size)
+		if _, err := io.ReadFull(in, old); err != nil {
+			return fmt.Errorf("truncated id3 tag: %w", err)
+		}
+		//Tags with unsynchronisation or an extended header are dropped instead of parsed.
+		if (header[3] == 3 || header[3] == 4) && header[5]&0xC0 == 0 {
+			tag = keepID3Frames(tag, old, header[3], frames)
+		}
+	} else if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	for _, id := range []string{"TIT2", "TPE1", "TALB", "TRCK", "WOAS", "APIC"} {
+		if payload, ok := frames[id]; ok {
+			tag = appendID3Frame(tag, id, payload)
+		}
+	}
+
+	if _, err := out.Write(appendSyncsafe([]byte{'I', 'D', '3', 4, 0, 0}, uint32(len(tag)))); err != nil {
+		return err
+	}
+	if _, err := out.Write(tag); err != nil {
+		return err
+	}
+	_, err := io.Copy(out, in)
+	return err
+}
+
+// keepID3Frames appends the frames of an old v2.3/v2.4 tag that are not being replaced, converting the sizes to v2.4.
+func keepID3Frames(tag, old []byte, version byte, replaced map[string][]byte) []byte {
+	for len(old) >= 10 && old[0] != 0 {
+		id := string(old[:4])
+		size := binary.BigEndian.Uint32(old[4:8])
+		if version == 4 {
+			size = syncsafe(old[4:8])
+		}
+		if uint64(size) > uint64(len(old)-10) {
+			break
+		}
+		payload := old[10 : 10+size]
+		//Frames using compression, encryption or other format flags can't be copied as is.
+		if _, ok := replaced[id]; !ok && old[9] == 0 {
+			tag = appendID3Frame(tag, id, payload)
+		}
+		old = old[10+size:]
+	}
+	return tag
+}
+
+/* Vorbis comments (ogg vorbis and opus) */
+
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04C11DB7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+func oggCRC(page []byte) uint32 {
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+type oggPage struct {
+	headerType byte
+	granule    uint64
+	serial     uint32
+	sequence   uint32
+	segments   []byte
+	data       []byte
+}
+
+func readOggPage(r io.Reader) (*oggPage, error) {
+	header := make([]byte, 27)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "OggS" {
+		return nil, errors.New("invalid ogg page")
+	}
+	page := &oggPage{
+		headerType: header[5],
+		granule:    binary.LittleEndian.Uint64(header[6:14]),
+		serial:     binary.LittleEndian.Uint32(header[14:18]),
+		sequence:   binary.LittleEndian.Uint32(header[18:22]),
+		segments:   make([]byte, header[26]),
+	}
+	if _, err := io.ReadFull(r, page.segments); err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, segment := range page.segments {
+		size += int(segment)
+	}
+	page.data = make([]byte, size)
+	if _, err := io.ReadFull(r, page.data); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (page *oggPage) bytes() []byte {
+	data := []byte{'O', 'g', 'g', 'S', 0, page.headerType}
+	data = binary.LittleEndian.AppendUint64(data, page.granule)
+	data = binary.LittleEndian.AppendUint32(data, page.serial)
+	data = binary.LittleEndian.AppendUint32(data, page.sequence)
+	data = append(data, 0, 0, 0, 0, byte(len(page.segments)))
+	data = append(append(data, page.segments...), page.data...)
+	binary.LittleEndian.PutUint32(data[22:26], oggCRC(data))
+	return data
+}
+
+// writeOggTags replaces the comment header packet, repaginating the header packets and renumbering the pages after them.
+func writeOggTags(in *os.File, out io.Writer, tags tagSet) error {
+	r := bufio.NewReader(in)
+
+	var packets [][]byte
+	var packet []byte
+	var serial uint32
+	headerPackets, headerPages := 0, 0
+	for headerPackets == 0 || len(packets) < headerPackets {
+		page, err := readOggPage(r)
+		if err != nil {
+			return fmt.Errorf("failed reading ogg headers: %w", err)
+		}
+		if headerPages == 0 {
+			serial = page.serial
+		} else if page.serial != serial {
+			return errors.New("multiplexed ogg streams are not supported")
+		}
+		headerPages++
+
+		offset := 0
+		for _, segment := range page.segments {
+			packet = append(packet, page.data[offset:offset+int(segment)]...)
+			offset += int(segment)
+			if segment < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+
+		if headerPackets == 0 && len(packets) > 0 {
+			switch {
+			case bytes.HasPrefix(packets[0], []byte("\x01vorbis")):
+				headerPackets = 3
+			case bytes.HasPrefix(packets[0], []byte("OpusHead")):
+				headerPackets = 2
+			default:
+				return ErrUnsupportedTagFormat
+			}
+		}
+	}
+	if len(packets) != headerPackets || packet != nil {
+		return errors.New("audio data shares a page with the ogg headers")
+	}
+
+	comment, err := rebuildVorbisComment(packets[1], tags)
+	if err != nil {
+		return err
+	}
+	packets[1] = comment
+
+	//The identification header goes alone on the first page, the other headers are packed together after it.
+	pages := []*oggPage{{headerType: 0x02, serial: serial}}
+	addOggSegments(pages[0], packets[0])
+	pages = append(pages, paginateOgg(serial, packets[1:])...)
+	for i, page := range pages {
+		page.sequence = uint32(i)
+		if _, err := out.Write(page.bytes()); err != nil {
+			return err
+		}
+	}
+
+	delta := uint32(len(pages) - headerPages)
+	if delta == 0 {
+		_, err := io.Copy(out, r)
+		return err
+	}
+	for {
+		page, err := readOggPage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if page.serial == serial {
+			page.sequence += delta
+		}
+		if _, err := out.Write(page.bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// addOggSegments adds the lacing values of the packet to the page.
+func addOggSegments(page *oggPage, packet []byte) {
+	for n := len(packet); ; n -= 255 {
+		if n < 255 {
+			page.segments = append(page.segments, byte(n))
+			break
+		}
+		page.segments = append(page.segments, 255)
+	}
+	page.data = append(page.data, packet...)
+}
+
+// paginateOgg lays out the packets in pages of up to 255 segments. Pages where no packet ends have the granule position -1.
+func paginateOgg(serial uint32, packets [][]byte) []*oggPage {
+	var pages []*oggPage
+	page := &oggPage{serial: serial}
+	for _, packet := range packets {
+		scratch := &oggPage{}
+		addOggSegments(scratch, packet)
+		data := scratch.data
+		for i, segment := range scratch.segments {
+			if len(page.segments) == 255 {
+				pages = append(pages, page)
+				page = &oggPage{serial: serial, headerType: 0x01}
+				if i == 0 {
+					page.headerType = 0
+				}
+			}
+			page.segments = append(page.segments, segment)
+			page.data = append(page.data, data[:segment]...)
+			data = data[segment:]
+		}
+	}
+	pages = append(pages, page)
+
+	for _, page := range pages {
+		page.granule = math.MaxUint64
+		for _, segment := range page.segments {
+			if segment < 255 {
+				page.granule = 0
+			}
+		}
+	}
+	return pages
+}
+
+// rebuildVorbisComment replaces the fields being set in a vorbis ("\x03vorbis") or opus ("OpusTags") comment header.
+func rebuildVorbisComment(packet []byte, tags tagSet) ([]byte, error) {
+	var magic []byte
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x03vorbis")):
+		magic = packet[:7]
+	case bytes.HasPrefix(packet, []byte("OpusTags")):
+		magic = packet[:8]
+	default:
+		return nil, errors.New("invalid comment header")
+	}
+
+	data := packet[len(magic):]
+	readField := func() ([]byte, bool) {
+		if len(data) < 4 {
+			return nil, false
+		}
+		size := binary.LittleEndian.Uint32(data)
+		if uint64(size) > uint64(len(data)-4) {
+			return nil, false
+		}
+		field := data[4 : 4+size]
+		data = data[4+size:]
+		return field, true
+	}
+
+	vendor, ok := readField()
+	if !ok || len(data) < 4 {
+		return nil, errors.New("invalid comment header")
+	}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	fields := map[string]string{}
+	if tags.Title != "" {
+		fields["TITLE"] = tags.Title
+	}
+	if tags.Artist != "" {
+		fields["ARTIST"] = tags.Artist
+	}
+	if tags.Album != "" {
+		fields["ALBUM"] = tags.Album
+	}
+	if tags.Track > 0 {
+		fields["TRACKNUMBER"] = strconv.Itoa(tags.Track)
+	}
+	if tags.SourceURL != "" {
+		fields["PURL"] = tags.SourceURL
+	}
+	if len(tags.cover) > 0 {
+		fields["METADATA_BLOCK_PICTURE"] = base64.StdEncoding.EncodeToString(flacPicture(tags.cover))
+	}
+
+	var comments [][]byte
+	for i := uint32(0); i < count; i++ {
+		comment, ok := readField()
+		if !ok {
+			return nil, errors.New("invalid comment header")
+		}
+		key, _, _ := strings.Cut(string(comment), "=")
+		if _, replaced := fields[strings.ToUpper(key)]; !replaced {
+			comments = append(comments, comment)
+		}
+	}
+	for _, key := range []string{"TITLE", "ARTIST", "ALBUM", "TRACKNUMBER", "PURL", "METADATA_BLOCK_PICTURE"} {
+		if value, ok := fields[key]; ok {
+			comments = append(comments, []byte(key+"="+value))
+		}
+	}
+
+	rebuilt := append([]byte{}, magic...)
+	rebuilt = binary.LittleEndian.AppendUint32(rebuilt, uint32(len(vendor)))
+	rebuilt = append(rebuilt, vendor...)
+	rebuilt = binary.LittleEndian.AppendUint32(rebuilt, uint32(len(comments)))
+	for _, comment := range comments {
+		rebuilt = binary.LittleEndian.AppendUint32(rebuilt, uint32(len(comment)))
+		rebuilt = append(rebuilt, comment...)
+	}
+	if magic[0] == 0x03 {
+		rebuilt = append(rebuilt, 1) //Vorbis framing bit.
+	}
+	return rebuilt, nil
+}
+
+/* iTunes style metadata (mp4/m4a) */
+
+func appendMP4Box(data []byte, boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	data = binary.BigEndian.AppendUint32(data, uint32(size))
+	data = append(data, boxType...)
+	for _, p := range payload {
+		data = append(data, p...)
+	}
+	return data
+}
+
+// writeMP4Tags rebuilds moov/udta/meta/ilst and copies the rest of the file as is, fixing chunk offsets if moov changed size
+// and is placed before the media data.
+func writeMP4Tags(in *os.File, out io.Writer, tags tagSet) error {
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	var moovStart, moovSize int64 = -1, 0
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= stat.Size(); {
+		n, err := in.ReadAt(header, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		size, _, boxType, ok := mp4BoxHeader(header[:n])
+		if !ok {
+			return errors.New("invalid mp4 box")
+		}
+		if size == 0 {
+			size = stat.Size() - offset
+		}
+		if size < 8 || size > stat.Size()-offset {
+			return errors.New("invalid mp4 box size")
+		}
+		if boxType == "moov" {
+			moovStart, moovSize = offset, size
+			break
+		}
+		offset += size
+	}
+	if moovStart < 0 {
+		return errors.New("moov box not found")
+	}
+	if moovSize > probeMaxBoxSize {
+		return errors.New("moov box is too big")
+	}
+
+	moov := make([]byte, moovSize)
+	if _, err := in.ReadAt(moov, moovStart); err != nil {
+		return err
+	}
+	_, headerLen, _, _ := mp4BoxHeader(moov)
+	children := rebuildMP4Children(moov[headerLen:], "udta", func(udta []byte) []byte {
+		return rebuildMP4Children(udta, "meta", func(meta []byte) []byte {
+			if len(meta) < 4 {
+				meta = []byte{0, 0, 0, 0}
+			}
+			items := meta[4:]
+			if !mp4HasChild(items, "hdlr") {
+				items = appendMP4Box(append([]byte{}, items...), "hdlr", make([]byte, 8), []byte("mdirappl"), make([]byte, 9))
+			}
+			return append(append([]byte{}, meta[:4]...), rebuildMP4Children(items, "ilst", func(ilst []byte) []byte {
+				return rebuildIlst(ilst, tags)
+			})...)
+		})
+	})
+	newMoov := appendMP4Box(nil, "moov", children)
+
+	if delta := int64(len(newMoov)) - moovSize; delta != 0 {
+		if err := shiftChunkOffsets(newMoov[8:], moovStart, delta); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.Copy(out, io.NewSectionReader(in, 0, moovStart)); err != nil {
+		return err
+	}
+	if _, err := out.Write(newMoov); err != nil {
+		return err
+	}
+	_, err = io.Copy(out, io.NewSectionReader(in, moovStart+moovSize, stat.Size()-moovStart-moovSize))
+	return err
+}
+
+func mp4HasChild(data []byte, boxType string) (found bool) {
+	mp4Boxes(data, func(t string, _ []byte) {
+		found = found || t == boxType
+	})
+	return found
+}
+
+// rebuildMP4Children copies the boxes in data, replacing the payload of the boxType child with rebuild(payload).
+// The child is created (rebuild(nil)) if it doesn't exist.
+func rebuildMP4Children(data []byte, boxType string, rebuild func(payload []byte) []byte) []byte {
+	var rebuilt []byte
+	found := false
+	mp4Boxes(data, func(t string, payload []byte) {
+		if t == boxType && !found {
+			found = true
+			rebuilt = appendMP4Box(rebuilt, t, rebuild(payload))
+			return
+		}
+		rebuilt = appendMP4Box(rebuilt, t, payload)
+	})
+	if !found {
+		rebuilt = appendMP4Box(rebuilt, boxType, rebuild(nil))
+	}
+	return rebuilt
+}
+
+func rebuildIlst(ilst []byte, tags tagSet) []byte {
+	items := map[string][]byte{}
+	text := func(value string) []byte {
+		return appendMP4Box(nil, "data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, []byte(value))
+	}
+	if tags.Title != "" {
+		items["\xa9nam"] = text(tags.Title)
+	}
+	if tags.Artist != "" {
+		items["\xa9ART"] = text(tags.Artist)
+	}
+	if tags.Album != "" {
+		items["\xa9alb"] = text(tags.Album)
+	}
+	if tags.Track > 0 && tags.Track <= math.MaxUint16 {
+		items["trkn"] = appendMP4Box(nil, "data", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, byte(tags.Track >> 8), byte(tags.Track), 0, 0, 0, 0})
+	}
+	if tags.SourceURL != "" {
+		items["purl"] = text(tags.SourceURL)
+	}
+	if len(tags.cover) > 0 {
+		//Data type 13 is jpeg and 14 png.
+		dataType := byte(13)
+		if mimeType, _ := coverType(tags.cover); mimeType == "image/png" {
+			dataType = 14
+		}
+		items["covr"] = appendMP4Box(nil, "data", []byte{0, 0, 0, dataType, 0, 0, 0, 0}, tags.cover)
+	}
+
+	var rebuilt []byte
+	mp4Boxes(ilst, func(t string, payload []byte) {
+		if _, replaced := items[t]; !replaced {
+			rebuilt = appendMP4Box(rebuilt, t, payload)
+		}
+	})
+	for _, t := range []string{"\xa9nam", "\xa9ART", "\xa9alb", "trkn", "purl", "covr"} {
+		if item, ok := items[t]; ok {
+			rebuilt = appendMP4Box(rebuilt, t, item)
+		}
+	}
+	return rebuilt
+}
+
+// shiftChunkOffsets adds delta to every stco/co64 chunk offset after moovStart, the media data moved by delta bytes.
+func shiftChunkOffsets(moov []byte, moovStart, delta int64) (err error) {
+	var walk func(data []byte)
+	walk = func(data []byte) {
+		mp4Boxes(data, func(boxType string, payload []byte) {
+			switch boxType {
+			case "trak", "mdia", "minf", "stbl":
+				walk(payload)
+			case "stco", "co64":
+				if len(payload) < 8 {
+					return
+				}
+				entrySize := 4
+				if boxType == "co64" {
+					entrySize = 8
+				}
+				count := int(binary.BigEndian.Uint32(payload[4:8]))
+				entries := payload[8:]
+				if count > len(entries)/entrySize {
+					err = errors.New("invalid chunk offset table")
+					return
+				}
+				for i := 0; i < count; i++ {
+					entry := entries[i*entrySize:]
+					if entrySize == 4 {
+						offset := int64(binary.BigEndian.Uint32(entry))
+						if offset > moovStart {
+							if offset+delta > math.MaxUint32 || offset+delta < 0 {
+								err = errors.New("chunk offset out of range after tagging")
+								return
+							}
+							binary.BigEndian.PutUint32(entry, uint32(offset+delta))
+						}
+					} else if offset := int64(binary.BigEndian.Uint64(entry)); offset > moovStart {
+						binary.BigEndian.PutUint64(entry, uint64(offset+delta))
+					}
+				}
+			}
+		})
+	}
+	walk(moov)
+	return err
+}
@@ -0,0 +1,163 @@
+package gobalt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTagsFromFilename(t *testing.T) {
+	tests := map[string]Tags{
+		"Never Gonna Give You Up - Rick Astley.mp3":               {Title: "Never Gonna Give You Up", Artist: "Rick Astley"},
+		"Song - Live - Artist (soundcloud).opus":                  {Title: "Song - Live", Artist: "Artist"},
+		"Audio Title - Audio Author (soundcloud, 1242868615).mp3": {Title: "Audio Title", Artist: "Audio Author"},
+		"youtube_yPYZpwSpKmA_audio.mp3":                           {},
+		"Just A Title.ogg":                                        {Title: "Just A Title"},
+		"Title (Official Video) - Channel.mp3":                    {Title: "Title (Official Video)", Artist: "Channel"},
+		"Title - Channel (Remastered 2009).mp3":                   {Title: "Title", Artist: "Channel (Remastered 2009)"},
+	}
+	for filename, expected := range tests {
+		if got := TagsFromFilename(filename); got != expected {
+			t.Errorf("TagsFromFilename(%q) = %+v, expected %+v", filename, got, expected)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteID3Tags(t *testing.T) {
+	audio := append([]byte{0xFF, 0xFB, 0x90, 0x64}, bytes.Repeat([]byte{0xAA}, 1000)...)
+	path := writeTestFile(t, "song.mp3", audio)
+
+	if err := WriteTags(path, Tags{Title: "Título", Artist: "Artist", SourceURL: "https://soundcloud.com/a/b"}); err != nil {
+		t.Fatalf("failed writing tags: %v", err)
+	}
+	if err := WriteTags(path, Tags{Album: "Album", Track: 3}); err != nil {
+		t.Fatalf("failed writing tags again: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(data, []byte{'I', 'D', '3', 4, 0, 0}) {
+		t.Fatalf("expected an id3v2.4 header, got %q", data[:10])
+	}
+	tagSize := int(syncsafe(data[6:10]))
+	tag := data[10 : 10+tagSize]
+	for _, expected := range []string{"TIT2\x00\x00\x00\x08\x00\x00\x03Título", "TPE1", "WOAS", "TALB", "TRCK\x00\x00\x00\x02\x00\x00\x033"} {
+		if !bytes.Contains(tag, []byte(expected)) {
+			t.Errorf("expected %q in the tag %q", expected, tag)
+		}
+	}
+	if !bytes.Equal(data[10+tagSize:], audio) {
+		t.Fatal("audio data changed after tagging")
+	}
+}
+
+func testOggOpus(t *testing.T) []byte {
+	t.Helper()
+	var file []byte
+	head := &oggPage{headerType: 0x02, serial: 42}
+	addOggSegments(head, append([]byte("OpusHead"), 1, 2, 0x38, 1, 0x80, 0xBB, 0, 0, 0, 0, 0))
+	tags := &oggPage{serial: 42, sequence: 1}
+	addOggSegments(tags, append([]byte("OpusTags\x06\x00\x00\x00vendor\x01\x00\x00\x00"), "\x0b\x00\x00\x00TITLE=old12"...))
+	file = append(file, head.bytes()...)
+	file = append(file, tags.bytes()...)
+	for i := uint32(2); i < 5; i++ {
+		audio := &oggPage{serial: 42, sequence: i, granule: uint64(i) * 960}
+		addOggSegments(audio, bytes.Repeat([]byte{byte(i)}, 300))
+		file = append(file, audio.bytes()...)
+	}
+	return file
+}
+
+func TestWriteOggTags(t *testing.T) {
+	path := writeTestFile(t, "song.opus", testOggOpus(t))
+
+	//A title this long needs more than one page, so the audio pages must be renumbered.
+	longTitle := strings.Repeat("a", 70000)
+	if err := WriteTags(path, Tags{Title: longTitle, Artist: "Artist"}); err != nil {
+		t.Fatalf("failed writing tags: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	r := bytes.NewReader(data)
+	var comment []byte
+	for sequence := uint32(0); r.Len() > 0; sequence++ {
+		start := len(data) - r.Len()
+		page, err := readOggPage(r)
+		if err != nil {
+			t.Fatalf("failed reading page %v: %v", sequence, err)
+		}
+		raw := append([]byte{}, data[start:len(data)-r.Len()]...)
+		crc := binary.LittleEndian.Uint32(raw[22:26])
+		binary.LittleEndian.PutUint32(raw[22:26], 0)
+		if oggCRC(raw) != crc {
+			t.Fatalf("page %v has an invalid crc", sequence)
+		}
+		if page.sequence != sequence {
+			t.Fatalf("page %v has sequence number %v", sequence, page.sequence)
+		}
+		if sequence > 0 && (page.granule == 0 || page.granule == math.MaxUint64) {
+			comment = append(comment, page.data...)
+		}
+	}
+	if !bytes.Contains(comment, []byte("TITLE="+longTitle)) || !bytes.Contains(comment, []byte("ARTIST=Artist")) || bytes.Contains(comment, []byte("TITLE=old")) {
+		t.Fatal("comment header doesn't have the expected tags")
+	}
+}
+
+func TestWriteMP4Tags(t *testing.T) {
+	mdat := append([]byte("MARK"), make([]byte, 100)...)
+	stco := func(offset uint32) []byte {
+		return mp4Box("stco", []byte{0, 0, 0, 0, 0, 0, 0, 1}, binary.BigEndian.AppendUint32(nil, offset))
+	}
+	buildMoov := func(offset uint32) []byte {
+		return mp4Box("moov", mp4Box("trak", mp4Box("mdia", mp4Box("minf", mp4Box("stbl", stco(offset))))))
+	}
+	ftyp := mp4Box("ftyp", []byte("M4A "))
+	moovLen := len(buildMoov(0))
+	mdatOffset := uint32(len(ftyp) + moovLen + 8)
+	path := writeTestFile(t, "song.m4a", bytes.Join([][]byte{ftyp, buildMoov(mdatOffset), mp4Box("mdat", mdat)}, nil))
+
+	if err := WriteTags(path, Tags{Title: "Title", Track: 2}); err != nil {
+		t.Fatalf("failed writing tags: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var newOffset uint32
+	var ilst []byte
+	mp4Boxes(data, func(boxType string, payload []byte) {
+		if boxType != "moov" {
+			return
+		}
+		i := bytes.Index(payload, []byte("stco"))
+		newOffset = binary.BigEndian.Uint32(payload[i+12:])
+		if i := bytes.Index(payload, []byte("ilst")); i >= 0 {
+			ilst = payload[i:]
+		}
+	})
+	if !bytes.Equal(data[newOffset:newOffset+4], []byte("MARK")) {
+		t.Fatalf("chunk offset %v doesn't point to the media data anymore", newOffset)
+	}
+	if !bytes.Contains(ilst, []byte("\xa9nam")) || !bytes.Contains(ilst, []byte("Title")) || !bytes.Contains(ilst, []byte("trkn")) {
+		t.Fatalf("ilst doesn't have the expected tags: %q", ilst)
+	}
+}
+
+func TestWriteTagsUnsupported(t *testing.T) {
+	path := writeTestFile(t, "song.wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "))
+	if err := WriteTags(path, Tags{Title: "Title"}); !errors.Is(err, ErrUnsupportedTagFormat) {
+		t.Fatalf("expected ErrUnsupportedTagFormat, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package gobalt
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Output filename templates, like "{title} - {uploader} [{quality}].{ext}", so downloads aren't stuck with cobalt's four filename styles.
+//
+// Available fields:
+//
+//   - {title}, {uploader}: title and author, from the cobalt filename.
+//   - {filename}: the cobalt filename without extension.
+//   - {ext}: file extension, from the cobalt filename or the content type.
+//   - {quality}, {codec}: like 1080p and h264, from the cobalt filename or ProcessMedia.
+//   - {service}, {id}: like youtube and dQw4w9WgXcQ, from classic and nerdy style filenames.
+//   - {width}, {height}, {audio_codec}, {duration} (seconds), {size} (bytes): from ProcessMedia.
+//   - {index}, {type}: position and type of a picker item, when downloaded with DownloadPicker.
+//
+// Fields without a value are removed along with the " - " or " | " before them, and brackets left empty are removed.
+// Use {{ and }} for literal braces.
+
+var (
+	//Fields capture the separator before them, so "{title} - {uploader}" becomes "Title" without an uploader.
+	templateField = regexp.MustCompile(`\{\{|\}\}|(\s*[-|]\s*)?\{([a-z_]+)\}`)
+	emptyBrackets = regexp.MustCompile(`\s*(\(\s*\)|\[\s*\])`)
+
+	//Video Title (1080p, h264) | Video Title (1080p, h264, youtube) | Video Title (1080p, h264, youtube, yPYZpwSpKmA)
+	videoFilename = regexp.MustCompile(`^(.*) \((\d+p), ([a-z0-9]+)(?:, ([a-z ]+))?(?:, ([^)]+))?\)$`)
+	//Audio Title - Audio Author (soundcloud, 1242868615)
+	nerdyAudioFilename = regexp.MustCompile(`^.* \(([a-z ]+), ([^)]+)\)$`)
+	//youtube_yPYZpwSpKmA_1920x1080_h264 | youtube_yPYZpwSpKmA_audio
+	classicFilename = regexp.MustCompile(`^([a-z]+)_(\S+?)(?:_(\d+)x(\d+)_([a-z0-9]+)|_audio)?$`)
+)
+
+// TemplateFields collects the template fields from a cobalt response and, if not nil, the media info from ProcessMedia.
+func TemplateFields(media *CobaltResponse, info *MediaInfo) map[string]string {
+	fields := map[string]string{}
+	if media != nil && media.Filename != "" {
+		var ext string
+		if hasExtension(media.Filename) {
+			ext = filepath.Ext(media.Filename)
+		}
+		name := strings.TrimSuffix(media.Filename, ext)
+		fields["filename"] = name
+		fields["ext"] = strings.TrimPrefix(ext, ".")
+
+		if m := classicFilename.FindStringSubmatch(name); m != nil && isKnownService(m[1]) {
+			fields["service"], fields["id"] = m[1], m[2]
+			if m[3] != "" {
+				fields["width"], fields["height"], fields["codec"], fields["quality"] = m[3], m[4], m[5], m[4]+"p"
+			}
+		} else if m := videoFilename.FindStringSubmatch(name); m != nil {
+			fields["title"], fields["quality"], fields["codec"], fields["service"], fields["id"] = m[1], m[2], m[3], m[4], m[5]
+		} else {
+			tags := TagsFromFilename(media.Filename)
+			fields["title"], fields["uploader"] = tags.Title, tags.Artist
+			if m := nerdyAudioFilename.FindStringSubmatch(name); m != nil {
+				fields["service"], fields["id"] = m[1], m[2]
+			}
+		}
+	}
+
+	if info != nil {
+		if fields["ext"] == "" {
+			fields["ext"] = strings.TrimPrefix(ExtensionByType(info.Type), ".")
+		}
+		if info.Width > 0 && info.Height > 0 {
+			fields["width"], fields["height"] = strconv.Itoa(info.Width), strconv.Itoa(info.Height)
+			fields["quality"] = strconv.Itoa(info.Height) + "p"
+		}
+		if info.VideoCodec != "" {
+			fields["codec"] = info.VideoCodec
+		}
+		if info.AudioCodec != "" {
+			fields["audio_codec"] = info.AudioCodec
+		}
+		if info.Duration > 0 {
+			fields["duration"] = strconv.Itoa(int(info.Duration.Seconds()))
+		}
+		if info.Size > 0 {
+			fields["size"] = strconv.FormatUint(uint64(info.Size), 10)
+		}
+	}
+
+	for key, value := range fields {
+		if value == "" {
+			delete(fields, key)
+		}
+	}
+	return fields
+}
+
+func isKnownService(name string) bool {
+	for _, service := range serviceHosts {
+		if service == ServiceName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderFilenameTemplate replaces the {field} placeholders in template. Missing fields are removed with the separator
+// before them, and brackets left empty by them are removed. The result should still be passed to SanitizeFilename.
+func RenderFilenameTemplate(template string, fields map[string]string) string {
+	rendered := templateField.ReplaceAllStringFunc(template, func(match string) string {
+		switch match {
+		case "{{":
+			return "\x00"
+		case "}}":
+			return "\x01"
+		}
+		m := templateField.FindStringSubmatch(match)
+		if value := fields[m[2]]; value != "" {
+			return m[1] + value
+		}
+		return ""
+	})
+	rendered = emptyBrackets.ReplaceAllString(rendered, "")
+	rendered = strings.Join(strings.Fields(rendered), " ")
+	rendered = strings.Trim(rendered, " -_.")
+	return strings.NewReplacer("\x00", "{", "\x01", "}").Replace(rendered)
+}
@@ -0,0 +1,52 @@
+package gobalt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderFilenameTemplate(t *testing.T) {
+	tests := []struct {
+		filename string
+		info     *MediaInfo
+		template string
+		expected string
+	}{
+		{"Never Gonna Give You Up - Rick Astley.mp3", nil, "{uploader} - {title}.{ext}", "Rick Astley - Never Gonna Give You Up.mp3"},
+		{"Video Title (1080p, h264, youtube, yPYZpwSpKmA).mp4", nil, "{title} [{quality}] {{{id}}}.{ext}", "Video Title [1080p] {yPYZpwSpKmA}.mp4"},
+		{"youtube_yPY_ZpwSpKmA_1920x1080_h264.mp4", nil, "{service}-{id}-{width}x{height}.{ext}", "youtube-yPY_ZpwSpKmA-1920x1080.mp4"},
+		{"Video Title (720p, vp9).webm", nil, "{title} - {uploader} [{quality}].{ext}", "Video Title [720p].webm"},
+		{"", &MediaInfo{Type: "audio/mpeg", Duration: 90 * time.Second, Height: 480, Width: 854}, "{title} {duration}s ({quality}).{ext}", "90s (480p).mp3"},
+		{"Song - Artist.opus", nil, "{title} - {missing}", "Song"},
+	}
+	for _, test := range tests {
+		fields := TemplateFields(&CobaltResponse{Filename: test.filename}, test.info)
+		if got := RenderFilenameTemplate(test.template, fields); got != test.expected {
+			t.Errorf("RenderFilenameTemplate(%q) with %q = %q, expected %q", test.template, test.filename, got, test.expected)
+		}
+	}
+}
+
+func TestDownloadTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	result, err := Download(&CobaltResponse{URL: server.URL, Filename: "Song - Artist.mp3"}, DownloadOptions{
+		Directory: dir,
+		Template:  "{track}. {uploader} - {title}.{ext}",
+		Fields:    map[string]string{"track": "01"},
+	})
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if result.Path != filepath.Join(dir, "01. Artist - Song.mp3") {
+		t.Fatalf("unexpected path %v", result.Path)
+	}
+}
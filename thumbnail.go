@@ -0,0 +1,95 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Thumbnails of YouTube videos, which are served from predictable urls in a few sizes.
+
+// youtubeThumbnailHost serves the thumbnails of YouTube videos.
+var youtubeThumbnailHost = "https://i.ytimg.com"
+
+// thumbnailSizes are the names of the thumbnails of a video, from the biggest to the smallest.
+// maxresdefault (1280x720) and sddefault (640x480) only exist for videos uploaded in a high enough quality, hqdefault (480x360) always does.
+var thumbnailSizes = []string{"maxresdefault", "sddefault", "hqdefault", "mqdefault", "default"}
+
+// ThumbnailURLs returns the urls of every size of thumbnail of a YouTube video, from the biggest to the smallest. Not every size exists.
+func ThumbnailURLs(videoID string) []string {
+	urls := make([]string, len(thumbnailSizes))
+	for i, size := range thumbnailSizes {
+		urls[i] = fmt.Sprintf("%v/vi/%v/%v.jpg", youtubeThumbnailHost, videoID, size)
+	}
+	return urls
+}
+
+// videoThumbnail is the hqdefault thumbnail of a video, the biggest one that always exists.
+func videoThumbnail(videoID string) string {
+	return ThumbnailURLs(videoID)[2]
+}
+
+// BestThumbnail finds the biggest thumbnail of a YouTube video, see the method.
+func BestThumbnail(ctx context.Context, videoID string) (string, error) {
+	return defaultCobalt().BestThumbnail(ctx, videoID)
+}
+
+// BestThumbnail returns the url of the biggest thumbnail that exists for a YouTube video, checking them from maxresdefault down
+// with HEAD requests made with the http client of this Cobalt.
+func (c *Cobalt) BestThumbnail(ctx context.Context, videoID string) (string, error) {
+	if videoID == "" || strings.ContainsAny(videoID, "/?#") {
+		return "", fmt.Errorf("invalid youtube video id %q", videoID)
+	}
+	var lastErr error
+	for _, thumbnail := range ThumbnailURLs(videoID) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodHead, thumbnail, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create the request to %v: %w", thumbnail, err)
+		}
+		request.Header.Add("User-Agent", c.userAgent)
+		res, err := c.httpClient.Do(request)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", err
+			}
+			lastErr = err
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			return thumbnail, nil
+		}
+		lastErr = fmt.Errorf("request failed with %v", res.Status)
+	}
+	return "", fmt.Errorf("no thumbnail found for %v: %w", videoID, lastErr)
+}
+
+// DownloadThumbnail downloads the biggest thumbnail of a YouTube video, see the method.
+func DownloadThumbnail(ctx context.Context, videoID string, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadThumbnail(ctx, videoID, options)
+}
+
+// DownloadThumbnail downloads the biggest thumbnail of a YouTube video (see BestThumbnail) like Download, saving it as <video id>.jpg
+// unless options.Filename is set.
+func (c *Cobalt) DownloadThumbnail(ctx context.Context, videoID string, options DownloadOptions) (*DownloadResult, error) {
+	thumbnail, err := c.BestThumbnail(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	return c.DownloadContext(ctx, &CobaltResponse{Status: "tunnel", URL: thumbnail, Filename: videoID + ".jpg"}, options)
+}
+
+// rendererThumbnail returns the biggest thumbnail of a renderer of the YouTube web clients, like
+// {"thumbnail":{"thumbnails":[{"url":"...","width":120},{"url":"...","width":480}]}}.
+func rendererThumbnail(renderer map[string]any) string {
+	thumbnails, _ := musicPath(renderer, "thumbnail", "thumbnails").([]any)
+	if len(thumbnails) == 0 {
+		return ""
+	}
+	thumbnail := musicString(thumbnails[len(thumbnails)-1], "url")
+	if strings.HasPrefix(thumbnail, "//") {
+		thumbnail = "https:" + thumbnail
+	}
+	return thumbnail
+}
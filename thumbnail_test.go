@@ -0,0 +1,48 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestThumbnails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//Like YouTube for videos uploaded in a low quality, no maxresdefault.
+		if r.URL.Path == "/vi/aaaaaaaaaaa/maxresdefault.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("jpeg"))
+	}))
+	defer server.Close()
+	oldHost := youtubeThumbnailHost
+	youtubeThumbnailHost = server.URL
+	defer func() { youtubeThumbnailHost = oldHost }()
+
+	urls := ThumbnailURLs("aaaaaaaaaaa")
+	if len(urls) != 5 || urls[0] != server.URL+"/vi/aaaaaaaaaaa/maxresdefault.jpg" || urls[4] != server.URL+"/vi/aaaaaaaaaaa/default.jpg" {
+		t.Errorf("unexpected thumbnail urls %v", urls)
+	}
+
+	best, err := BestThumbnail(context.Background(), "aaaaaaaaaaa")
+	if err != nil || best != server.URL+"/vi/aaaaaaaaaaa/sddefault.jpg" {
+		t.Errorf("expected the sddefault thumbnail, got %v, %v", best, err)
+	}
+	if _, err := BestThumbnail(context.Background(), "../secret"); err == nil {
+		t.Error("expected an error for an invalid video id")
+	}
+
+	dir := t.TempDir()
+	result, err := DownloadThumbnail(context.Background(), "aaaaaaaaaaa", DownloadOptions{Directory: dir})
+	if err != nil {
+		t.Fatalf("failed to download the thumbnail: %v", err)
+	}
+	if data, _ := os.ReadFile(result.Path); result.Path != filepath.Join(dir, "aaaaaaaaaaa.jpg") || string(data) != "jpeg" {
+		t.Errorf("unexpected download %+v with %q", result, data)
+	}
+}
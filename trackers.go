@@ -0,0 +1,173 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/go-version"
+)
+
+// Instance lists from several trackers, merged so the list stays usable when a tracker is stale or down.
+
+// DefaultTrackers are the instance trackers used by MergeInstanceTrackers when none is given.
+// They must serve a json array in the format of instances.hyper.lol.
+var DefaultTrackers = []string{"https://instances.hyper.lol/instances.json"}
+
+// trackerList is the list of instances from one tracker.
+type trackerList struct {
+	instances []CobaltInstance
+	updated   time.Time //When the tracker updated the list, from Last-Modified or Date. Zero if unknown.
+	err       error
+}
+
+// MergeInstanceTrackers gets the instance lists from the trackers concurrently and merges them, see the method.
+func MergeInstanceTrackers(ctx context.Context, trackers ...string) ([]CobaltInstance, error) {
+	return defaultCobalt().MergeInstanceTrackers(ctx, trackers...)
+}
+
+// MergeInstanceTrackers gets the instance lists from trackers (DefaultTrackers if none) concurrently, and merges the instances by
+// api hostname. When trackers disagree on an instance, the tracker with the most recently updated list wins, and the fields it
+// doesn't have are taken from the others. Like GetCobaltInstances, only 10.0.0+ instances are returned.
+//
+// Trackers that fail are skipped, an error is only returned if all of them failed.
+func (c *Cobalt) MergeInstanceTrackers(ctx context.Context, trackers ...string) ([]CobaltInstance, error) {
+	if len(trackers) == 0 {
+		trackers = DefaultTrackers
+	}
+
+	lists := make([]trackerList, len(trackers))
+	var wg sync.WaitGroup
+	for i, tracker := range trackers {
+		wg.Add(1)
+		go func(i int, tracker string) {
+			defer wg.Done()
+			lists[i] = c.getTracker(ctx, tracker)
+		}(i, tracker)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, list := range lists {
+		if list.err != nil {
+			errs = append(errs, list.err)
+		}
+	}
+	if len(errs) == len(lists) {
+		return nil, fmt.Errorf("every instance tracker failed: %w", errors.Join(errs...))
+	}
+
+	return mergeTrackerLists(lists), nil
+}
+
+func (c *Cobalt) getTracker(ctx context.Context, tracker string) trackerList {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, tracker, nil)
+	if err != nil {
+		return trackerList{err: fmt.Errorf("failed to create the request to %v: %w", tracker, err)}
+	}
+	request.Header.Add("User-Agent", c.userAgent)
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return trackerList{err: err}
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return trackerList{err: fmt.Errorf("tracker %v answered with %v", tracker, response.Status)}
+	}
+
+	body, err := c.readBody(response)
+	if err != nil {
+		return trackerList{err: err}
+	}
+	list := trackerList{}
+	if err := json.Unmarshal(body, &list.instances); err != nil {
+		return trackerList{err: fmt.Errorf("invalid instance list from %v: %w", tracker, err)}
+	}
+	for _, header := range []string{"Last-Modified", "Date"} {
+		if updated, err := http.ParseTime(response.Header.Get(header)); err == nil {
+			list.updated = updated
+			break
+		}
+	}
+	return list
+}
+
+// mergeTrackerLists merges the lists by api hostname, keeping the order the instances were first seen in.
+func mergeTrackerLists(lists []trackerList) []CobaltInstance {
+	type merged struct {
+		instance CobaltInstance
+		updated  time.Time
+	}
+	var order []string
+	instances := map[string]*merged{}
+	for _, list := range lists {
+		for _, instance := range list.instances {
+			host := instanceHost(instance.API)
+			if host == "" {
+				continue
+			}
+			current, ok := instances[host]
+			if !ok {
+				instances[host] = &merged{instance, list.updated}
+				order = append(order, host)
+				continue
+			}
+			if list.updated.After(current.updated) {
+				current.instance = fillInstance(instance, current.instance)
+				current.updated = list.updated
+			} else {
+				current.instance = fillInstance(current.instance, instance)
+			}
+		}
+	}
+
+	result := make([]CobaltInstance, 0, len(order))
+	for _, host := range order {
+		if instance := instances[host].instance; version.Compare(instance.Version, "10.0.0", ">=") {
+			result = append(result, instance)
+		}
+	}
+	return result
+}
+
+// fillInstance returns fresh with its empty fields taken from stale.
+func fillInstance(fresh, stale CobaltInstance) CobaltInstance {
+	for _, field := range []struct{ fresh, stale *string }{
+		{&fresh.Trust, &stale.Trust},
+		{&fresh.Commit, &stale.Commit},
+		{&fresh.Version, &stale.Version},
+		{&fresh.Branch, &stale.Branch},
+		{&fresh.Protocol, &stale.Protocol},
+		{&fresh.Name, &stale.Name},
+		{&fresh.FrontEnd, &stale.FrontEnd},
+	} {
+		if *field.fresh == "" {
+			*field.fresh = *field.stale
+		}
+	}
+	if fresh.StartTime == 0 {
+		fresh.StartTime = stale.StartTime
+	}
+	if fresh.Services == (Services{}) {
+		fresh.Services = stale.Services
+	}
+	return fresh
+}
+
+// instanceHost returns the lowercase hostname of an instance api url, which trackers may list without a scheme.
+func instanceHost(api string) string {
+	if !strings.Contains(api, "://") {
+		api = "https://" + api
+	}
+	u, err := url.Parse(api)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+}
@@ -0,0 +1,50 @@
+package gobalt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMergeInstanceTrackers(t *testing.T) {
+	tracker := func(updated time.Time, list string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Last-Modified", updated.UTC().Format(http.TimeFormat))
+			w.Write([]byte(list))
+		}))
+	}
+	stale := tracker(time.Now().Add(-24*time.Hour), `[
+		{"api":"cobalt.example.com","version":"10.1.0","name":"example","trust":"trusted","services":{"youtube":true}},
+		{"api":"https://old.example.com","version":"7.15.0"},
+		{"api":"only-stale.example.com","version":"10.4.0"}
+	]`)
+	defer stale.Close()
+	fresh := tracker(time.Now(), `[
+		{"api":"https://COBALT.example.com/","version":"10.5.4","api_online":true},
+		{"api":"new.example.com","version":"11.0.0"}
+	]`)
+	defer fresh.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+
+	instances, err := MergeInstanceTrackers(context.Background(), stale.URL, down.URL, fresh.URL)
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if len(instances) != 3 {
+		t.Fatalf("expected 3 modern instances, got %+v", instances)
+	}
+	merged := instances[0]
+	if merged.Version != "10.5.4" || !merged.APIOnline || merged.Name != "example" || merged.Trust != "trusted" || !merged.Services.Youtube {
+		t.Errorf("expected the fresh data filled with the stale data, got %+v", merged)
+	}
+	if instances[1].API != "only-stale.example.com" || instances[2].API != "new.example.com" {
+		t.Errorf("unexpected order %+v", instances)
+	}
+
+	if _, err := MergeInstanceTrackers(context.Background(), down.URL); err == nil {
+		t.Errorf("expected an error when every tracker fails")
+	}
+}
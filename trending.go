@@ -0,0 +1,56 @@
+package gobalt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The trending feed of YouTube, for bots that surface popular media.
+
+type trendingCategory string
+
+const (
+	TrendingNow    trendingCategory = ""                                             //Videos trending in every category.
+	TrendingMusic  trendingCategory = "4gINGgt5dG1hX2NoYXJ0cw=="                     //Music videos.
+	TrendingGaming trendingCategory = "4gIcGhpnYW1pbmdfY29ycHVzX21vc3RfcG9wdWxhcg==" //Gaming videos.
+	TrendingMovies trendingCategory = "4gIKGgh0cmFpbGVycw=="                         //Movie trailers.
+)
+
+// GetTrending gets the trending videos of a region, see the method.
+func GetTrending(ctx context.Context, region string, category trendingCategory) ([]PlaylistEntry, error) {
+	return defaultCobalt().Trending(ctx, region, category)
+}
+
+// Trending gets the trending videos of a category in a region, a country code like US or BR (empty for the default of YouTube),
+// using the http client of this Cobalt. Index is the position in the feed. Videos shown in more than one shelf are only returned once.
+func (c *Cobalt) Trending(ctx context.Context, region string, category trendingCategory) ([]PlaylistEntry, error) {
+	client := youtubeClient
+	client.region = strings.ToUpper(region)
+	request := map[string]any{"browseId": "FEtrending"}
+	if category != TrendingNow {
+		request["params"] = string(category)
+	}
+
+	var response any
+	if err := c.innertubeRequest(ctx, client, *client.api, request, &response); err != nil {
+		return nil, err
+	}
+	walked := &musicWalk{}
+	walked.walk(response)
+
+	var entries []PlaylistEntry
+	seen := make(map[string]bool)
+	for _, item := range walked.results {
+		result, ok := searchResult(item)
+		if !ok || result.Type != VideoResults || seen[result.ID] {
+			continue
+		}
+		seen[result.ID] = true
+		entries = append(entries, PlaylistEntry{URL: result.URL, VideoID: result.ID, Title: result.Title, Author: result.Author, Index: len(entries) + 1, Duration: result.Duration, Thumbnail: result.Thumbnail})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no videos in the trending feed", ErrUnexpectedResponse)
+	}
+	return entries, nil
+}
@@ -0,0 +1,41 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTrending(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		fmt.Fprint(w, `{"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"content":{"sectionListRenderer":{"contents":[
+			{"shelfRenderer":{"content":{"expandedShelfContentsRenderer":{"items":[
+				{"videoRenderer":{"videoId":"aaaaaaaaaaa","title":{"runs":[{"text":"First"}]},"ownerText":{"runs":[{"text":"Channel"}]},"lengthText":{"simpleText":"4:05"}}},
+				{"videoRenderer":{"videoId":"bbbbbbbbbbb","title":{"runs":[{"text":"Second"}]}}}]}}}},
+			{"shelfRenderer":{"content":{"expandedShelfContentsRenderer":{"items":[
+				{"videoRenderer":{"videoId":"aaaaaaaaaaa","title":{"runs":[{"text":"First"}]}}}]}}}}]}}}}]}}}`)
+	}))
+	defer server.Close()
+	oldAPI := youtubeAPI
+	youtubeAPI = server.URL
+	defer func() { youtubeAPI = oldAPI }()
+
+	entries, err := GetTrending(context.Background(), "br", TrendingMusic)
+	if err != nil {
+		t.Fatalf("failed to get trending videos: %v", err)
+	}
+	client, _ := request["context"].(map[string]any)["client"].(map[string]any)
+	if request["browseId"] != "FEtrending" || request["params"] != string(TrendingMusic) || client["gl"] != "BR" {
+		t.Errorf("unexpected request %v", request)
+	}
+	expected := PlaylistEntry{URL: "https://www.youtube.com/watch?v=aaaaaaaaaaa", VideoID: "aaaaaaaaaaa", Title: "First", Author: "Channel", Index: 1, Duration: 4*time.Minute + 5*time.Second, Thumbnail: "https://i.ytimg.com/vi/aaaaaaaaaaa/hqdefault.jpg"}
+	if len(entries) != 2 || entries[0] != expected || entries[1].Index != 2 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
@@ -0,0 +1,242 @@
+package gobalt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mcuadros/go-version"
+)
+
+// Automated trust assessment of cobalt instances. The "trust" field from the instance tracker only says who runs the instance,
+// these checks look at how the instance actually behaves.
+
+// TrustOptions enables the more expensive checks of AssessInstance.
+type TrustOptions struct {
+	TestURL      string //Media url submitted to the instance to check the returned filename and file sizes. Empty skips these checks.
+	VerifyCommit bool   //Compares the reported version against the api/package.json of the reported git commit on GitHub.
+}
+
+// TrustCheck is the result of a single check made by AssessInstance.
+type TrustCheck struct {
+	Name    string  //Name of the check: tls, headers, version, commit, filename or sizes.
+	Passed  bool    //If the instance passed the check.
+	Skipped bool    //The check couldn't be made (not enabled, or the information wasn't available). Skipped checks don't count to the score.
+	Detail  string  //Why the check failed or was skipped.
+	Weight  float64 //How much the check counts to the score.
+}
+
+// TrustAssessment is returned by AssessInstance.
+type TrustAssessment struct {
+	API    string       //Instance api url assessed.
+	Score  float64      //From 0 (untrustworthy) to 1, the weighted share of checks passed.
+	Checks []TrustCheck //Every check made, including the skipped ones.
+}
+
+// Urls, domains, handles and html in filenames are a sign of instances injecting ads into the media they serve.
+var filenameInjection = regexp.MustCompile(`(?i)(https?://|www\.|t\.me/|discord\.gg|<[a-z/][^>]*>|\.(com|net|org|ru|io|gg|xyz|top|link)\b)`)
+
+var (
+	gitCommitHash = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	gitRemoteName = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+)
+
+// AssessInstance checks an instance for a valid TLS chain, sane response headers, consistent version information and,
+// depending on options, content injection in filenames and consistent file sizes. Returns an error only if the instance is unreachable.
+func AssessInstance(api string, options TrustOptions) (*TrustAssessment, error) {
+	return defaultCobalt().AssessInstance(api, options)
+}
+
+// AssessInstance assesses the instance at api using the http client of this Cobalt, see the package level AssessInstance.
+func (c *Cobalt) AssessInstance(api string, options TrustOptions) (*TrustAssessment, error) {
+	if !strings.HasPrefix(api, "http") {
+		api = "https://" + api
+	}
+
+	res, err := c.genericHttpRequest(api, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := c.readBody(res)
+	if err != nil {
+		return nil, err
+	}
+	var info ServerInfo
+	infoErr := c.decodeInstanceJSON(res, body, &info)
+
+	assessment := &TrustAssessment{API: api}
+	assessment.Checks = append(assessment.Checks, checkTLS(res), checkHeaders(res))
+	if infoErr != nil {
+		assessment.Checks = append(assessment.Checks, TrustCheck{Name: "version", Weight: 2, Detail: "invalid server info: " + infoErr.Error()})
+	} else {
+		assessment.Checks = append(assessment.Checks, checkVersion(api, &info))
+	}
+
+	if options.VerifyCommit && infoErr == nil {
+		assessment.Checks = append(assessment.Checks, c.checkCommit(&info))
+	} else {
+		assessment.Checks = append(assessment.Checks, TrustCheck{Name: "commit", Weight: 2, Skipped: true, Detail: "not enabled"})
+	}
+
+	if options.TestURL != "" {
+		assessment.Checks = append(assessment.Checks, c.checkTestJob(api, options.TestURL)...)
+	} else {
+		assessment.Checks = append(assessment.Checks,
+			TrustCheck{Name: "filename", Weight: 2, Skipped: true, Detail: "no test url"},
+			TrustCheck{Name: "sizes", Weight: 1, Skipped: true, Detail: "no test url"})
+	}
+
+	var passed, total float64
+	for _, check := range assessment.Checks {
+		if check.Skipped {
+			continue
+		}
+		total += check.Weight
+		if check.Passed {
+			passed += check.Weight
+		}
+	}
+	if total > 0 {
+		assessment.Score = passed / total
+	}
+
+	return assessment, nil
+}
+
+func checkTLS(res *http.Response) TrustCheck {
+	check := TrustCheck{Name: "tls", Weight: 3}
+	switch {
+	case res.TLS == nil:
+		check.Detail = "served over plain http"
+	case len(res.TLS.VerifiedChains) == 0:
+		check.Detail = "certificate chain was not verified"
+	default:
+		check.Passed = true
+	}
+	return check
+}
+
+func checkHeaders(res *http.Response) TrustCheck {
+	check := TrustCheck{Name: "headers", Weight: 1}
+	var problems []string
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "application/json" {
+		problems = append(problems, fmt.Sprintf("content type is %q instead of application/json", res.Header.Get("Content-Type")))
+	}
+	if res.TLS != nil && res.Header.Get("Strict-Transport-Security") == "" {
+		problems = append(problems, "no Strict-Transport-Security header")
+	}
+	check.Passed = len(problems) == 0
+	check.Detail = strings.Join(problems, ", ")
+	return check
+}
+
+// checkVersion looks for version information that makes sense: a v10+ version, a commit hash, a repository name and the instance url matching.
+func checkVersion(api string, info *ServerInfo) TrustCheck {
+	check := TrustCheck{Name: "version", Weight: 2}
+	var problems []string
+	if info.Cobalt.Version == "" || !version.Compare(info.Cobalt.Version, "10.0.0", ">=") {
+		problems = append(problems, fmt.Sprintf("unexpected version %q", info.Cobalt.Version))
+	}
+	if !gitCommitHash.MatchString(info.Git.Commit) {
+		problems = append(problems, fmt.Sprintf("invalid commit %q", info.Git.Commit))
+	}
+	if !gitRemoteName.MatchString(info.Git.Remote) {
+		problems = append(problems, fmt.Sprintf("invalid remote %q", info.Git.Remote))
+	}
+	apiURL, apiErr := url.Parse(api)
+	reportedURL, reportedErr := url.Parse(info.Cobalt.URL)
+	if apiErr != nil || reportedErr != nil || !strings.EqualFold(apiURL.Hostname(), reportedURL.Hostname()) {
+		problems = append(problems, fmt.Sprintf("reported url %q doesn't match %q", info.Cobalt.URL, api))
+	}
+	check.Passed = len(problems) == 0
+	check.Detail = strings.Join(problems, ", ")
+	return check
+}
+
+// checkCommit compares the reported version with the version in api/package.json at the reported commit.
+func (c *Cobalt) checkCommit(info *ServerInfo) TrustCheck {
+	check := TrustCheck{Name: "commit", Weight: 2}
+	if !gitCommitHash.MatchString(info.Git.Commit) || !gitRemoteName.MatchString(info.Git.Remote) {
+		check.Detail = "no valid commit or remote reported"
+		return check
+	}
+
+	res, err := c.genericHttpRequest(fmt.Sprintf("https://raw.githubusercontent.com/%v/%v/api/package.json", info.Git.Remote, info.Git.Commit), http.MethodGet, nil)
+	if err != nil {
+		//Private forks, rate limits and network errors don't make the instance less trustworthy.
+		check.Skipped = true
+		check.Detail = fmt.Sprintf("couldn't fetch package.json: %v", err)
+		return check
+	}
+	defer res.Body.Close()
+
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	body, err := c.readBody(res)
+	if err == nil {
+		err = json.Unmarshal(body, &pkg)
+	}
+	if err != nil {
+		check.Skipped = true
+		check.Detail = fmt.Sprintf("couldn't parse package.json: %v", err)
+		return check
+	}
+	check.Passed = pkg.Version == info.Cobalt.Version
+	if !check.Passed {
+		check.Detail = fmt.Sprintf("commit %v is version %v, instance reports %v", info.Git.Commit, pkg.Version, info.Cobalt.Version)
+	}
+	return check
+}
+
+// checkTestJob submits testURL to the instance, checks the returned filename for injected content and compares
+// the Content-Length of a HEAD request with the size in Content-Range of a ranged request.
+func (c *Cobalt) checkTestJob(api, testURL string) []TrustCheck {
+	filename := TrustCheck{Name: "filename", Weight: 2}
+	sizes := TrustCheck{Name: "sizes", Weight: 1}
+
+	settings := CreateDefaultSettings()
+	settings.Url = testURL
+	media, err := c.run(context.Background(), api, settings)
+	if err != nil {
+		filename.Skipped, sizes.Skipped = true, true
+		filename.Detail = fmt.Sprintf("test job failed: %v", err)
+		sizes.Detail = filename.Detail
+		return []TrustCheck{filename, sizes}
+	}
+
+	if injected := filenameInjection.FindString(media.Filename); injected != "" {
+		filename.Detail = fmt.Sprintf("filename %q contains %q", media.Filename, injected)
+	} else {
+		filename.Passed = true
+	}
+
+	if media.URL == "" {
+		sizes.Skipped, sizes.Detail = true, "no single file url in the response"
+		return []TrustCheck{filename, sizes}
+	}
+	head, headErr := c.genericHttpRequest(media.URL, http.MethodHead, nil)
+	ranged, rangedErr := c.rangedHttpRequest(context.Background(), media.URL, nil, 0, 0)
+	if headErr != nil || rangedErr != nil || head.Header.Get("Content-Length") == "" || ranged.StatusCode != http.StatusPartialContent {
+		sizes.Skipped, sizes.Detail = true, "the file server doesn't support HEAD and range requests"
+	} else if headSize, rangedSize := head.Header.Get("Content-Length"), sizeFromContentRange(ranged.Header.Get("Content-Range")); headSize != rangedSize {
+		sizes.Detail = fmt.Sprintf("HEAD reports %v bytes, range request reports %v bytes", headSize, rangedSize)
+	} else {
+		sizes.Passed = true
+	}
+	if headErr == nil {
+		head.Body.Close()
+	}
+	if rangedErr == nil {
+		ranged.Body.Close()
+	}
+
+	return []TrustCheck{filename, sizes}
+}
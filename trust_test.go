@@ -0,0 +1,70 @@
+package gobalt
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAssessInstance(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/file":
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(make([]byte, 2048)))
+		case r.Method == http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"status":"tunnel","url":"%v/file","filename":"Song - Artist (get it at www.bestvpn.com).mp3"}`, server.URL)
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+			fmt.Fprintf(w, `{"cobalt":{"version":"10.5.4","url":"%v/"},"git":{"branch":"main","commit":"6a1cb0a1d4f4b5a9f7c4f0d8b3e2a1c0f9e8d7c6","remote":"imputnet/cobalt"}}`, server.URL)
+		}
+	}))
+	defer server.Close()
+
+	oldTransport := Client.Transport
+	Client.Transport = server.Client().Transport
+	defer func() { Client.Transport = oldTransport }()
+
+	assessment, err := AssessInstance(server.URL, TrustOptions{TestURL: "https://soundcloud.com/artist/song"})
+	if err != nil {
+		t.Fatalf("failed assessing instance: %v", err)
+	}
+
+	expected := map[string]bool{"tls": true, "headers": true, "version": true, "filename": false, "sizes": true}
+	for _, check := range assessment.Checks {
+		passed, ok := expected[check.Name]
+		if !ok {
+			if !check.Skipped {
+				t.Errorf("expected check %v to be skipped", check.Name)
+			}
+			continue
+		}
+		if check.Skipped || check.Passed != passed {
+			t.Errorf("check %v: passed %v, skipped %v (%v), expected passed %v", check.Name, check.Passed, check.Skipped, check.Detail, passed)
+		}
+	}
+	if assessment.Score <= 0.5 || assessment.Score >= 1 {
+		t.Errorf("unexpected score %v", assessment.Score)
+	}
+}
+
+func TestAssessInstancePlainHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html>not cobalt</html>`))
+	}))
+	defer server.Close()
+
+	assessment, err := AssessInstance(server.URL, TrustOptions{})
+	if err != nil {
+		t.Fatalf("failed assessing instance: %v", err)
+	}
+	if assessment.Score != 0 {
+		t.Fatalf("expected a score of 0, got %v: %+v", assessment.Score, assessment.Checks)
+	}
+}
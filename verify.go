@@ -0,0 +1,54 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Checking the urls returned by cobalt, some instances hand out tunnel urls that are dead before they're used.
+
+// ErrDeadURL is returned by Run when URL verification is enabled and the url returned by the instance doesn't work, see WithURLVerification.
+var ErrDeadURL = errors.New("the instance returned a dead url")
+
+// WithURLVerification makes Run check the url of tunnel and redirect responses with a ranged GET of a single byte.
+// Dead urls are retried by submitting the job again, up to retries times, then Run fails with ErrDeadURL. Default: disabled.
+func WithURLVerification(retries int) Option {
+	return func(c *Cobalt) {
+		c.verifyURLs = true
+		c.verifyRetries = max(retries, 0)
+	}
+}
+
+// verifyJob checks the url of media, submitting the job again while it's dead and there are retries left.
+func (c *Cobalt) verifyJob(ctx context.Context, api string, options Settings, info *ServerInfo, media *CobaltResponse) (*CobaltResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if !media.IsTunnel() && !media.IsRedirect() {
+			return media, nil
+		}
+		err := c.verifyURL(ctx, media.URL)
+		if err == nil {
+			return media, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= c.verifyRetries {
+			return nil, fmt.Errorf("%w: %v", ErrDeadURL, err)
+		}
+		media, err = c.runJob(ctx, api, options, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// verifyURL requests the first byte of url, an error if it couldn't be fetched.
+func (c *Cobalt) verifyURL(ctx context.Context, url string) error {
+	res, err := c.rangedHttpRequest(ctx, url, nil, 0, 0)
+	if err != nil {
+		return fmt.Errorf("%v: %w", url, err)
+	}
+	res.Body.Close()
+	return nil
+}
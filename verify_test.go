@@ -0,0 +1,52 @@
+package gobalt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestURLVerification(t *testing.T) {
+	var jobs atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tunnel/dead":
+			http.NotFound(w, r)
+		case r.URL.Path == "/tunnel/alive":
+			w.Write([]byte("media"))
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cobalt":{"version":"10.5.4"},"git":{}}`))
+		default:
+			//The first job gets a dead url.
+			file := "alive"
+			if jobs.Add(1) == 1 {
+				file = "dead"
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"status":"tunnel","url":"%v/tunnel/%v","filename":"file.mp4"}`, server.URL, file)
+		}
+	}))
+	defer server.Close()
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	media, err := New(WithAPI(server.URL), WithURLVerification(1)).Run(settings)
+	if err != nil || media.URL != server.URL+"/tunnel/alive" || jobs.Load() != 2 {
+		t.Errorf("expected the job to be retried for a live url, got %+v, %v after %v jobs", media, err, jobs.Load())
+	}
+
+	jobs.Store(0)
+	if _, err := New(WithAPI(server.URL), WithURLVerification(0)).Run(settings); !errors.Is(err, ErrDeadURL) || jobs.Load() != 1 {
+		t.Errorf("expected ErrDeadURL without retries, got %v after %v jobs", err, jobs.Load())
+	}
+
+	jobs.Store(0)
+	if media, err := New(WithAPI(server.URL)).Run(settings); err != nil || media.URL != server.URL+"/tunnel/dead" {
+		t.Errorf("expected urls not to be verified by default, got %+v, %v", media, err)
+	}
+}
@@ -0,0 +1,56 @@
+package gobalt
+
+import (
+	"encoding/json"
+
+	"github.com/mcuadros/go-version"
+)
+
+// Cobalt instances reject requests with fields they don't know, so Run shapes the request for the version of the instance.
+
+// settingsField is the range of cobalt versions supporting a request field, empty for no limit. Until is exclusive.
+type settingsField struct {
+	since, until string
+	renamed      string //Name of the field in the versions after until.
+}
+
+// latestCobaltVersion is the newest cobalt version gobalt knows, requests are shaped for it when the instance version isn't known.
+const latestCobaltVersion = "11.0"
+
+// Fields of Settings not supported by every cobalt 10+ version. Fields not listed are supported by all of them.
+var settingsFields = map[string]settingsField{
+	"youtubeDubBrowserLang": {until: "11.0"},
+	"tiktokH265":            {until: "11.0", renamed: "allowH265"},
+	"twitterGif":            {until: "11.0", renamed: "convertGif"},
+	"subtitleLang":          {since: "11.0"},
+}
+
+// shapeSettings marshals options, dropping and renaming the fields for the instance version.
+// The fields are sent as they are if the version is unknown.
+func shapeSettings(options Settings, instanceVersion string) ([]byte, error) {
+	body, err := json.Marshal(options)
+	if err != nil || instanceVersion == "" {
+		return body, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		field, ok := settingsFields[name]
+		if !ok {
+			continue
+		}
+		if field.since != "" && version.Compare(instanceVersion, field.since, "<") {
+			delete(fields, name)
+		}
+		if field.until != "" && version.Compare(instanceVersion, field.until, ">=") {
+			delete(fields, name)
+			if field.renamed != "" {
+				fields[field.renamed] = value
+			}
+		}
+	}
+	return json.Marshal(fields)
+}
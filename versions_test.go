@@ -0,0 +1,69 @@
+package gobalt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShapeSettings(t *testing.T) {
+	settings := CreateDefaultSettings()
+	settings.Url = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	settings.TikTokH265 = true
+
+	tests := []struct {
+		version  string
+		present  []string
+		absent   []string
+		renamed  string
+		expected any
+	}{
+		{"", []string{"youtubeDubBrowserLang", "tiktokH265", "twitterGif"}, nil, "", nil},
+		{"10.5.4", []string{"youtubeDubBrowserLang", "tiktokH265", "twitterGif", "downloadMode"}, []string{"allowH265", "convertGif"}, "", nil},
+		{"11.2", []string{"allowH265", "convertGif", "downloadMode"}, []string{"youtubeDubBrowserLang", "tiktokH265", "twitterGif"}, "allowH265", true},
+	}
+	for _, test := range tests {
+		body, err := shapeSettings(settings, test.version)
+		if err != nil {
+			t.Fatalf("version %q: %v", test.version, err)
+		}
+		var fields map[string]any
+		json.Unmarshal(body, &fields)
+		for _, name := range test.present {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("version %q: expected field %v", test.version, name)
+			}
+		}
+		for _, name := range test.absent {
+			if _, ok := fields[name]; ok {
+				t.Errorf("version %q: unexpected field %v", test.version, name)
+			}
+		}
+		if test.renamed != "" && fields[test.renamed] != test.expected {
+			t.Errorf("version %q: expected %v to keep the value %v, got %v", test.version, test.renamed, test.expected, fields[test.renamed])
+		}
+	}
+}
+
+func TestSettingsExtra(t *testing.T) {
+	options := CreateDefaultSettings()
+	options.Url = "https://example.com/video"
+	options.Extra = map[string]any{"subtitleLang": "pt", "localProcessing": true, "videoQuality": "144"}
+
+	body, err := shapeSettings(options, "11.0")
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var fields map[string]any
+	json.Unmarshal(body, &fields)
+	if fields["subtitleLang"] != "pt" || fields["localProcessing"] != true {
+		t.Errorf("expected the extra fields to be sent, got %s", body)
+	}
+	if fields["videoQuality"] != "1080" || fields["allowH265"] != false {
+		t.Errorf("expected the known fields to take precedence, got %s", body)
+	}
+
+	options.Extra = map[string]any{"invalid": make(chan int)}
+	if _, err := json.Marshal(options); err == nil {
+		t.Errorf("expected an error for an extra field that can't be marshaled")
+	}
+}
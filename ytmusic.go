@@ -0,0 +1,238 @@
+package gobalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// YouTube Music playlists and albums, read from the api of the music web client (WEB_REMIX), which has the album and track
+// metadata the playlist api doesn't. Albums are OLAK5uy_ playlists, or MPREb_ browse pages.
+
+// youtubeMusicAPI is the browse endpoint of the music web client.
+var youtubeMusicAPI = "https://music.youtube.com/youtubei/v1/browse?prettyPrint=false"
+
+var youtubeMusicClient = innertubeClient{api: &youtubeMusicAPI, name: "WEB_REMIX", version: "1.20241118.01.00", origin: "https://music.youtube.com"}
+
+// MusicPlaylist is a YouTube Music playlist or album.
+type MusicPlaylist struct {
+	ID      string       //Playlist id, or the browse id of the album.
+	Title   string       //Title of the playlist or album.
+	Artist  string       //Artist of the album, or author of the playlist.
+	IsAlbum bool         //If it's an album, with track numbers.
+	Tracks  []MusicTrack //Tracks, in order.
+}
+
+// MusicTrack is a track of a YouTube Music playlist or album.
+type MusicTrack struct {
+	URL     string //music.youtube.com url of the track.
+	VideoID string //YouTube video id.
+	Title   string //Title of the track.
+	Artist  string //Artist of the track, the album artist for albums.
+	Album   string //Album of the track, if known.
+	Index   int    //Track number in the album, 0 for playlists.
+}
+
+// URLs returns the urls of the tracks, as a Playlist.
+func (p *MusicPlaylist) URLs() Playlist {
+	urls := make(Playlist, len(p.Tracks))
+	for i, track := range p.Tracks {
+		urls[i] = track.URL
+	}
+	return urls
+}
+
+// GetYoutubeMusicPlaylist gets a YouTube Music playlist or album with its metadata, from urls like
+// music.youtube.com/playlist?list=OLAK5uy_..., music.youtube.com/browse/MPREb_... or music.youtube.com/watch?v=...&list=....
+func GetYoutubeMusicPlaylist(playlist string) (*MusicPlaylist, error) {
+	return defaultCobalt().MusicPlaylist(playlist)
+}
+
+// MusicPlaylist gets a YouTube Music playlist or album using the http client of this Cobalt, see GetYoutubeMusicPlaylist.
+func (c *Cobalt) MusicPlaylist(playlist string) (*MusicPlaylist, error) {
+	browseID, err := youtubeMusicBrowseID(playlist)
+	if err != nil {
+		return nil, err
+	}
+	result := &MusicPlaylist{ID: strings.TrimPrefix(browseID, "VL"), IsAlbum: isMusicAlbum(browseID)}
+
+	first := true
+	for page, err := range c.browsePages(context.Background(), youtubeMusicClient, browseID) {
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			if page.header == nil {
+				return nil, fmt.Errorf("%w: no playlist in the youtube music response for %v", ErrUnexpectedResponse, playlist)
+			}
+			result.Title, result.Artist = musicHeader(page.header)
+			first = false
+		}
+		for _, item := range page.items {
+			if track, ok := musicTrack(item, result); ok {
+				result.Tracks = append(result.Tracks, track)
+			}
+		}
+	}
+	return result, nil
+}
+
+func isMusicAlbum(browseID string) bool {
+	return strings.HasPrefix(browseID, "MPREb_") || strings.HasPrefix(browseID, "VLOLAK5uy_")
+}
+
+// youtubeMusicBrowseID returns the browse id of a YouTube Music (or YouTube) playlist or album url.
+func youtubeMusicBrowseID(playlist string) (string, error) {
+	u, err := url.Parse(playlist)
+	if err != nil {
+		return "", err
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "music.youtube.com" && host != "youtube.com" && host != "m.youtube.com" {
+		return "", errors.New("non youtube music playlist url provided")
+	}
+	if id, ok := strings.CutPrefix(u.Path, "/browse/"); ok && strings.HasPrefix(id, "MPREb_") {
+		return id, nil
+	}
+	if list := u.Query().Get("list"); list != "" && (u.Path == "/playlist" || u.Path == "/watch") {
+		return "VL" + list, nil
+	}
+	return "", errors.New("non youtube music playlist url provided")
+}
+
+// musicWalk finds the header, tracks (or videos, for the YouTube web client) and continuation token anywhere in a response,
+// the web clients move them around between versions and between the first page and continuations.
+type musicWalk struct {
+	header       map[string]any
+	items        []map[string]any
+	videos       []map[string]any //playlistVideoRenderer of the YouTube web client.
+	results      []searchItem     //Search results of the YouTube web client.
+	continuation string
+}
+
+func (w *musicWalk) walk(node any) {
+	switch node := node.(type) {
+	case []any:
+		for _, child := range node {
+			w.walk(child)
+		}
+	case map[string]any:
+		for key, child := range node {
+			switch key {
+			case "musicResponsiveHeaderRenderer", "musicDetailHeaderRenderer", "musicEditablePlaylistDetailHeaderRenderer":
+				if header, ok := child.(map[string]any); ok && w.header == nil {
+					w.header = header
+				}
+			case "musicResponsiveListItemRenderer":
+				if item, ok := child.(map[string]any); ok {
+					w.items = append(w.items, item)
+				}
+				continue
+			case "playlistVideoRenderer":
+				if video, ok := child.(map[string]any); ok {
+					w.videos = append(w.videos, video)
+				}
+				continue
+			case "videoRenderer", "playlistRenderer", "channelRenderer":
+				if result, ok := child.(map[string]any); ok {
+					w.results = append(w.results, searchItem{kind: key, data: result})
+				}
+				continue
+			case "continuationCommand":
+				if token := musicString(child, "token"); token != "" {
+					w.continuation = token
+				}
+			case "nextContinuationData":
+				if token := musicString(child, "continuation"); token != "" {
+					w.continuation = token
+				}
+			}
+			w.walk(child)
+		}
+	}
+}
+
+// musicHeader returns the title and artist (or author) from the header of a playlist or album page.
+func musicHeader(header map[string]any) (title, artist string) {
+	//The editable header of playlists wraps the detail header.
+	if inner, ok := musicPath(header, "header", "musicDetailHeaderRenderer").(map[string]any); ok {
+		header = inner
+	}
+	title = musicText(header["title"])
+	if artist = musicText(header["straplineTextOne"]); artist != "" {
+		return title, artist
+	}
+	//Older detail headers have "Album • Artist • 2020" as subtitle.
+	runs, _ := musicPath(header, "subtitle", "runs").([]any)
+	if len(runs) >= 3 {
+		artist = musicString(runs[2], "text")
+	}
+	return title, artist
+}
+
+// musicTrack reads a track from a list item, false for items that aren't playable tracks.
+func musicTrack(item map[string]any, playlist *MusicPlaylist) (MusicTrack, bool) {
+	videoID := musicString(item["playlistItemData"], "videoId")
+	columns, _ := item["flexColumns"].([]any)
+	column := func(i int) any {
+		if i >= len(columns) {
+			return nil
+		}
+		return musicPath(columns[i], "musicResponsiveListItemFlexColumnRenderer", "text")
+	}
+	if videoID == "" {
+		runs, _ := musicPath(column(0), "runs").([]any)
+		if len(runs) > 0 {
+			videoID = musicString(musicPath(runs[0], "navigationEndpoint", "watchEndpoint"), "videoId")
+		}
+	}
+	if videoID == "" {
+		return MusicTrack{}, false
+	}
+
+	track := MusicTrack{
+		URL:     "https://music.youtube.com/watch?v=" + videoID,
+		VideoID: videoID,
+		Title:   musicText(column(0)),
+		Artist:  musicText(column(1)),
+		Album:   musicText(column(2)),
+	}
+	if playlist.IsAlbum {
+		track.Album = playlist.Title
+		if track.Artist == "" {
+			track.Artist = playlist.Artist
+		}
+		track.Index, _ = strconv.Atoi(musicText(item["index"]))
+	}
+	return track, true
+}
+
+// musicPath follows keys through nested objects, nil if one is missing.
+func musicPath(node any, keys ...string) any {
+	for _, key := range keys {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = object[key]
+	}
+	return node
+}
+
+func musicString(node any, key string) string {
+	s, _ := musicPath(node, key).(string)
+	return s
+}
+
+// musicText joins the runs of a text object, like {"runs":[{"text":"Artist"},{"text":" & "},{"text":"Other"}]}.
+func musicText(node any) string {
+	runs, _ := musicPath(node, "runs").([]any)
+	var text strings.Builder
+	for _, run := range runs {
+		text.WriteString(musicString(run, "text"))
+	}
+	return text.String()
+}
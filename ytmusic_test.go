@@ -0,0 +1,80 @@
+package gobalt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func musicItem(videoID, title, artist string, index int) string {
+	return fmt.Sprintf(`{"musicResponsiveListItemRenderer":{"index":{"runs":[{"text":"%v"}]},"playlistItemData":{"videoId":"%v"},"flexColumns":[
+		{"musicResponsiveListItemFlexColumnRenderer":{"text":{"runs":[{"text":"%v","navigationEndpoint":{"watchEndpoint":{"videoId":"%v"}}}]}}},
+		{"musicResponsiveListItemFlexColumnRenderer":{"text":{"runs":[{"text":"%v"}]}}}]}}`, index, videoID, title, videoID, artist)
+}
+
+func TestMusicPlaylist(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if request["continuation"] == "page2" {
+			fmt.Fprintf(w, `{"onResponseReceivedActions":[{"appendContinuationItemsAction":{"continuationItems":[%v]}}]}`, musicItem("ccccccccccc", "Third", "", 3))
+			return
+		}
+		fmt.Fprintf(w, `{"contents":{"twoColumnBrowseResultsRenderer":{
+			"tabs":[{"tabRenderer":{"content":{"sectionListRenderer":{"contents":[{"musicResponsiveHeaderRenderer":{
+				"title":{"runs":[{"text":"The Album"}]},"straplineTextOne":{"runs":[{"text":"The Artist"}]}}}]}}}}],
+			"secondaryContents":{"sectionListRenderer":{"contents":[{"musicShelfRenderer":{"contents":[%v,%v,
+				{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page2"}}}}]}}]}}}}}`,
+			musicItem("aaaaaaaaaaa", "First", "", 1), musicItem("bbbbbbbbbbb", "Second", "Guest", 2))
+	}))
+	defer server.Close()
+	oldAPI := youtubeMusicAPI
+	youtubeMusicAPI = server.URL
+	defer func() { youtubeMusicAPI = oldAPI }()
+
+	album, err := GetYoutubeMusicPlaylist("https://music.youtube.com/playlist?list=OLAK5uy_abcdef")
+	if err != nil {
+		t.Fatalf("failed getting the album: %v", err)
+	}
+	if requests[0]["browseId"] != "VLOLAK5uy_abcdef" || len(requests) != 2 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+	if !album.IsAlbum || album.Title != "The Album" || album.Artist != "The Artist" || len(album.Tracks) != 3 {
+		t.Fatalf("unexpected album %+v", album)
+	}
+	expected := []MusicTrack{
+		{URL: "https://music.youtube.com/watch?v=aaaaaaaaaaa", VideoID: "aaaaaaaaaaa", Title: "First", Artist: "The Artist", Album: "The Album", Index: 1},
+		{URL: "https://music.youtube.com/watch?v=bbbbbbbbbbb", VideoID: "bbbbbbbbbbb", Title: "Second", Artist: "Guest", Album: "The Album", Index: 2},
+		{URL: "https://music.youtube.com/watch?v=ccccccccccc", VideoID: "ccccccccccc", Title: "Third", Artist: "The Artist", Album: "The Album", Index: 3},
+	}
+	for i, track := range album.Tracks {
+		if track != expected[i] {
+			t.Errorf("track %v is %+v, expected %+v", i, track, expected[i])
+		}
+	}
+
+	urls, err := GetYoutubePlaylist("https://music.youtube.com/browse/MPREb_xyz")
+	if err != nil || len(urls) != 3 || urls[0] != expected[0].URL {
+		t.Errorf("expected GetYoutubePlaylist to read music albums, got %v, %v", urls, err)
+	}
+}
+
+func TestYoutubeMusicBrowseID(t *testing.T) {
+	tests := map[string]string{
+		"https://music.youtube.com/playlist?list=PLabc":          "VLPLabc",
+		"https://music.youtube.com/watch?v=abc&list=OLAK5uy_xyz": "VLOLAK5uy_xyz",
+		"https://music.youtube.com/browse/MPREb_abc":             "MPREb_abc",
+		"https://www.youtube.com/playlist?list=OLAK5uy_xyz":      "VLOLAK5uy_xyz",
+		"https://music.youtube.com/channel/UCabc":                "",
+		"https://example.com/playlist?list=PLabc":                "",
+	}
+	for playlist, expected := range tests {
+		if id, _ := youtubeMusicBrowseID(playlist); id != expected {
+			t.Errorf("youtubeMusicBrowseID(%v) = %q, expected %q", playlist, id, expected)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gobalt
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Zip archives of multi file results, like the cobalt web app offers for pickers.
+
+// DownloadZip downloads the media of responses into a zip archive, see the method.
+func DownloadZip(ctx context.Context, w io.Writer, responses []*CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	return defaultCobalt().DownloadZip(ctx, w, responses, options)
+}
+
+// DownloadZip downloads the media of responses (tunnels, redirects or pickers, with their background audio) into a zip archive
+// written to w, like a file or an http.ResponseWriter. Files are named like Download names them, and picker items like DownloadPicker
+// names them, with " (2)" added to repeated names. options.Filename and options.PostProcessors are ignored.
+// The result has the size of the archive and no Path.
+func (c *Cobalt) DownloadZip(ctx context.Context, w io.Writer, responses []*CobaltResponse, options DownloadOptions) (*DownloadResult, error) {
+	counter := &countingWriter{w: w}
+	archive := zip.NewWriter(counter)
+	names := map[string]bool{}
+
+	for _, media := range responses {
+		if media == nil {
+			continue
+		}
+		jobs := []pickerJob{{label: media.URL, media: media, options: options}}
+		if media.IsPicker() && media.Picker != nil {
+			jobs = pickerJobs(media, options)
+		} else if media.URL == "" {
+			return nil, errors.New("a response has no url to download")
+		} else {
+			jobs[0].options.Filename = ""
+		}
+		for _, job := range jobs {
+			if err := c.zipEntry(ctx, archive, job, names); err != nil {
+				return nil, fmt.Errorf("failed to download %v: %w", job.label, err)
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return &DownloadResult{Size: counter.written, ContentType: "application/zip"}, nil
+}
+
+// zipEntry streams the media of job into a new file of the archive.
+func (c *Cobalt) zipEntry(ctx context.Context, archive *zip.Writer, job pickerJob, names map[string]bool) error {
+	stream, err := c.OpenStreamContext(ctx, job.media.URL, job.options)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	name := SanitizeFilename(downloadName(job.media, stream, job.options), job.options.Sanitize)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; names[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%v (%v)%v", base, i, ext)
+	}
+	names[strings.ToLower(name)] = true
+
+	//Media is already compressed, it's only stored.
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = writeStream(ctx, entry, stream, job.options.Progress)
+	return err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.written += int64(n)
+	return n, err
+}
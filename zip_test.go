@@ -0,0 +1,99 @@
+package gobalt
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %v: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestDownloadZip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("media " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	picker := []PickerItem{{Type: Photo, URL: server.URL + "/1"}, {Type: Photo, URL: server.URL + "/2"}}
+	responses := []*CobaltResponse{
+		{Status: "picker", Picker: &picker},
+		{Status: "tunnel", URL: server.URL + "/3", Filename: "cover.jpg"},
+		{Status: "redirect", URL: server.URL + "/4", Filename: "cover.jpg"},
+	}
+	var buffer bytes.Buffer
+	result, err := DownloadZip(context.Background(), &buffer, responses, DownloadOptions{Fields: map[string]string{"title": "Set"}, Filename: "ignored.zip"})
+	if err != nil {
+		t.Fatalf("failed to create the archive: %v", err)
+	}
+	if result.Size != int64(buffer.Len()) || result.ContentType != "application/zip" {
+		t.Errorf("unexpected result %+v for %v bytes", result, buffer.Len())
+	}
+
+	files := readZip(t, buffer.Bytes())
+	expected := map[string]string{"Set - 1 (photo).jpg": "media /1", "Set - 2 (photo).jpg": "media /2", "cover.jpg": "media /3", "cover (2).jpg": "media /4"}
+	if len(files) != len(expected) {
+		t.Errorf("expected %v files, got %v", len(expected), files)
+	}
+	for name, content := range expected {
+		if files[name] != content {
+			t.Errorf("expected %v to contain %q, got %q", name, content, files[name])
+		}
+	}
+
+	if _, err := DownloadZip(context.Background(), io.Discard, []*CobaltResponse{{Status: "error"}}, DownloadOptions{}); err == nil {
+		t.Error("expected an error for a response without url")
+	}
+}
+
+func TestDownloadPickerZip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	picker := []PickerItem{{Type: Photo, URL: server.URL + "/1"}, {Type: Photo, URL: server.URL + "/2"}}
+	dir := t.TempDir()
+	results, err := DownloadPicker(context.Background(), &CobaltResponse{Status: "picker", Picker: &picker}, DownloadOptions{Directory: dir, Zip: true, Fields: map[string]string{"title": "Album"}})
+	if err != nil {
+		t.Fatalf("failed to download the picker: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != filepath.Join(dir, "Album.zip") {
+		t.Fatalf("expected a single archive, got %+v", results)
+	}
+	data, err := os.ReadFile(results[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := readZip(t, data)
+	if files["Album - 1 (photo).jpg"] != "media" || files["Album - 2 (photo).jpg"] != "media" {
+		t.Errorf("unexpected archive contents %v", files)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the archive in the directory, got %v", entries)
+	}
+}